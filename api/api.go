@@ -22,6 +22,8 @@ func (a *Attorney) ApiHandler(w http.ResponseWriter, r *http.Request) {
 	var actionArray []actions.Action
 	var err error
 	fmt.Println(r.FormValue("action"))
+	// forms resolve handles to tokens
+	a.state.RLock()
 	switch r.FormValue("action") {
 	case "BoardEditor":
 		actionArray, err = BoardEditorForm(r, a.state.MembersIndex, a.author).ToAction()
@@ -58,6 +60,7 @@ func (a *Attorney) ApiHandler(w http.ResponseWriter, r *http.Request) {
 	case "Vote":
 		actionArray, err = VoteForm(r).ToAction()
 	}
+	a.state.RUnlock()
 	if err == nil && len(actionArray) > 0 {
 		a.Send(actionArray)
 	}
@@ -125,8 +128,10 @@ func FormToBool(r *http.Request, field string) bool {
 
 func FormToPolicy(r *http.Request) Policy {
 	return Policy{
-		Majority:      FormToI(r, "policyMajority"),
-		SuperMajority: FormToI(r, "policySupermajority"),
+		Majority:       FormToI(r, "policyMajority"),
+		SuperMajority:  FormToI(r, "policySupermajority"),
+		Quorum:         FormToI(r, "policyQuorum"),
+		AcceptOnExpiry: FormToBool(r, "policyAcceptOnExpiry"),
 	}
 }
 
@@ -160,6 +165,16 @@ func CancelEventForm(r *http.Request) CancelEvent {
 	return action
 }
 
+func ChangeHandleForm(r *http.Request) ChangeHandle {
+	action := ChangeHandle{
+		Action:  "ChangeHandle",
+		ID:      FormToI(r, "id"),
+		Reasons: r.FormValue("reasons"),
+		Handle:  r.FormValue("handle"),
+	}
+	return action
+}
+
 func CheckinEventForm(r *http.Request, ephemeralToken crypto.Token) CheckinEvent {
 	action := CheckinEvent{
 		Action:         "CheckinEvent",
@@ -171,6 +186,19 @@ func CheckinEventForm(r *http.Request, ephemeralToken crypto.Token) CheckinEvent
 	return action
 }
 
+func CommentForm(r *http.Request) Comment {
+	action := Comment{
+		Action:     "Comment",
+		ID:         FormToI(r, "id"),
+		Reasons:    r.FormValue("reasons"),
+		OnBehalfOf: r.FormValue("onBehalfOf"),
+		Object:     FormToHash(r, "object"),
+		Parent:     FormToHash(r, "parent"),
+		Content:    r.FormValue("content"),
+	}
+	return action
+}
+
 func CreateBoardForm(r *http.Request) CreateBoard {
 	action := CreateBoard{
 		Action:      "CreateBoard",
@@ -276,6 +304,16 @@ func GreetCheckinEventForm(r *http.Request, handles map[string]crypto.Token) Mul
 	return action
 }
 
+func GrantPowerOfAttorneyForm(r *http.Request) GrantPowerOfAttorney {
+	action := GrantPowerOfAttorney{
+		Action:   "GrantPowerOfAttorney",
+		ID:       FormToI(r, "id"),
+		Reasons:  r.FormValue("reasons"),
+		Attorney: crypto.TokenFromString(r.FormValue("attorney")),
+	}
+	return action
+}
+
 func ImprintStampForm(r *http.Request) ImprintStamp {
 	action := ImprintStamp{
 		Action:     "ImprintStamp",
@@ -347,6 +385,16 @@ func RequestMembershipForm(r *http.Request) RequestMembership {
 	return action
 }
 
+func RevokePowerOfAttorneyForm(r *http.Request) RevokePowerOfAttorney {
+	action := RevokePowerOfAttorney{
+		Action:   "RevokePowerOfAttorney",
+		ID:       FormToI(r, "id"),
+		Reasons:  r.FormValue("reasons"),
+		Attorney: crypto.TokenFromString(r.FormValue("attorney")),
+	}
+	return action
+}
+
 func UpdateBoardForm(r *http.Request) UpdateBoard {
 	action := UpdateBoard{
 		Action:  "UpdateBoard",
@@ -391,6 +439,14 @@ func UpdateCollectiveForm(r *http.Request) UpdateCollective {
 		supermajority := FormToB(r, "supermajority")
 		action.SuperMajority = &supermajority
 	}
+	if s := r.FormValue("quorum"); s != "" {
+		quorum := FormToB(r, "quorum")
+		action.Quorum = &quorum
+	}
+	if s := r.FormValue("acceptOnExpiry"); s != "" {
+		accept := s == "on"
+		action.AcceptOnExpiry = &accept
+	}
 	return action
 }
 
@@ -433,6 +489,18 @@ func VoteForm(r *http.Request) Vote {
 		Reasons: r.FormValue("reasons"),
 		Hash:    FormToHash(r, "hash"),
 		Approve: FormToBool(r, "approve"),
+		Abstain: r.FormValue("approve") == "abstain",
+	}
+	return action
+}
+
+func WithdrawForm(r *http.Request) Withdraw {
+	action := Withdraw{
+		Action:     "Withdraw",
+		ID:         FormToI(r, "id"),
+		Reasons:    r.FormValue("reasons"),
+		OnBehalfOf: r.FormValue("onBehalfOf"),
+		Hash:       FormToHash(r, "hash"),
 	}
 	return action
 }
@@ -4,12 +4,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/crypto/dh"
-	"github.com/lienkolabs/breeze/util"
 	"github.com/lienkolabs/synergy/social"
 	"github.com/lienkolabs/synergy/social/actions"
 	"github.com/lienkolabs/synergy/social/index"
@@ -23,7 +23,7 @@ var templateFiles []string = []string{
 	"createboard", "votecreateboard", "updateboard", "voteupdateboard", "updateevent",
 	"updatecollective", "voteupdatecollective", "createevent", "voteupdateevent", "editview",
 	"createcollective", "connections", "updates", "news", "pending", "mymedia", "myevents",
-	"detailedvote", "votecreateevent", "votecancelevent", "login", "signin",
+	"detailedvote", "votecreateevent", "votecancelevent", "login", "signin", "verify", "sessions",
 }
 
 type Attorney struct {
@@ -33,7 +33,7 @@ type Attorney struct {
 	ephemeralpub crypto.Token
 	wallet       crypto.PrivateKey
 	pending      map[crypto.Hash]actions.Action
-	epoch        uint64
+	epoch        atomic.Uint64
 	gateway      social.Gatewayer
 	state        *state.State
 	templates    *template.Template
@@ -49,7 +49,6 @@ func NewAttorneyServer(pk crypto.PrivateKey, token crypto.Token, port int, gatew
 		pending: make(map[crypto.Hash]actions.Action),
 		gateway: gateway,
 		state:   gateway.State(),
-		epoch:   0,
 		indexer: indexer,
 	}
 	attorney.genesisTime = time.Date(2023, 9, 20, 0, 0, 0, 0, time.UTC)
@@ -60,7 +59,7 @@ func NewAttorneyServer(pk crypto.PrivateKey, token crypto.Token, port int, gatew
 		for {
 			select {
 			case epoch := <-blockEvent:
-				attorney.epoch = epoch
+				attorney.epoch.Store(epoch)
 			case action := <-send:
 				gateway.Action(attorney.DressAction(action))
 			}
@@ -148,20 +147,7 @@ func (a *Attorney) Send(all []actions.Action) {
 // Dress a giving action with current epoch, attorney´s author
 // attorneys signature, attorneys wallet and wallet signature
 func (a *Attorney) DressAction(action actions.Action) []byte {
-	bytes := action.Serialize()
-	dress := []byte{0}
-	util.PutUint64(a.epoch, &dress)
-	util.PutToken(a.author, &dress)
-	dress = append(dress, 0, 1, 0, 0) // axe void synergy
-	dress = append(dress, bytes[8+crypto.TokenSize:]...)
-	util.PutToken(a.pk.PublicKey(), &dress)
-	signature := a.pk.Sign(dress)
-	util.PutSignature(signature, &dress)
-	util.PutToken(a.wallet.PublicKey(), &dress)
-	util.PutUint64(0, &dress) // zero fee
-	signature = a.wallet.Sign(dress)
-	util.PutSignature(signature, &dress)
-	return dress
+	return actions.Dress(action.Serialize(), a.epoch.Load(), a.author, a.pk, a.wallet, 0)
 }
 
 func (a *Attorney) Confirmed(hash crypto.Hash) {
@@ -2,78 +2,261 @@ package api
 
 import (
 	"encoding/hex"
-	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"sync"
 
 	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
 	"github.com/lienkolabs/synergy/social/state"
 )
 
 const cookieSessionDuration = 30 * 24 * 60 * 60 // epochs
 
+/*
+cookies.dat starts with cookiesHeader followed by the version of the format
+and then fixed size slots
+
+	token (32) | cookie (32) | issued (8) | expires (8)
+
+A revoked or expired session has its slot zeroed and reused by the next
+session. Files without header hold only token | cookie and are rewritten on
+open with a fresh window for each session.
+*/
+
+const (
+	cookiesHeader       = "synergy-cookies"
+	cookiesFormat  byte = 1
+	cookieSlotSize      = 2*crypto.Size + 2*8
+	legacySlotSize      = 2 * crypto.Size
+)
+
+// Session is a signed in browser of a member
+type Session struct {
+	Token   crypto.Token
+	Cookie  string
+	Issued  uint64 // epoch
+	Expires uint64 // epoch
+	slot    int64  // position on file
+}
+
+// ID identifies the session on the pages without revealing its cookie
+func (s *Session) ID() string {
+	hash := crypto.Hasher([]byte(s.Cookie))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s *Session) serialize() []byte {
+	data := make([]byte, 0, cookieSlotSize)
+	util.PutToken(s.Token, &data)
+	cookie, _ := hex.DecodeString(s.Cookie)
+	data = append(data, cookie...)
+	util.PutUint64(s.Issued, &data)
+	util.PutUint64(s.Expires, &data)
+	return data
+}
+
 type CookieStore struct {
-	file       *os.File
-	session    map[string]crypto.Token // cookie to token
-	sessionend map[uint64][]string     //epoch to cookies
-	position   map[crypto.Token]int64  // cookie to position on file
+	mu      sync.Mutex
+	file    *os.File
+	session map[string]*Session                  // cookie to session
+	members map[crypto.Token]map[string]struct{} // token to cookies
+	expires map[uint64][]string                  // epoch to cookies
+	free    []int64                              // zeroed slots
+	end     int64                                // position after the last slot
+	cleaned uint64                               // next epoch to be cleaned
 }
 
 func (c *CookieStore) Close() {
 	c.file.Close()
 }
 
+func (c *CookieStore) write(data []byte, position int64) bool {
+	if n, err := c.file.WriteAt(data, position); n != len(data) {
+		log.Printf("unexpected error in cookie store: %v", err)
+		return false
+	}
+	return true
+}
+
+func (c *CookieStore) remove(cookie string) {
+	session, ok := c.session[cookie]
+	if !ok {
+		return
+	}
+	delete(c.session, cookie)
+	if cookies, ok := c.members[session.Token]; ok {
+		delete(cookies, cookie)
+		if len(cookies) == 0 {
+			delete(c.members, session.Token)
+		}
+	}
+	if c.write(make([]byte, cookieSlotSize), session.slot) {
+		c.free = append(c.free, session.slot)
+	}
+}
+
+// Unset revokes the session of the cookie if it belongs to token
 func (c *CookieStore) Unset(token crypto.Token, cookie string) {
-	position, ok := c.position[token]
-	if ok {
-		bytes := make([]byte, crypto.Size)
-		c.file.Seek(position+crypto.Size, 0)
-		if n, err := c.file.Write(bytes); n != len(bytes) {
-			log.Printf("unexpected error in cookie store: %v", err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if session, ok := c.session[cookie]; ok && session.Token.Equal(token) {
+		c.remove(cookie)
+	}
+}
+
+// Revoke ends the session of token with the given ID
+func (c *CookieStore) Revoke(token crypto.Token, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for cookie := range c.members[token] {
+		if c.session[cookie].ID() == id {
+			c.remove(cookie)
+			return true
+		}
+	}
+	return false
+}
+
+// RevokeOthers ends every session of token except the one of cookie
+func (c *CookieStore) RevokeOthers(token crypto.Token, cookie string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for other := range c.members[token] {
+		if other != cookie {
+			c.remove(other)
 		}
 	}
-	delete(c.session, cookie)
 }
 
+// RevokeAll ends every session of token
+func (c *CookieStore) RevokeAll(token crypto.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for cookie := range c.members[token] {
+		c.remove(cookie)
+	}
+}
+
+// Clean ends the sessions that expired up to epoch
 func (c *CookieStore) Clean(epoch uint64) {
-	cookies := c.sessionend[epoch]
-	for _, cookie := range cookies {
-		if token, ok := c.session[cookie]; ok {
-			c.Unset(token, cookie)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ; c.cleaned <= epoch; c.cleaned++ {
+		for _, cookie := range c.expires[c.cleaned] {
+			c.remove(cookie)
 		}
+		delete(c.expires, c.cleaned)
 	}
 }
 
 func (c *CookieStore) Get(cookie string) (crypto.Token, bool) {
-	token, ok := c.session[cookie]
-	return token, ok
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session, ok := c.session[cookie]
+	if !ok {
+		return crypto.ZeroToken, false
+	}
+	return session.Token, true
 }
 
+// Sessions returns copies of the sessions of token, the most recent first
+func (c *CookieStore) Sessions(token crypto.Token) []Session {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sessions := make([]Session, 0, len(c.members[token]))
+	for cookie := range c.members[token] {
+		sessions = append(sessions, *c.session[cookie])
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Issued > sessions[j].Issued
+	})
+	return sessions
+}
+
+// Set opens a new session for token issued at epoch
 func (c *CookieStore) Set(token crypto.Token, cookie string, epoch uint64) bool {
 	bytes, err := hex.DecodeString(cookie)
 	if err != nil || len(bytes) != crypto.Size {
 		return false
 	}
-	c.session[cookie] = token
-	epochEnd := epoch + cookieSessionDuration
-	c.sessionend[epochEnd] = append(c.sessionend[epochEnd], cookie)
-	position, ok := c.position[token]
-	if ok {
-		c.file.Seek(position, 0)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.session[cookie]; ok {
+		return false
+	}
+	session := &Session{Token: token, Cookie: cookie, Issued: epoch, Expires: epoch + cookieSessionDuration}
+	if len(c.free) > 0 {
+		session.slot = c.free[len(c.free)-1]
 	} else {
-		bytes = append(token[:], bytes...) // token + cookie
-		c.file.Seek(0, 2)
+		session.slot = c.end
 	}
-	if n, err := c.file.Write(bytes); n != len(bytes) {
-		log.Printf("unexpected error in cookie store: %v", err)
+	if !c.write(session.serialize(), session.slot) {
 		return false
 	}
+	if len(c.free) > 0 && c.free[len(c.free)-1] == session.slot {
+		c.free = c.free[:len(c.free)-1]
+	} else {
+		c.end += cookieSlotSize
+	}
+	c.add(session)
 	return true
 }
 
+func (c *CookieStore) add(session *Session) {
+	c.session[session.Cookie] = session
+	if _, ok := c.members[session.Token]; !ok {
+		c.members[session.Token] = make(map[string]struct{})
+	}
+	c.members[session.Token][session.Cookie] = struct{}{}
+	c.expires[session.Expires] = append(c.expires[session.Expires], session.Cookie)
+}
+
+// parseCookies returns the sessions on the content of a cookie store file.
+// Legacy files are reported so that they can be rewritten.
+func parseCookies(data []byte, epoch uint64) ([]*Session, bool, bool) {
+	sessions := make([]*Session, 0)
+	if len(data) > len(cookiesHeader) && string(data[:len(cookiesHeader)]) == cookiesHeader {
+		head := len(cookiesHeader) + 1
+		if data[len(cookiesHeader)] != cookiesFormat || (len(data)-head)%cookieSlotSize != 0 {
+			return nil, false, false
+		}
+		for position := head; position < len(data); position += cookieSlotSize {
+			session := &Session{slot: int64(position)}
+			p := position
+			session.Token, p = util.ParseToken(data, p)
+			session.Cookie = hex.EncodeToString(data[p : p+crypto.Size])
+			p += crypto.Size
+			session.Issued, p = util.ParseUint64(data, p)
+			session.Expires, _ = util.ParseUint64(data, p)
+			sessions = append(sessions, session)
+		}
+		return sessions, false, true
+	}
+	if len(data)%legacySlotSize != 0 {
+		return nil, false, false
+	}
+	for position := 0; position < len(data); position += legacySlotSize {
+		var token crypto.Token
+		copy(token[:], data[position:position+crypto.Size])
+		sessions = append(sessions, &Session{
+			Token:   token,
+			Cookie:  hex.EncodeToString(data[position+crypto.Size : position+legacySlotSize]),
+			Issued:  epoch,
+			Expires: epoch + cookieSessionDuration,
+		})
+	}
+	return sessions, true, true
+}
+
+func emptySlot(s *Session) bool {
+	return s.Token.Equal(crypto.ZeroToken) || s.Cookie == hex.EncodeToString(make([]byte, crypto.Size))
+}
+
 func OpenCokieStore(path string, s *state.State) *CookieStore {
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
 	if err != nil {
 		log.Fatalf("could not open cookie store file: %v", err)
 	}
@@ -81,30 +264,48 @@ func OpenCokieStore(path string, s *state.State) *CookieStore {
 	if err != nil {
 		log.Fatalf("could not read cookie store file: %v", err)
 	}
-	if len(data)%(2*crypto.Size) != 0 {
-		log.Fatalf("length of cookie store file incompatible: %v", len(data))
+	s.RLock()
+	epoch := s.Epoch
+	s.RUnlock()
+	sessions, legacy, ok := parseCookies(data, epoch)
+	if !ok {
+		log.Fatalf("cookie store file incompatible: length %v", len(data))
 	}
-	position := 0
 	store := &CookieStore{
-		file:       file,
-		session:    make(map[string]crypto.Token),
-		sessionend: make(map[uint64][]string),
-		position:   make(map[crypto.Token]int64),
+		file:    file,
+		session: make(map[string]*Session),
+		members: make(map[crypto.Token]map[string]struct{}),
+		expires: make(map[uint64][]string),
+		free:    make([]int64, 0),
+		end:     int64(len(cookiesHeader) + 1),
+		cleaned: epoch,
 	}
-	epoch := s.Epoch
-	for n := 0; n < len(data)/(2*crypto.Size); n++ {
-		var token crypto.Token
-		copy(token[:], data[2*n*crypto.Size:(2*n+1)*crypto.Size])
-		var hash crypto.Hash
-		copy(hash[:], data[(2*n+1)*crypto.Size:2*(n+1)*crypto.Size])
-		if !hash.Equal(crypto.ZeroHash) && !token.Equal(crypto.ZeroToken) {
-			cookie := hex.EncodeToString(hash[:])
-			fmt.Println(cookie, token)
-			store.session[cookie] = token
-			store.position[token] = int64(position)
-			endEpoch := epoch + cookieSessionDuration
-			store.sessionend[endEpoch] = append(store.sessionend[endEpoch], cookie)
-			store.position[token] = int64(2 * n * crypto.Size)
+	if legacy || len(data) == 0 {
+		// rewritten from scratch on the current format
+		if err := file.Truncate(0); err != nil {
+			log.Fatalf("could not rewrite cookie store file: %v", err)
+		}
+		store.write(append([]byte(cookiesHeader), cookiesFormat), 0)
+		for _, session := range sessions {
+			if emptySlot(session) {
+				continue
+			}
+			session.slot = store.end
+			store.write(session.serialize(), session.slot)
+			store.end += cookieSlotSize
+			store.add(session)
+		}
+		return store
+	}
+	store.end = int64(len(data))
+	for _, session := range sessions {
+		if emptySlot(session) {
+			store.free = append(store.free, session.slot)
+		} else if session.Expires <= epoch {
+			store.write(make([]byte, cookieSlotSize), session.slot)
+			store.free = append(store.free, session.slot)
+		} else {
+			store.add(session)
 		}
 	}
 	return store
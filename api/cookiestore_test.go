@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+func randomCookie() string {
+	token, _ := crypto.RandomAsymetricKey()
+	return hex.EncodeToString(token[:])
+}
+
+func TestCookieStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.dat")
+	genesis := state.GenesisState(nil)
+	genesis.Epoch = 100
+	store := OpenCokieStore(path, genesis)
+	user, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	first, second, third := randomCookie(), randomCookie(), randomCookie()
+	if !store.Set(user, first, 100) || !store.Set(user, second, 200) || !store.Set(other, third, 300) {
+		t.Fatal("Could not set sessions")
+	}
+	if store.Set(user, first, 400) {
+		t.Error("Cookie reused")
+	}
+	if sessions := store.Sessions(user); len(sessions) != 2 || sessions[0].Cookie != second {
+		t.Fatalf("Unexpected sessions: %+v", sessions)
+	}
+	// a member cannot revoke the session of another
+	store.Unset(user, third)
+	if store.Revoke(user, store.Sessions(other)[0].ID()) {
+		t.Error("Revoked session of other member")
+	}
+	if _, ok := store.Get(third); !ok {
+		t.Fatal("Session of other member revoked")
+	}
+	store.Close()
+
+	// expiry is kept after restart
+	genesis.Epoch = 150 + cookieSessionDuration
+	store = OpenCokieStore(path, genesis)
+	if _, ok := store.Get(first); ok {
+		t.Error("Expired session loaded")
+	}
+	if session := store.Sessions(user); len(session) != 1 || session[0].Issued != 200 || session[0].Expires != 200+cookieSessionDuration {
+		t.Fatalf("Session not persisted: %+v", session)
+	}
+	// the expired slot is reused
+	fourth := randomCookie()
+	if !store.Set(user, fourth, genesis.Epoch) {
+		t.Fatal("Could not set session")
+	}
+	if info, _ := os.Stat(path); info.Size() != int64(len(cookiesHeader)+1+3*cookieSlotSize) {
+		t.Errorf("Free slot not reused: size %v", info.Size())
+	}
+	store.Clean(200 + cookieSessionDuration)
+	if _, ok := store.Get(second); ok {
+		t.Error("Session not cleaned")
+	}
+	if _, ok := store.Get(third); !ok {
+		t.Error("Unexpired session cleaned")
+	}
+	store.RevokeOthers(user, fourth)
+	store.Set(user, second, genesis.Epoch)
+	store.RevokeOthers(user, fourth)
+	if sessions := store.Sessions(user); len(sessions) != 1 || sessions[0].Cookie != fourth {
+		t.Errorf("Other sessions not revoked: %+v", sessions)
+	}
+	store.Close()
+}
+
+func TestLegacyCookieStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.dat")
+	user, _ := crypto.RandomAsymetricKey()
+	cookie := randomCookie()
+	raw, _ := hex.DecodeString(cookie)
+	if err := os.WriteFile(path, append(user[:], raw...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	genesis := state.GenesisState(nil)
+	genesis.Epoch = 10
+	store := OpenCokieStore(path, genesis)
+	defer store.Close()
+	if token, ok := store.Get(cookie); !ok || !token.Equal(user) {
+		t.Fatal("Legacy session not loaded")
+	}
+	if sessions := store.Sessions(user); sessions[0].Expires != 10+cookieSessionDuration {
+		t.Errorf("Unexpected expiry: %v", sessions[0].Expires)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data[:len(cookiesHeader)]) != cookiesHeader {
+		t.Error("Legacy file not rewritten")
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+const (
+	csrfField  = "csrf"         // form field of the token
+	csrfHeader = "X-CSRF-Token" // header of the token for scripts
+)
+
+const (
+	maxBodySize   = 1 << 20  // bytes
+	maxUploadSize = 20 << 20 // bytes, for /uploadfile
+)
+
+// postOnly are the paths that change state and only accept POST
+var postOnly = map[string]struct{}{
+	"/api":         {},
+	"/uploadfile":  {},
+	"/credentials": {},
+	"/newuser":     {},
+	"/signout":     {},
+}
+
+// csrfExempt are the forms used before signing in. The json api is
+// protected by its content type instead.
+var csrfExempt = map[string]struct{}{
+	"/credentials": {},
+	"/newuser":     {},
+	"/reset":       {},
+	"/verify":      {},
+}
+
+var errInvalidCSRF = errors.New("invalid or missing csrf token")
+
+// csrfToken is bound to the session cookie and to the attorney key, so it
+// survives restarts and ends with the session.
+func (a *AttorneyGeneral) csrfToken(cookie string) string {
+	seed := append([]byte("csrf"), a.pk[:]...)
+	hash := crypto.Hasher(append(seed, cookie...))
+	return hex.EncodeToString(hash[:])
+}
+
+// CSRF returns the token to be embedded on the forms of the signed in member
+func (a *AttorneyGeneral) CSRF(r *http.Request) string {
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return ""
+	}
+	if _, ok := a.session.Get(cookie.Value); !ok {
+		return ""
+	}
+	return a.csrfToken(cookie.Value)
+}
+
+func (a *AttorneyGeneral) checkCSRF(r *http.Request) error {
+	expected := a.CSRF(r)
+	if expected == "" {
+		return errInvalidCSRF
+	}
+	token := r.Header.Get(csrfHeader)
+	if token == "" {
+		token = r.FormValue(csrfField)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return errInvalidCSRF
+	}
+	return nil
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// Harden caps the size of request bodies, restricts state changing paths to
+// POST and checks the csrf token of every other request that is not safe.
+func (a *AttorneyGeneral) Harden(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		limit := int64(maxBodySize)
+		if path == "/uploadfile" {
+			limit = maxUploadSize
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		if _, ok := postOnly[path]; ok && r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if safeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(path, RestPrefix+"/") {
+			// cross-site forms cannot send json without a preflight
+			if media, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || media != "application/json" {
+				writeJSONError(w, http.StatusUnsupportedMediaType, "content type must be application/json")
+				return
+			}
+		} else if _, ok := csrfExempt[path]; !ok {
+			if err := a.checkCSRF(r); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
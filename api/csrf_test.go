@@ -0,0 +1,80 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+func TestHarden(t *testing.T) {
+	genesis := state.GenesisState(nil)
+	_, attorneyKey := crypto.RandomAsymetricKey()
+	a := &AttorneyGeneral{
+		pk:      attorneyKey,
+		state:   genesis,
+		session: OpenCokieStore(filepath.Join(t.TempDir(), "cookies.dat"), genesis),
+	}
+	defer a.session.Close()
+	user, _ := crypto.RandomAsymetricKey()
+	cookie := randomCookie()
+	a.session.Set(user, cookie, 0)
+
+	reached := 0
+	handler := a.Harden(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		reached++
+	}))
+	request := func(method, path, contentType, body, session string) int {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		if session != "" {
+			r.AddCookie(&http.Cookie{Name: cookieName, Value: session})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	form := "application/x-www-form-urlencoded"
+	token := a.csrfToken(cookie)
+
+	if code := request(http.MethodGet, "/api?action=Vote", "", "", cookie); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET on /api answered %v", code)
+	}
+	if code := request(http.MethodPost, "/api", form, "action=Vote", cookie); code != http.StatusForbidden {
+		t.Errorf("POST without csrf token answered %v", code)
+	}
+	if code := request(http.MethodPost, "/api", form, url.Values{"csrf": {a.csrfToken(randomCookie())}}.Encode(), cookie); code != http.StatusForbidden {
+		t.Errorf("POST with token of other session answered %v", code)
+	}
+	if code := request(http.MethodPost, "/api", form, url.Values{"csrf": {token}}.Encode(), ""); code != http.StatusForbidden {
+		t.Errorf("POST without session answered %v", code)
+	}
+	if code := request(http.MethodPost, "/api", form, url.Values{"csrf": {token}}.Encode(), cookie); code != http.StatusOK || reached != 1 {
+		t.Errorf("POST with csrf token answered %v", code)
+	}
+	if code := request(http.MethodPost, "/credentials", form, "handle=x", ""); code != http.StatusOK || reached != 2 {
+		t.Errorf("Sign in form answered %v", code)
+	}
+	if code := request(http.MethodPost, RestPrefix+"/actions", "text/plain", "{}", cookie); code != http.StatusUnsupportedMediaType {
+		t.Errorf("json api accepted text/plain: %v", code)
+	}
+	if code := request(http.MethodPost, RestPrefix+"/actions", "application/json; charset=utf-8", "{}", cookie); code != http.StatusOK || reached != 3 {
+		t.Errorf("json api answered %v", code)
+	}
+	large := url.Values{"csrf": {token}, "content": {strings.Repeat("x", maxBodySize)}}.Encode()
+	if code := request(http.MethodPost, "/api", form, large, cookie); code == http.StatusOK {
+		t.Error("Body larger than cap accepted")
+	}
+}
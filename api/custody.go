@@ -0,0 +1,215 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+/*
+Self-custody: the member keeps its own key and signs its envelopes as its
+own attorney. The server only pays the fee with its wallet and relays.
+
+	POST /api/v1/unsigned  author + action (json) or payload (hex serialized)
+	                       -> hex of the envelopes to be signed
+	POST /api/v1/signed    hex of each envelope followed by the signature
+	                       -> hashes and status urls, as /api/v1/actions
+	GET  /api/v1/actions/{hash}?signature=
+	                       status of the action, authorized by the hex of the
+	                       signature of the author on the hash
+*/
+
+type UnsignedRequest struct {
+	Author  string          `json:"author"`            // hex token of the member
+	Action  json.RawMessage `json:"action,omitempty"`  // one of the structs of jsonactions.go
+	Payload string          `json:"payload,omitempty"` // or the hex of a serialized actions.* payload
+}
+
+type UnsignedAction struct {
+	Hash     string `json:"hash"`
+	Unsigned string `json:"unsigned"` // hex of the bytes the author signs
+}
+
+type UnsignedResponse struct {
+	Epoch   uint64           `json:"epoch"`
+	Actions []UnsignedAction `json:"actions"`
+}
+
+type SignedRequest struct {
+	Signed []string `json:"signed"` // hex of each unsigned envelope followed by its signature
+}
+
+var (
+	errNoAction         = errors.New("either action or payload must be given")
+	errInvalidPayload   = errors.New("invalid payload")
+	errNotMember        = errors.New("author is not a member")
+	errNotSelfCustodied = errors.New("envelope must be signed by its author")
+)
+
+func (r UnsignedRequest) payloads() ([][]byte, error) {
+	if r.Payload != "" {
+		payload, err := hex.DecodeString(r.Payload)
+		if err != nil || actions.ActionKind(payload) == actions.AUnknown {
+			return nil, errInvalidPayload
+		}
+		return [][]byte{payload}, nil
+	}
+	if len(r.Action) == 0 {
+		return nil, errNoAction
+	}
+	all, err := parseJSONAction(r.Action)
+	if err != nil {
+		return nil, err
+	}
+	payloads := make([][]byte, 0, len(all))
+	for _, action := range all {
+		payloads = append(payloads, action.Serialize())
+	}
+	return payloads, nil
+}
+
+// canRelay checks if the server pays for actions of the author: members and
+// their own sign in.
+func (a *AttorneyGeneral) canRelay(author crypto.Token, action []byte) bool {
+	if actions.ActionKind(action) == actions.ASignIn {
+		return true
+	}
+	a.state.RLock()
+	defer a.state.RUnlock()
+	return a.state.IsMember(author)
+}
+
+// UnsignedHandler returns the envelopes of an action for the author to sign
+// with its own key.
+func (a *AttorneyGeneral) UnsignedHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "could not read request")
+		return
+	}
+	var request UnsignedRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{fmt.Sprintf("invalid json: %v", err)}})
+		return
+	}
+	author, err := social.ParseToken(request.Author)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{err.Error()}})
+		return
+	}
+	payloads, err := request.payloads()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{err.Error()}})
+		return
+	}
+	if !a.canRelay(author, payloads[0]) {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{errNotMember.Error()}})
+		return
+	}
+	epoch := a.epoch.Load()
+	response := UnsignedResponse{Epoch: epoch, Actions: make([]UnsignedAction, 0, len(payloads))}
+	for _, payload := range payloads {
+		unsigned := actions.Unsigned(payload, epoch, author, author)
+		// the undressed action is epoch | author | payload
+		undressed := make([]byte, 0, len(payload))
+		undressed = append(undressed, unsigned[1:1+8+crypto.TokenSize]...)
+		undressed = append(undressed, payload[8+crypto.TokenSize:]...)
+		hashText, _ := crypto.Hasher(undressed).MarshalText()
+		response.Actions = append(response.Actions, UnsignedAction{
+			Hash:     string(hashText),
+			Unsigned: hex.EncodeToString(unsigned),
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// SignedHandler pays the fee of envelopes signed by their authors and sends
+// them to the gateway.
+func (a *AttorneyGeneral) SignedHandler(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "could not read request")
+		return
+	}
+	var request SignedRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{fmt.Sprintf("invalid json: %v", err)}})
+		return
+	}
+	if len(request.Signed) == 0 {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{errNoAction.Error()}})
+		return
+	}
+	// every envelope is checked before any is relayed
+	signed := make([][]byte, 0, len(request.Signed))
+	errs := make([]string, 0)
+	for n, text := range request.Signed {
+		envelope, err := hex.DecodeString(text)
+		if err == nil {
+			err = a.checkSigned(envelope)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("signed[%v]: %v", n, err))
+			continue
+		}
+		signed = append(signed, envelope)
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: errs})
+		return
+	}
+	response := SubmitResponse{Actions: make([]SubmittedAction, 0, len(signed))}
+	for _, envelope := range signed {
+		dressed := actions.PayFee(envelope, a.wallet, 0)
+		parsed, err := actions.ParseEnvelope(dressed)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{err.Error()}})
+			return
+		}
+		response.Actions = append(response.Actions, a.submit(dressed, parsed))
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// signedBy returns the author of the tracked action if the signature query
+// parameter is its signature on the hash of the action. Self-custodied members
+// have no session to follow the status of their actions.
+func (a *AttorneyGeneral) signedBy(r *http.Request, hash crypto.Hash) crypto.Token {
+	bytes, err := hex.DecodeString(r.URL.Query().Get("signature"))
+	if err != nil || len(bytes) != crypto.SignatureSize {
+		return crypto.ZeroToken
+	}
+	var signature crypto.Signature
+	copy(signature[:], bytes)
+	author, ok := a.tracker.Author(hash)
+	if !ok || !author.Verify(hash[:], signature) {
+		return crypto.ZeroToken
+	}
+	return author
+}
+
+// checkSigned verifies the signature of the author on the envelope with the
+// same code the state uses for dressed actions.
+func (a *AttorneyGeneral) checkSigned(signed []byte) error {
+	envelope, err := actions.VerifyAttorney(signed)
+	if err != nil {
+		return err
+	}
+	if !envelope.Attorney.Equal(envelope.Author) {
+		return errNotSelfCustodied
+	}
+	if actions.ActionKind(envelope.Action) == actions.AUnknown {
+		return errInvalidPayload
+	}
+	if !a.canRelay(envelope.Author, envelope.Action) {
+		return errNotMember
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+func postJSON(handler http.HandlerFunc, path string, value any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(value)
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestSelfCustodyStatus(t *testing.T) {
+	_, attorneyKey := crypto.RandomAsymetricKey()
+	gateway := &recordGateway{}
+	a := &AttorneyGeneral{
+		pk:      attorneyKey,
+		wallet:  attorneyKey,
+		tracker: NewActionTracker(),
+		gateway: gateway,
+		state:   state.GenesisState(nil),
+	}
+	a.epoch.Store(10)
+	author, key := crypto.RandomAsymetricKey()
+	signin := &actions.Signin{Author: author, Handle: "custody"}
+	w := postJSON(a.UnsignedHandler, RestPrefix+"/unsigned", UnsignedRequest{Author: author.String(), Payload: hex.EncodeToString(signin.Serialize())})
+	var unsigned UnsignedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &unsigned); err != nil || len(unsigned.Actions) != 1 {
+		t.Fatalf("unexpected unsigned response: %v", w.Body.String())
+	}
+	envelope, _ := hex.DecodeString(unsigned.Actions[0].Unsigned)
+	signature := key.Sign(envelope)
+	signed := hex.EncodeToString(append(envelope, signature[:]...))
+	w = postJSON(a.SignedHandler, RestPrefix+"/signed", SignedRequest{Signed: []string{signed}})
+	var submitted SubmitResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &submitted); err != nil || len(submitted.Actions) != 1 || len(gateway.actions) != 1 {
+		t.Fatalf("unexpected signed response: %v", w.Body.String())
+	}
+	if submitted.Actions[0].Hash != unsigned.Actions[0].Hash {
+		t.Error("relayed action hash differs from the unsigned one")
+	}
+
+	hash := crypto.DecodeHash(submitted.Actions[0].Hash)
+	status := func(query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, submitted.Actions[0].Status+query, nil)
+		w := httptest.NewRecorder()
+		a.RestHandler(w, r)
+		return w
+	}
+	if w := status(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("status without session nor signature: %v", w.Code)
+	}
+	_, other := crypto.RandomAsymetricKey()
+	forged := other.Sign(hash[:])
+	if w := status("?signature=" + hex.EncodeToString(forged[:])); w.Code != http.StatusUnauthorized {
+		t.Errorf("status with signature of another key: %v", w.Code)
+	}
+	proof := key.Sign(hash[:])
+	w = status("?signature=" + hex.EncodeToString(proof[:]))
+	var got ActionStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Status != StatusSubmitted {
+		t.Errorf("status with signature of the author: %v", w.Body.String())
+	}
+}
@@ -4,35 +4,65 @@ import (
 	"fmt"
 	"log"
 	"net/smtp"
+	"strings"
 )
 
-var emailMessage = "To: %v\r\n" + "Subject: Synergy Protocol Sigin\r\n" + "\r\n" + "%v\r\n"
+var emailMessage = "From: %v\r\n" + "To: %v\r\n" + "Subject: %v\r\n" + "\r\n" + "%v\r\n"
 
-const message = `Your email was associated to a Synergy account for the handle %v.
+const verifyMessage = `Your email was associated to a Synergy account for the handle %v.
 
-If you did not pursue such action, just ignore this email. Otherwise, please follow the instructions below to activate your account.
-
-Fiirst you need to grant power of attorney to the application on axé/breeze network. Go to your wallet associated to the handle and grant power of attorney to
+If you did not pursue such action, just ignore this email. Otherwise, please follow the link below to confirm your email and choose your password.
 
 %v
+%v
+Thank you for joining Synergy! #FreeOurHandles
+`
 
-using the fingerprint
+const attorneyMessage = `
+Before using the interface you need to grant power of attorney to the application on axé/breeze network. Go to your wallet associated to the handle and grant power of attorney to
 
 %v
+`
 
-Once this message is incorporated into the network you will receive another email confirming your account activation and providing you with a provisory password to access your account.
+const resetMessage = `A password reset was requested for the Synergy account of the handle %v.
 
-Thank you for joining Synergy! #FreeOurHandles
+If you did not pursue such action, just ignore this email. Otherwise, please follow the link below to choose a new password.
+
+%v
 `
 
-func (a *AttorneyGeneral) sendEmail(handle, email, fingerprint, password string) {
-	auth := smtp.PlainAuth("", "freemyhandle@gmail.com", password, "smtp.gmail.com")
-	to := []string{email}
-	body := fmt.Sprintf(message, handle, a.pk.PublicKey(), fingerprint)
-	emailMsg := fmt.Sprintf(emailMessage, email, body)
-	err := smtp.SendMail("smtp.gmail.com:587", auth, "freemyhandle@gmail.com", to, []byte(emailMsg))
-	if err != nil {
-		log.Fatal(err)
+// Mailer sends the verification and password reset emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPMailer sends emails through an smtp server
+type SMTPMailer struct {
+	Addr string // host:port
+	From string
+	Auth smtp.Auth // nil for servers without authentication
+}
+
+func NewGmailMailer(from, password string) *SMTPMailer {
+	return &SMTPMailer{
+		Addr: "smtp.gmail.com:587",
+		From: from,
+		Auth: smtp.PlainAuth("", from, password, "smtp.gmail.com"),
 	}
-	fmt.Println("email sent")
+}
+
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+	msg := fmt.Sprintf(emailMessage, m.From, to, subject, body)
+	return smtp.SendMail(m.Addr, m.Auth, m.From, []string{to}, []byte(msg))
+}
+
+// LogMailer writes the emails to the log, for servers without smtp
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	log.Printf("email to %v: %v\n%v", to, subject, body)
+	return nil
 }
@@ -15,52 +15,53 @@ import (
 const maxStringSize = 50
 
 type HeaderInfo struct {
-	UserName   string
-	UserHandle string
-	Active     string
-	Path       string
-	EndPath    string
-	Section    string
-	Error      string
+	UserName   string `json:"userName"`
+	UserHandle string `json:"userHandle"`
+	Active     string `json:"active"`
+	Path       string `json:"path"`
+	EndPath    string `json:"endPath"`
+	Section    string `json:"section"`
+	Error      string `json:"error"`
+	CSRF       string `json:"-"`
 }
 
 // Drafts template struct
 
 type DraftsView struct {
-	Title       string
-	Authors     []AuthorDetail
-	Hash        string
-	Description string
-	Keywords    []string
+	Title       string         `json:"title"`
+	Authors     []AuthorDetail `json:"authors"`
+	Hash        string         `json:"hash"`
+	Description string         `json:"description"`
+	Keywords    []string       `json:"keywords"`
 }
 
 type DraftsListView struct {
-	Drafts []DraftsView
-	Head   HeaderInfo
+	Drafts []DraftsView `json:"drafts"`
+	Head   HeaderInfo   `json:"-"`
 }
 
 type AuthorDetail struct {
-	Name       string
-	Link       string
-	Collective bool
+	Name       string `json:"name"`
+	Link       string `json:"link"`
+	Collective bool   `json:"collective"`
 }
 
 type ReferenceDetail struct {
-	Title  string
-	Author string
-	Date   string
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Date   string `json:"date"`
 }
 
 // co-autor, stamp, pin, version, release
 type DraftVoteAction struct {
-	Kind       string
-	OnBehalfOf string // collective or board editor
-	Hash       string
+	Kind       string `json:"kind"`
+	OnBehalfOf string `json:"onBehalfOf"` // collective or board editor
+	Hash       string `json:"hash"`
 }
 
 type NameLink struct {
-	Name string
-	Link string
+	Name string `json:"name"`
+	Link string `json:"link"`
 }
 
 func NameLinker(name string) NameLink {
@@ -71,44 +72,96 @@ func NameLinker(name string) NameLink {
 }
 
 type DraftEditView struct {
-	Date    string
-	Authors []AuthorDetail
-	Hash    string
+	Date    string         `json:"date"`
+	Authors []AuthorDetail `json:"authors"`
+	Hash    string         `json:"hash"`
 }
 
 type DraftDetailView struct {
-	Title       string
-	Date        string
-	Description string
-	Keywords    []string
-	Hash        string
+	Title       string   `json:"title"`
+	Date        string   `json:"date"`
+	Description string   `json:"description"`
+	Keywords    []string `json:"keywords"`
+	Hash        string   `json:"hash"`
 	//Content      string
-	Authors      []AuthorDetail
-	References   []ReferenceDetail
-	PreviousHash string
-	Pinned       []NameLink
-	Edited       bool
-	Released     bool
-	Stamps       []NameLink
-	Votes        []DraftVoteAction
-	Policy       Policy
-	Authorship   bool
-	Head         HeaderInfo
-	Content      string
-	Edits        []DraftEditView
+	Authors      []AuthorDetail    `json:"authors"`
+	References   []ReferenceDetail `json:"references"`
+	PreviousHash string            `json:"previousHash"`
+	Pinned       []NameLink        `json:"pinned"`
+	Edited       bool              `json:"edited"`
+	Released     bool              `json:"released"`
+	Stamps       []NameLink        `json:"stamps"`
+	Votes        []DraftVoteAction `json:"votes"`
+	Policy       Policy            `json:"policy"`
+	Authorship   bool              `json:"authorship"`
+	Head         HeaderInfo        `json:"-"`
+	Content      string            `json:"content"`
+	Edits        []DraftEditView   `json:"edits"`
+	Comments     []CommentView     `json:"comments"`
+	Release      *ReleaseThread    `json:"release,omitempty"`
+}
+
+// ReleaseThread are the comments on the release of a draft. Head points to
+// the header of the draft view.
+type ReleaseThread struct {
+	Hash     string        `json:"hash"`
+	Comments []CommentView `json:"comments"`
+	Head     *HeaderInfo   `json:"-"`
 }
 
 type EditDetailedView struct {
-	DraftTitle string
-	DraftHash  string
-	Reasons    string
-	Hash       string
-	Authors    []AuthorDetail
-	Votes      []DraftVoteAction
-	Head       HeaderInfo
+	DraftTitle string            `json:"draftTitle"`
+	DraftHash  string            `json:"draftHash"`
+	Reasons    string            `json:"reasons"`
+	Hash       string            `json:"hash"`
+	Authors    []AuthorDetail    `json:"authors"`
+	Votes      []DraftVoteAction `json:"votes"`
+	Comments   []CommentView     `json:"comments"`
+	Head       HeaderInfo        `json:"-"`
+}
+
+// CommentView is a comment of a flattened thread: replies follow the comment
+// they answer with Depth one greater.
+type CommentView struct {
+	Hash       string       `json:"hash"`
+	Object     string       `json:"object"`
+	Author     AuthorDetail `json:"author"`
+	OnBehalfOf string       `json:"onBehalfOf"`
+	Content    string       `json:"content"`
+	Date       string       `json:"date"`
+	Depth      int          `json:"depth"`
+	Redirect   string       `json:"-"`
+}
+
+func CommentList(s *state.State, object crypto.Hash, redirect string) []CommentView {
+	list := make([]CommentView, 0)
+	var flatten func(comments []*state.Comment, depth int)
+	flatten = func(comments []*state.Comment, depth int) {
+		for _, comment := range comments {
+			handle := s.Members[crypto.HashToken(comment.Author)]
+			view := CommentView{
+				Hash:     crypto.EncodeHash(comment.Hash),
+				Object:   crypto.EncodeHash(comment.Object),
+				Author:   AuthorDetail{Name: handle, Link: url.QueryEscape(handle)},
+				Content:  comment.Content,
+				Date:     PrettyDate(s.TimeOfEpoch(comment.Epoch)),
+				Depth:    depth,
+				Redirect: redirect,
+			}
+			if comment.Collective != nil {
+				view.OnBehalfOf = comment.Collective.Name
+			}
+			list = append(list, view)
+			flatten(comment.Replies, depth+1)
+		}
+	}
+	flatten(s.Thread(object), 0)
+	return list
 }
 
 func EditDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, token crypto.Token) *EditDetailedView {
+	s.RLock()
+	defer s.RUnlock()
 	fmt.Println(crypto.EncodeHash(hash))
 	edit, ok := s.Edits[hash]
 	if !ok {
@@ -132,6 +185,7 @@ func EditDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, token
 		Hash:       crypto.EncodeHash(edit.Edit),
 		Authors:    AuthorList(edit.Authors, s),
 		Votes:      make([]DraftVoteAction, 0),
+		Comments:   CommentList(s, edit.Edit, "editview/"+crypto.EncodeHash(edit.Edit)),
 		Head:       head,
 	}
 	pending := i.GetVotes(token)
@@ -252,6 +306,8 @@ func AuthorList(c state.Consensual, s *state.State) []AuthorDetail {
 }
 
 func DraftsFromState(state *state.State) DraftsListView {
+	state.RLock()
+	defer state.RUnlock()
 	head := HeaderInfo{
 		Active:  "Drafts",
 		Path:    "explore / ",
@@ -277,6 +333,8 @@ func DraftsFromState(state *state.State) DraftsListView {
 }
 
 func DraftDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, token crypto.Token, genesis time.Time) *DraftDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	draft, ok := s.Drafts[hash]
 	if !ok {
 		draft, ok = s.Proposals.Draft[hash]
@@ -297,6 +355,7 @@ func DraftDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, toke
 		Votes:       make([]DraftVoteAction, 0),
 		Authorship:  draft.Authors.IsMember(token),
 		Hash:        string(hashText),
+		Comments:    CommentList(s, hash, "draft/"+string(hashText)),
 	}
 	if view.Authorship {
 		view.Head = HeaderInfo{
@@ -348,6 +407,12 @@ func DraftDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, toke
 	if release, ok := s.Releases[draft.DraftHash]; ok {
 		view.Stamps = StampList(release.Stamps)
 		view.Released = true
+		releaseText := crypto.EncodeHash(release.Hash)
+		view.Release = &ReleaseThread{
+			Hash:     releaseText,
+			Comments: CommentList(s, release.Hash, "draft/"+string(hashText)),
+			Head:     &view.Head,
+		}
 	}
 	if len(draft.Edits) > 0 {
 		view.Edited = true
@@ -384,19 +449,21 @@ func DraftDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, toke
 // Edits template struct
 
 type EditsView struct {
-	Authors []AuthorDetail
-	Reasons string
-	Hash    string
+	Authors []AuthorDetail `json:"authors"`
+	Reasons string         `json:"reasons"`
+	Hash    string         `json:"hash"`
 }
 
 type EditsListView struct {
-	DraftTitle string
-	DraftHash  string
-	Edits      []EditsView
-	Head       HeaderInfo
+	DraftTitle string      `json:"draftTitle"`
+	DraftHash  string      `json:"draftHash"`
+	Edits      []EditsView `json:"edits"`
+	Head       HeaderInfo  `json:"-"`
 }
 
 func EditsFromState(s *state.State, drafthash crypto.Hash) EditsListView {
+	s.RLock()
+	defer s.RUnlock()
 	draft, ok := s.Drafts[drafthash]
 	if !ok {
 		return EditsListView{}
@@ -427,30 +494,32 @@ func EditsFromState(s *state.State, drafthash crypto.Hash) EditsListView {
 // Votes template struct
 
 type VotesView struct {
-	Action            string
-	Scope             string
-	ScopeLink         string
-	Hash              string
-	Handler           string
-	ObjectType        string
-	ObjectLink        string
-	ObjectCaption     string
-	ComplementType    string
-	ComplementLink    string
-	ComplementCaption string
-	Reasons           string
+	Action            string `json:"action"`
+	Scope             string `json:"scope"`
+	ScopeLink         string `json:"scopeLink"`
+	Hash              string `json:"hash"`
+	Handler           string `json:"handler"`
+	ObjectType        string `json:"objectType"`
+	ObjectLink        string `json:"objectLink"`
+	ObjectCaption     string `json:"objectCaption"`
+	ComplementType    string `json:"complementType"`
+	ComplementLink    string `json:"complementLink"`
+	ComplementCaption string `json:"complementCaption"`
+	Reasons           string `json:"reasons"`
 }
 
 type VotesListView struct {
-	Votes []VotesView
-	Head  HeaderInfo
+	Votes []VotesView `json:"votes"`
+	Head  HeaderInfo  `json:"-"`
 }
 
 type VoteDetailView struct {
-	Hash string
+	Hash string `json:"hash"`
 }
 
 func VotesFromState(s *state.State, i *index.Index, token crypto.Token) VotesListView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "Votes",
 		Path:    "venture / ",
@@ -553,14 +622,16 @@ func VotesFromState(s *state.State, i *index.Index, token crypto.Token) VotesLis
 }
 
 type RequestMembershipView struct {
-	Collective string
-	Handle     string
-	Hash       string
-	Reasons    string
-	Majority   string
+	Collective string `json:"collective"`
+	Handle     string `json:"handle"`
+	Hash       string `json:"hash"`
+	Reasons    string `json:"reasons"`
+	Majority   string `json:"majority"`
 }
 
 func RequestMembershipFromState(s *state.State, hash crypto.Hash) *RequestMembershipView {
+	s.RLock()
+	defer s.RUnlock()
 	vote, ok := s.Proposals.RequestMembership[hash]
 	if !ok {
 		return nil
@@ -582,11 +653,14 @@ func RequestMembershipFromState(s *state.State, hash crypto.Hash) *RequestMember
 }
 
 type EditVersion struct {
-	DraftHash string
-	Head      HeaderInfo
+	DraftHash string        `json:"draftHash"`
+	Comments  []CommentView `json:"comments"`
+	Head      HeaderInfo    `json:"-"`
 }
 
 func NewEdit(s *state.State, hash crypto.Hash) *EditVersion {
+	s.RLock()
+	defer s.RUnlock()
 	draft, ok := s.Drafts[hash]
 	if !ok {
 		return nil
@@ -599,19 +673,20 @@ func NewEdit(s *state.State, hash crypto.Hash) *EditVersion {
 	}
 	return &EditVersion{
 		DraftHash: crypto.EncodeHash(draft.DraftHash),
+		Comments:  CommentList(s, draft.DraftHash, "draft/"+crypto.EncodeHash(draft.DraftHash)),
 		Head:      head,
 	}
 }
 
 type DraftVersion struct {
-	OnBehalfOf    string
-	Policy        Policy
-	Title         string
-	Keywords      string
-	Description   string
-	PreviousDraft string
-	References    string
-	Head          HeaderInfo
+	OnBehalfOf    string     `json:"onBehalfOf"`
+	Policy        Policy     `json:"policy"`
+	Title         string     `json:"title"`
+	Keywords      string     `json:"keywords"`
+	Description   string     `json:"description"`
+	PreviousDraft string     `json:"previousDraft"`
+	References    string     `json:"references"`
+	Head          HeaderInfo `json:"-"`
 }
 
 func NewDraftVersion(s *state.State, hash crypto.Hash) *DraftVersion {
@@ -653,22 +728,28 @@ func NewDraftVersion(s *state.State, hash crypto.Hash) *DraftVersion {
 // Boards template struct
 
 type CollectiveUpdateView struct {
-	Name             string
-	Link             string
-	OldDescription   string
-	Description      string
-	OldMajority      int
-	Majority         int
-	OldSuperMajority int
-	SuperMajority    int
-	Member           bool
-	Hash             string
-	Reasons          string
-	Head             HeaderInfo
-	Voting           DetailedVoteView
+	Name             string           `json:"name"`
+	Link             string           `json:"link"`
+	OldDescription   string           `json:"oldDescription"`
+	Description      string           `json:"description"`
+	OldMajority      int              `json:"oldMajority"`
+	Majority         int              `json:"majority"`
+	OldSuperMajority int              `json:"oldSuperMajority"`
+	SuperMajority    int              `json:"superMajority"`
+	OldQuorum        int              `json:"oldQuorum"`
+	Quorum           *int             `json:"quorum,omitempty"`
+	OldAccept        bool             `json:"oldAcceptOnExpiry"`
+	Accept           *bool            `json:"acceptOnExpiry,omitempty"`
+	Member           bool             `json:"member"`
+	Hash             string           `json:"hash"`
+	Reasons          string           `json:"reasons"`
+	Head             HeaderInfo       `json:"-"`
+	Voting           DetailedVoteView `json:"voting"`
 }
 
 func CollectiveToUpdateFromState(s *state.State, name string) *CollectiveUpdateView {
+	s.RLock()
+	defer s.RUnlock()
 	collectiveName, _ := url.QueryUnescape(name)
 	col, ok := s.Collective(collectiveName)
 	if !ok {
@@ -686,12 +767,16 @@ func CollectiveToUpdateFromState(s *state.State, name string) *CollectiveUpdateV
 		OldDescription:   col.Description,
 		OldMajority:      col.Policy.Majority,
 		OldSuperMajority: col.Policy.SuperMajority,
+		OldQuorum:        col.Policy.Quorum,
+		OldAccept:        col.Policy.AcceptOnExpiry,
 		Head:             head,
 	}
 	return update
 }
 
 func CollectiveUpdateFromState(s *state.State, hash crypto.Hash, token crypto.Token) *CollectiveUpdateView {
+	s.RLock()
+	defer s.RUnlock()
 	pending, ok := s.Proposals.UpdateCollective[hash]
 	if !ok {
 		return nil
@@ -709,6 +794,8 @@ func CollectiveUpdateFromState(s *state.State, hash crypto.Hash, token crypto.To
 		OldDescription:   live.Description,
 		OldMajority:      live.Policy.Majority,
 		OldSuperMajority: live.Policy.SuperMajority,
+		OldQuorum:        live.Policy.Quorum,
+		OldAccept:        live.Policy.AcceptOnExpiry,
 		Hash:             crypto.EncodeHash(hash),
 		Reasons:          pending.Update.Reasons,
 		Head:             head,
@@ -723,6 +810,11 @@ func CollectiveUpdateFromState(s *state.State, hash crypto.Hash, token crypto.To
 	if pending.Update.SuperMajority != nil {
 		update.SuperMajority = int(*pending.Update.SuperMajority)
 	}
+	if pending.Update.Quorum != nil {
+		quorum := int(*pending.Update.Quorum)
+		update.Quorum = &quorum
+	}
+	update.Accept = pending.Update.AcceptOnExpiry
 	if live.IsMember(token) {
 		update.Member = true
 	}
@@ -731,22 +823,24 @@ func CollectiveUpdateFromState(s *state.State, hash crypto.Hash, token crypto.To
 }
 
 type BoardUpdateView struct {
-	Name              string
-	Link              string
-	Collective        string
-	Description       string
-	OldDescription    string
-	KeywordsString    string
-	OldKeywordsString string
-	PinMajority       byte
-	OldPinMajority    byte
-	Reasons           string
-	Hash              string
-	Head              HeaderInfo
-	Voting            DetailedVoteView
+	Name              string           `json:"name"`
+	Link              string           `json:"link"`
+	Collective        string           `json:"collective"`
+	Description       string           `json:"description"`
+	OldDescription    string           `json:"oldDescription"`
+	KeywordsString    string           `json:"keywordsString"`
+	OldKeywordsString string           `json:"oldKeywordsString"`
+	PinMajority       byte             `json:"pinMajority"`
+	OldPinMajority    byte             `json:"oldPinMajority"`
+	Reasons           string           `json:"reasons"`
+	Hash              string           `json:"hash"`
+	Head              HeaderInfo       `json:"-"`
+	Voting            DetailedVoteView `json:"voting"`
 }
 
 func BoardToUpdateFromState(s *state.State, name string) *BoardUpdateView {
+	s.RLock()
+	defer s.RUnlock()
 	boardName, _ := url.QueryUnescape(name)
 	live, ok := s.Board(boardName)
 	if !ok {
@@ -773,6 +867,8 @@ func BoardToUpdateFromState(s *state.State, name string) *BoardUpdateView {
 }
 
 func BoardUpdateFromState(s *state.State, hash crypto.Hash) *BoardUpdateView {
+	s.RLock()
+	defer s.RUnlock()
 	pending, ok := s.Proposals.UpdateBoard[hash]
 	if !ok {
 		return nil
@@ -812,32 +908,33 @@ func BoardUpdateFromState(s *state.State, hash crypto.Hash) *BoardUpdateView {
 }
 
 type BoardsView struct {
-	Name           string
-	Description    string
-	Hash           string
-	Collective     string
-	CollectiveLink string
-	Link           string
-	Keywords       []string
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Hash           string   `json:"hash"`
+	Collective     string   `json:"collective"`
+	CollectiveLink string   `json:"collectiveLink"`
+	Link           string   `json:"link"`
+	Keywords       []string `json:"keywords"`
 }
 
 type BoardsListView struct {
-	Boards []BoardsView
-	Head   HeaderInfo
+	Boards []BoardsView `json:"boards"`
+	Head   HeaderInfo   `json:"-"`
 }
 
 type VoteDetails struct {
-	Caption string
-	Link    string
-	Reasons string
+	Caption string `json:"caption"`
+	Link    string `json:"link"`
+	Reasons string `json:"reasons"`
 }
 
 type DetailedVoteView struct {
-	Voted    int
-	Approve  []VoteDetails
-	Reject   []VoteDetails
-	NotCast  []VoteDetails
-	Majority int
+	Voted    int           `json:"voted"`
+	Approve  []VoteDetails `json:"approve"`
+	Reject   []VoteDetails `json:"reject"`
+	Abstain  []VoteDetails `json:"abstain"`
+	NotCast  []VoteDetails `json:"notCast"`
+	Majority int           `json:"majority"`
 }
 
 func NewDetailedVoteView(votes []actions.Vote, consensus state.Consensual, s *state.State) DetailedVoteView {
@@ -845,6 +942,7 @@ func NewDetailedVoteView(votes []actions.Vote, consensus state.Consensual, s *st
 	view := DetailedVoteView{
 		Approve:  make([]VoteDetails, 0),
 		Reject:   make([]VoteDetails, 0),
+		Abstain:  make([]VoteDetails, 0),
 		NotCast:  make([]VoteDetails, 0),
 		Majority: majority,
 	}
@@ -861,13 +959,15 @@ func NewDetailedVoteView(votes []actions.Vote, consensus state.Consensual, s *st
 			Link:    url.QueryEscape(handle),
 			Reasons: vote.Reasons,
 		}
-		if vote.Approve {
+		if vote.Abstain {
+			view.Abstain = append(view.Abstain, voteDetail)
+		} else if vote.Approve {
 			view.Approve = append(view.Approve, voteDetail)
 		} else {
 			view.Reject = append(view.Reject, voteDetail)
 		}
 	}
-	view.Voted = len(view.Approve) + len(view.Reject)
+	view.Voted = len(view.Approve) + len(view.Reject) + len(view.Abstain)
 	for token := range allVoters {
 		handle := s.Members[crypto.HashToken(token)]
 		voteDetail := VoteDetails{
@@ -881,25 +981,27 @@ func NewDetailedVoteView(votes []actions.Vote, consensus state.Consensual, s *st
 }
 
 type BoardDetailView struct {
-	Name             string
-	Link             string
-	Description      string
-	Collective       string
-	CollectiveLink   string
-	Keywords         []string
-	PinMajority      int
-	Editors          []MemberDetailView
-	Drafts           []DraftsView
-	Editorship       bool
-	CollectiveMember bool
-	Reasons          string
-	Author           string
-	Hash             string
-	Head             HeaderInfo
-	Voting           DetailedVoteView
+	Name             string             `json:"name"`
+	Link             string             `json:"link"`
+	Description      string             `json:"description"`
+	Collective       string             `json:"collective"`
+	CollectiveLink   string             `json:"collectiveLink"`
+	Keywords         []string           `json:"keywords"`
+	PinMajority      int                `json:"pinMajority"`
+	Editors          []MemberDetailView `json:"editors"`
+	Drafts           []DraftsView       `json:"drafts"`
+	Editorship       bool               `json:"editorship"`
+	CollectiveMember bool               `json:"collectiveMember"`
+	Reasons          string             `json:"reasons"`
+	Author           string             `json:"author"`
+	Hash             string             `json:"hash"`
+	Head             HeaderInfo         `json:"-"`
+	Voting           DetailedVoteView   `json:"voting"`
 }
 
 func BoardsFromState(s *state.State) BoardsListView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "Boards",
 		Path:    "explore / ",
@@ -926,6 +1028,8 @@ func BoardsFromState(s *state.State) BoardsListView {
 }
 
 func PendingBoardFromState(s *state.State, hash crypto.Hash) *BoardDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	pending, ok := s.Proposals.CreateBoard[hash]
 	if !ok {
 		return nil
@@ -957,6 +1061,8 @@ func PendingBoardFromState(s *state.State, hash crypto.Hash) *BoardDetailView {
 }
 
 func BoardDetailFromState(s *state.State, name string, token crypto.Token) *BoardDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	boardName, _ := url.QueryUnescape(name)
 	board, ok := s.Board(boardName)
 	if !ok {
@@ -1014,59 +1120,64 @@ func BoardDetailFromState(s *state.State, name string, token crypto.Token) *Boar
 // Collectives template struct
 
 type CollectivesView struct {
-	Name         string
-	Description  string
-	Participants int
-	Link         string
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Participants int    `json:"participants"`
+	Link         string `json:"link"`
 }
 
 type CollectivesListView struct {
-	Collectives []CollectivesView
-	Head        HeaderInfo
+	Collectives []CollectivesView `json:"collectives"`
+	Head        HeaderInfo        `json:"-"`
 }
 
 type CaptionLink struct {
-	Caption string
-	Link    string
+	Caption string `json:"caption"`
+	Link    string `json:"link"`
 }
 
 type StampView struct {
-	Draft            CaptionLink
-	DraftAuthors     []CaptionLink
-	DraftDescription string
-	DraftKeywords    []string
+	Draft            CaptionLink   `json:"draft"`
+	DraftAuthors     []CaptionLink `json:"draftAuthors"`
+	DraftDescription string        `json:"draftDescription"`
+	DraftKeywords    []string      `json:"draftKeywords"`
 }
 
 type BoardOnCollectiveView struct {
-	Board       CaptionLink
-	Description string
-	Keywords    []string
+	Board       CaptionLink `json:"board"`
+	Description string      `json:"description"`
+	Keywords    []string    `json:"keywords"`
 }
 
 type EventOnCollectiveView struct {
-	StartAt     string
-	Hash        string
-	Venue       string
-	Description string
-	Managers    []CaptionLink
+	StartAt     string        `json:"startAt"`
+	Hash        string        `json:"hash"`
+	Venue       string        `json:"venue"`
+	Description string        `json:"description"`
+	Managers    []CaptionLink `json:"managers"`
 }
 
 type CollectiveDetailView struct {
-	Name          string
-	Hash          string // hash of name for the reaction funcionalities
-	Link          string
-	Description   string
-	Majority      int
-	SuperMajority int
-	Members       []MemberDetailView
-	Membership    bool
-	Head          HeaderInfo
-	Stamps        []StampView
-	Boards        []BoardOnCollectiveView
-	Events        []EventOnCollectiveView
+	Name          string                  `json:"name"`
+	Hash          string                  `json:"hash"` // hash of name for the reaction funcionalities
+	Link          string                  `json:"link"`
+	Description   string                  `json:"description"`
+	Majority      int                     `json:"majority"`
+	SuperMajority int                     `json:"superMajority"`
+	Quorum        int                     `json:"quorum"`
+	Accept        bool                    `json:"acceptOnExpiry"`
+	Strategy      string                  `json:"strategy"`
+	Members       []MemberDetailView      `json:"members"`
+	Membership    bool                    `json:"membership"`
+	Head          HeaderInfo              `json:"-"`
+	Stamps        []StampView             `json:"stamps"`
+	Boards        []BoardOnCollectiveView `json:"boards"`
+	Events        []EventOnCollectiveView `json:"events"`
 }
 
 func ColletivesFromState(s *state.State) CollectivesListView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "Collectives",
 		Path:    "explore / ",
@@ -1089,7 +1200,21 @@ func ColletivesFromState(s *state.State) CollectivesListView {
 	return view
 }
 
+func strategyName(kind byte) string {
+	switch kind {
+	case actions.WeightedStrategy:
+		return "weighted votes"
+	case actions.VetoStrategy:
+		return "majority with veto"
+	case actions.ReviewersStrategy:
+		return "named reviewers"
+	}
+	return "majority"
+}
+
 func CollectiveDetailFromState(s *state.State, i *index.Index, name string, token crypto.Token) *CollectiveDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	collectiveName, _ := url.QueryUnescape(name)
 	collective, ok := s.Collective(collectiveName)
 	fmt.Println(name, collective)
@@ -1103,6 +1228,9 @@ func CollectiveDetailFromState(s *state.State, i *index.Index, name string, toke
 		Description:   collective.Description,
 		Majority:      collective.Policy.Majority,
 		SuperMajority: collective.Policy.SuperMajority,
+		Quorum:        collective.Policy.Quorum,
+		Accept:        collective.Policy.AcceptOnExpiry,
+		Strategy:      strategyName(collective.Policy.Strategy.Kind),
 		Members:       make([]MemberDetailView, 0),
 		Membership:    collective.IsMember(token),
 		Stamps:        make([]StampView, 0),
@@ -11,46 +11,46 @@ import (
 )
 
 type EventsView struct {
-	Hash        string
-	Live        bool
-	Description string
-	StartAt     time.Time
-	Collective  NameLink
-	Public      bool
+	Hash        string    `json:"hash"`
+	Live        bool      `json:"live"`
+	Description string    `json:"description"`
+	StartAt     time.Time `json:"startAt"`
+	Collective  NameLink  `json:"collective"`
+	Public      bool      `json:"public"`
 }
 
 type EventVoteAction struct {
-	Kind   string // create, cancel, update
-	Hash   string
-	Update string
+	Kind   string `json:"kind"` // create, cancel, update
+	Hash   string `json:"hash"`
+	Update string `json:"update"`
 }
 
 type EventsListView struct {
-	Events []EventsView
-	Head   HeaderInfo
+	Events []EventsView `json:"events"`
+	Head   HeaderInfo   `json:"-"`
 }
 
 type VoteUpdateEventView struct {
-	Description     string
-	OldDescription  string
-	StartAt         string
-	OldStartAt      string
-	EstimatedEnd    string
-	OldEstimatedEnd string
-	Venue           string
-	OldVenue        string
-	Open            string
-	OldOpen         string
-	Public          string
-	OldPublic       string
-	Hash            string
-	Reasons         string
-	Collective      string
-	CollectiveLink  string
-	Managing        bool
-	VoteHash        string
-	Head            HeaderInfo
-	Voting          DetailedVoteView
+	Description     string           `json:"description"`
+	OldDescription  string           `json:"oldDescription"`
+	StartAt         string           `json:"startAt"`
+	OldStartAt      string           `json:"oldStartAt"`
+	EstimatedEnd    string           `json:"estimatedEnd"`
+	OldEstimatedEnd string           `json:"oldEstimatedEnd"`
+	Venue           string           `json:"venue"`
+	OldVenue        string           `json:"oldVenue"`
+	Open            string           `json:"open"`
+	OldOpen         string           `json:"oldOpen"`
+	Public          string           `json:"public"`
+	OldPublic       string           `json:"oldPublic"`
+	Hash            string           `json:"hash"`
+	Reasons         string           `json:"reasons"`
+	Collective      string           `json:"collective"`
+	CollectiveLink  string           `json:"collectiveLink"`
+	Managing        bool             `json:"managing"`
+	VoteHash        string           `json:"voteHash"`
+	Head            HeaderInfo       `json:"-"`
+	Voting          DetailedVoteView `json:"voting"`
 }
 
 func yesorno(b *bool) string {
@@ -64,6 +64,8 @@ func yesorno(b *bool) string {
 }
 
 func EventUpdateFromState(s *state.State, hash crypto.Hash, token crypto.Token) VoteUpdateEventView {
+	s.RLock()
+	defer s.RUnlock()
 	update, ok := s.Proposals.UpdateEvent[hash]
 	if !ok {
 		return VoteUpdateEventView{}
@@ -125,28 +127,30 @@ func EventUpdateFromState(s *state.State, hash crypto.Hash, token crypto.Token)
 }
 
 type EventDetailView struct {
-	Live               bool
-	Description        string
-	StartAt            time.Time
-	EstimatedEnd       time.Time
-	Collective         NameLink
-	MemberOfCollective bool
-	Venue              string
-	Open               bool
-	Public             bool
-	ManagerMajority    int
-	Managers           []MemberDetailView
-	Checkedin          []CheckInDetails
-	Votes              DetailedVoteView
-	Managing           bool
-	Hash               string
-	Greeted            []MemberDetailView
-	MyGreeting         string
-	Head               HeaderInfo
-	EventReasons       string
+	Live               bool               `json:"live"`
+	Description        string             `json:"description"`
+	StartAt            time.Time          `json:"startAt"`
+	EstimatedEnd       time.Time          `json:"estimatedEnd"`
+	Collective         NameLink           `json:"collective"`
+	MemberOfCollective bool               `json:"memberOfCollective"`
+	Venue              string             `json:"venue"`
+	Open               bool               `json:"open"`
+	Public             bool               `json:"public"`
+	ManagerMajority    int                `json:"managerMajority"`
+	Managers           []MemberDetailView `json:"managers"`
+	Checkedin          []CheckInDetails   `json:"checkedin"`
+	Votes              DetailedVoteView   `json:"votes"`
+	Managing           bool               `json:"managing"`
+	Hash               string             `json:"hash"`
+	Greeted            []MemberDetailView `json:"greeted"`
+	MyGreeting         string             `json:"myGreeting"`
+	Head               HeaderInfo         `json:"-"`
+	EventReasons       string             `json:"eventReasons"`
 }
 
 func PendingEventFromState(s *state.State, i *index.Index, hash crypto.Hash) *EventDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	event, ok := s.Proposals.CreateEvent[hash]
 	if !ok {
 		return &EventDetailView{
@@ -180,6 +184,8 @@ func PendingEventFromState(s *state.State, i *index.Index, hash crypto.Hash) *Ev
 }
 
 func CancelEventFromState(s *state.State, i *index.Index, hash crypto.Hash) *EventDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	cancel, ok := s.Proposals.CancelEvent[hash]
 	if !ok {
 		return nil
@@ -212,6 +218,8 @@ func CancelEventFromState(s *state.State, i *index.Index, hash crypto.Hash) *Eve
 }
 
 func EventsFromState(state *state.State) EventsListView {
+	state.RLock()
+	defer state.RUnlock()
 	head := HeaderInfo{
 		Active:  "Events",
 		Path:    "explore / ",
@@ -238,12 +246,14 @@ func EventsFromState(state *state.State) EventsListView {
 }
 
 type CheckInDetails struct {
-	Handle       NameLink
-	Reasons      string
-	EphemeralKey string
+	Handle       NameLink `json:"handle"`
+	Reasons      string   `json:"reasons"`
+	EphemeralKey string   `json:"ephemeralKey"`
 }
 
 func EventDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, token crypto.Token, ephemeral crypto.PrivateKey) *EventDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	event, ok := s.Events[hash]
 	if !ok {
 		event = s.Proposals.GetEvent(hash)
@@ -313,6 +323,8 @@ func EventDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, toke
 }
 
 func EventUpdateDetailFromState(s *state.State, i *index.Index, hash crypto.Hash, token crypto.Token) *EventDetailView {
+	s.RLock()
+	defer s.RUnlock()
 	event, ok := s.Events[hash]
 	if !ok {
 		event = s.Proposals.GetEvent(hash)
@@ -353,27 +365,29 @@ func EventUpdateDetailFromState(s *state.State, i *index.Index, hash crypto.Hash
 // Members template struct
 
 type MembersView struct {
-	Hash   string
-	Handle string
-	Link   string
+	Hash   string `json:"hash"`
+	Handle string `json:"handle"`
+	Link   string `json:"link"`
 }
 
 type MembersListView struct {
-	Members []MembersView
-	Head    HeaderInfo
+	Members []MembersView `json:"members"`
+	Head    HeaderInfo    `json:"-"`
 }
 
 type MemberDetailView struct {
-	Handle string
-	Link   string
+	Handle string `json:"handle"`
+	Link   string `json:"link"`
 }
 
 type MemberDetailViewPage struct {
-	Detail MemberDetailView
-	Head   HeaderInfo
+	Detail MemberDetailView `json:"detail"`
+	Head   HeaderInfo       `json:"-"`
 }
 
 func MembersFromState(state *state.State) MembersListView {
+	state.RLock()
+	defer state.RUnlock()
 	head := HeaderInfo{
 		Active:  "Members",
 		Path:    "explore / ",
@@ -397,6 +411,8 @@ func MembersFromState(state *state.State) MembersListView {
 }
 
 func MemberDetailFromState(state *state.State, handle string) *MemberDetailViewPage {
+	state.RLock()
+	defer state.RUnlock()
 	_, ok := state.MembersIndex[handle]
 	if !ok {
 		return nil
@@ -421,64 +437,66 @@ func MemberDetailFromState(state *state.State, handle string) *MemberDetailViewP
 // Central Connections
 
 type LastAction struct {
-	Type        string
-	Handle      string
-	TimeOfInstr string
+	Type        string `json:"type"`
+	Handle      string `json:"handle"`
+	TimeOfInstr string `json:"timeOfInstr"`
 	// TimeOfInstr time.Time
 }
 
 type LastReference struct {
-	Author      string
-	TimeOfInstr string
+	Author      string `json:"author"`
+	TimeOfInstr string `json:"timeOfInstr"`
 	// TimeOfInstr time.Time
 }
 
 type CentralCollectives struct {
-	Name     string
-	Link     string
-	NBoards  int
-	NStamps  int
-	NEvents  int
-	LastSelf LastAction
-	LastAny  LastAction
+	Name     string     `json:"name"`
+	Link     string     `json:"link"`
+	NBoards  int        `json:"nBoards"`
+	NStamps  int        `json:"nStamps"`
+	NEvents  int        `json:"nEvents"`
+	LastSelf LastAction `json:"lastSelf"`
+	LastAny  LastAction `json:"lastAny"`
 }
 
 type CentralBoards struct {
-	Name     string
-	Link     string
-	NPins    int
-	NEditors int
-	LastSelf LastAction
-	LastAny  LastAction
+	Name     string     `json:"name"`
+	Link     string     `json:"link"`
+	NPins    int        `json:"nPins"`
+	NEditors int        `json:"nEditors"`
+	LastSelf LastAction `json:"lastSelf"`
+	LastAny  LastAction `json:"lastAny"`
 }
 
 type CentralEvents struct {
-	Hash         string
-	DateCol      string //data e horario mais nome do coletivo
-	NCheckins    int
-	NPenCheckins int
+	Hash         string `json:"hash"`
+	DateCol      string `json:"dateCol"` //data e horario mais nome do coletivo
+	NCheckins    int    `json:"nCheckins"`
+	NPenCheckins int    `json:"nPenCheckins"`
 }
 
 type CentralEdits struct {
-	Title       string
-	CreatedAt   time.Time
-	NReferences int
-	LastRef     LastReference
+	Title       string        `json:"title"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	NReferences int           `json:"nReferences"`
+	LastRef     LastReference `json:"lastRef"`
 }
 
 type ConnectionsListView struct {
-	Head         HeaderInfo
-	Collectives  []CentralCollectives
-	NCollectives int
-	Boards       []CentralBoards
-	NBoards      int
-	Events       []CentralEvents
-	NEvents      int
-	Edits        []CentralEdits
-	NEdits       int
+	Head         HeaderInfo           `json:"-"`
+	Collectives  []CentralCollectives `json:"collectives"`
+	NCollectives int                  `json:"nCollectives"`
+	Boards       []CentralBoards      `json:"boards"`
+	NBoards      int                  `json:"nBoards"`
+	Events       []CentralEvents      `json:"events"`
+	NEvents      int                  `json:"nEvents"`
+	Edits        []CentralEdits       `json:"edits"`
+	NEdits       int                  `json:"nEdits"`
 }
 
 func ConnectionsFromState(state *state.State, indexer *index.Index, token crypto.Token, genesisTime time.Time) ConnectionsListView {
+	state.RLock()
+	defer state.RUnlock()
 	head := HeaderInfo{
 		Active:  "Connections",
 		Path:    "venture / ",
@@ -13,8 +13,8 @@ import (
 )
 
 type UpdatesView struct {
-	Objects []ObjectUpdateView
-	Head    HeaderInfo
+	Objects []ObjectUpdateView `json:"objects"`
+	Head    HeaderInfo         `json:"-"`
 }
 
 func (u *UpdatesView) Len() int {
@@ -30,10 +30,10 @@ func (u *UpdatesView) Swap(i, j int) {
 }
 
 type ObjectUpdateView struct {
-	Name       string
-	ObjectKind string
-	Updates    []ActionUpdateView
-	Reactions  []ReactionUpdateView
+	Name       string               `json:"name"`
+	ObjectKind string               `json:"objectKind"`
+	Updates    []ActionUpdateView   `json:"updates"`
+	Reactions  []ReactionUpdateView `json:"reactions"`
 }
 
 func (o ObjectUpdateView) LastUpdated() time.Time {
@@ -47,18 +47,18 @@ func (o ObjectUpdateView) LastUpdated() time.Time {
 }
 
 type ReactionUpdateView struct {
-	Description         string
-	Reasons             string
-	LastUpdatedInterval string
-	LastUpdatedTime     time.Time
+	Description         string    `json:"description"`
+	Reasons             string    `json:"reasons"`
+	LastUpdatedInterval string    `json:"lastUpdatedInterval"`
+	LastUpdatedTime     time.Time `json:"lastUpdatedTime"`
 }
 
 type ActionUpdateView struct {
-	Description         string
-	VoteStatus          string
-	VoteHash            string
-	LastUpdatedInterval string
-	LastUpdatedTime     time.Time
+	Description         string    `json:"description"`
+	VoteStatus          string    `json:"voteStatus"`
+	VoteHash            string    `json:"voteHash"`
+	LastUpdatedInterval string    `json:"lastUpdatedInterval"`
+	LastUpdatedTime     time.Time `json:"lastUpdatedTime"`
 }
 
 func actionsToActionUpdateView(actions []index.ActionDetails, genesisTime time.Time, token crypto.Token) ([]ActionUpdateView, []ReactionUpdateView) {
@@ -106,6 +106,8 @@ func actionsToActionUpdateView(actions []index.ActionDetails, genesisTime time.T
 }
 
 func UpdatesViewFromState(s *state.State, i *index.Index, token crypto.Token, genesisTime time.Time) *UpdatesView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "Updates",
 		Path:    "venture / ",
@@ -188,20 +190,23 @@ func UpdatesViewFromState(s *state.State, i *index.Index, token crypto.Token, ge
 }
 
 type PendingActionsView struct {
-	Pending []PendingActionDetailView
-	Head    HeaderInfo
+	Pending []PendingActionDetailView `json:"pending"`
+	Head    HeaderInfo                `json:"-"`
 }
 
 type PendingActionDetailView struct {
-	Description  string
-	ProposedAt   string
-	VotesApprove int
-	VotesReject  int
-	VotesNeeded  int
-	VoteHash     string
+	Description  string `json:"description"`
+	ProposedAt   string `json:"proposedAt"`
+	VotesApprove int    `json:"votesApprove"`
+	VotesReject  int    `json:"votesReject"`
+	VotesAbstain int    `json:"votesAbstain"`
+	VotesNeeded  int    `json:"votesNeeded"`
+	VoteHash     string `json:"voteHash"`
 }
 
 func PendingActionsFromState(s *state.State, i *index.Index, token crypto.Token, genesisTime time.Time) *PendingActionsView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "Pending",
 		Path:    "venture / ",
@@ -226,7 +231,9 @@ func PendingActionsFromState(s *state.State, i *index.Index, token crypto.Token,
 		}
 		for _, vote := range pending.Pool.Votes {
 			if _, ok := pending.Pool.Voters[vote.Author]; ok {
-				if vote.Approve {
+				if vote.Abstain {
+					item.VotesAbstain += 1
+				} else if vote.Approve {
 					item.VotesApprove += 1
 				} else {
 					item.VotesReject += 1
@@ -239,37 +246,39 @@ func PendingActionsFromState(s *state.State, i *index.Index, token crypto.Token,
 }
 
 type MyEditView struct {
-	DraftTitle  string
-	DraftHash   string
-	Hash        string
-	PublishedAt string
-	AuthorType  string
+	DraftTitle  string `json:"draftTitle"`
+	DraftHash   string `json:"draftHash"`
+	Hash        string `json:"hash"`
+	PublishedAt string `json:"publishedAt"`
+	AuthorType  string `json:"authorType"`
 }
 
 type EditOnDraftView struct {
-	Caption string
-	Link    string
-	Time    string
+	Caption string `json:"caption"`
+	Link    string `json:"link"`
+	Time    string `json:"time"`
 }
 
 type MyDraftView struct {
-	Title       string
-	Hash        string
-	PublishedAt string
-	Release     string
-	Pinned      []CaptionLink
-	Edit        []EditOnDraftView
-	Stamps      []CaptionLink
-	AuthorType  string
+	Title       string            `json:"title"`
+	Hash        string            `json:"hash"`
+	PublishedAt string            `json:"publishedAt"`
+	Release     string            `json:"release"`
+	Pinned      []CaptionLink     `json:"pinned"`
+	Edit        []EditOnDraftView `json:"edit"`
+	Stamps      []CaptionLink     `json:"stamps"`
+	AuthorType  string            `json:"authorType"`
 }
 
 type MyMediaView struct {
-	Drafts []MyDraftView
-	Edits  []MyEditView
-	Head   HeaderInfo
+	Drafts []MyDraftView `json:"drafts"`
+	Edits  []MyEditView  `json:"edits"`
+	Head   HeaderInfo    `json:"-"`
 }
 
 func MyMediaFromState(s *state.State, i *index.Index, token crypto.Token) *MyMediaView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "MyMedia",
 		Path:    "venture / my media / ",
@@ -380,28 +389,30 @@ func MyMediaFromState(s *state.State, i *index.Index, token crypto.Token) *MyMed
 }
 
 type NewActionView struct {
-	Action      string
-	Category    string
-	Duration    string
-	NotRepeated bool
+	Action      string `json:"action"`
+	Category    string `json:"category"`
+	Duration    string `json:"duration"`
+	NotRepeated bool   `json:"notRepeated"`
 }
 
 type NewActionsView struct {
-	NewStuff   int
-	Updates    int
-	Awareness  int
-	People     int
-	Collective int
-	Board      int
-	Event      int
-	Draft      int
-	Edit       int
-	Actions    []NewActionView
-	ReActions  []NewActionView
-	Head       HeaderInfo
+	NewStuff   int             `json:"newStuff"`
+	Updates    int             `json:"updates"`
+	Awareness  int             `json:"awareness"`
+	People     int             `json:"people"`
+	Collective int             `json:"collective"`
+	Board      int             `json:"board"`
+	Event      int             `json:"event"`
+	Draft      int             `json:"draft"`
+	Edit       int             `json:"edit"`
+	Actions    []NewActionView `json:"actions"`
+	ReActions  []NewActionView `json:"reActions"`
+	Head       HeaderInfo      `json:"-"`
 }
 
 func NewActionsFromState(s *state.State, i *index.Index, genesisTime time.Time) *NewActionsView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "News",
 		Path:    "explore / ",
@@ -465,30 +476,32 @@ func NewActionsFromState(s *state.State, i *index.Index, genesisTime time.Time)
 }
 
 type MyEventView struct {
-	Collective           string
-	StartAt              string
-	Description          string
-	Venue                string
-	Open                 bool
-	Public               bool
-	Greeting             bool
-	Attendee             []CaptionLink
-	AttendeeCount        int
-	GreetingCount        int
-	GreetingPendingCount int
-	Hash                 string
+	Collective           string        `json:"collective"`
+	StartAt              string        `json:"startAt"`
+	Description          string        `json:"description"`
+	Venue                string        `json:"venue"`
+	Open                 bool          `json:"open"`
+	Public               bool          `json:"public"`
+	Greeting             bool          `json:"greeting"`
+	Attendee             []CaptionLink `json:"attendee"`
+	AttendeeCount        int           `json:"attendeeCount"`
+	GreetingCount        int           `json:"greetingCount"`
+	GreetingPendingCount int           `json:"greetingPendingCount"`
+	Hash                 string        `json:"hash"`
 }
 
 type MyEventsView struct {
-	TodayCount    int
-	NextWeekCount int
-	FurtherCount  int
-	Events        []MyEventView
-	Managed       []MyEventView
-	Head          HeaderInfo
+	TodayCount    int           `json:"todayCount"`
+	NextWeekCount int           `json:"nextWeekCount"`
+	FurtherCount  int           `json:"furtherCount"`
+	Events        []MyEventView `json:"events"`
+	Managed       []MyEventView `json:"managed"`
+	Head          HeaderInfo    `json:"-"`
 }
 
 func MyEventsFromState(s *state.State, i *index.Index, token crypto.Token) *MyEventsView {
+	s.RLock()
+	defer s.RUnlock()
 	head := HeaderInfo{
 		Active:  "MyEvents",
 		Path:    "venture / my events / ",
@@ -571,26 +584,30 @@ func MyEventsFromState(s *state.State, i *index.Index, token crypto.Token) *MyEv
 }
 
 type DetailedVote struct {
-	Author  CaptionLink
-	Approve bool
-	Reasons string
+	Author  CaptionLink `json:"author"`
+	Approve bool        `json:"approve"`
+	Reasons string      `json:"reasons"`
 }
 
 type DetailedPool struct {
-	Description string
-	Reasons     string
-	Approve     []DetailedVote
-	Reject      []DetailedVote
-	Needed      int
-	NotVoted    []CaptionLink
-	Head        HeaderInfo
-	ProposedAt  string
+	Description string         `json:"description"`
+	Reasons     string         `json:"reasons"`
+	Approve     []DetailedVote `json:"approve"`
+	Reject      []DetailedVote `json:"reject"`
+	Abstain     []DetailedVote `json:"abstain"`
+	Needed      int            `json:"needed"`
+	NotVoted    []CaptionLink  `json:"notVoted"`
+	Head        HeaderInfo     `json:"-"`
+	ProposedAt  string         `json:"proposedAt"`
 }
 
 func DetailedVoteFromState(s *state.State, i *index.Index, hash crypto.Hash, genesisTime time.Time) *DetailedPool {
+	s.RLock()
+	defer s.RUnlock()
 	detailed := DetailedPool{
 		Approve:  make([]DetailedVote, 0),
 		Reject:   make([]DetailedVote, 0),
+		Abstain:  make([]DetailedVote, 0),
 		NotVoted: make([]CaptionLink, 0),
 	}
 	detailed.Head = HeaderInfo{
@@ -621,7 +638,9 @@ func DetailedVoteFromState(s *state.State, i *index.Index, hash crypto.Hash, gen
 				Approve: vote.Approve,
 				Reasons: vote.Reasons,
 			}
-			if vote.Approve {
+			if vote.Abstain {
+				detailed.Abstain = append(detailed.Abstain, voteDetailed)
+			} else if vote.Approve {
 				detailed.Approve = append(detailed.Approve, voteDetailed)
 			} else {
 				detailed.Reject = append(detailed.Reject, voteDetailed)
@@ -637,24 +656,26 @@ func DetailedVoteFromState(s *state.State, i *index.Index, hash crypto.Hash, gen
 }
 
 type DraftFromMember struct {
-	Title       string
-	Description string
-	Link        string
-	CoAuthors   []CaptionLink
-	Keywords    []string
+	Title       string        `json:"title"`
+	Description string        `json:"description"`
+	Link        string        `json:"link"`
+	CoAuthors   []CaptionLink `json:"coAuthors"`
+	Keywords    []string      `json:"keywords"`
 }
 
 type MemberView struct {
-	Head        HeaderInfo
-	Handle      string
-	Collectives []CaptionLink
-	Boards      []CaptionLink
-	Events      []CaptionLink
-	Drafts      []DraftFromMember
-	Edits       []CaptionLink
+	Head        HeaderInfo        `json:"-"`
+	Handle      string            `json:"handle"`
+	Collectives []CaptionLink     `json:"collectives"`
+	Boards      []CaptionLink     `json:"boards"`
+	Events      []CaptionLink     `json:"events"`
+	Drafts      []DraftFromMember `json:"drafts"`
+	Edits       []CaptionLink     `json:"edits"`
 }
 
 func MemberViewFromState(s *state.State, i *index.Index, handle string) *MemberView {
+	s.RLock()
+	defer s.RUnlock()
 	view := MemberView{
 		Head:        HeaderInfo{},
 		Handle:      handle,
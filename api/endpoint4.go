@@ -3,6 +3,9 @@ package api
 import (
 	"fmt"
 	"strings"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
 )
 
 func NewHeaderInfo(active, endpath, section string, path ...string) HeaderInfo {
@@ -23,3 +26,25 @@ func LimitStringSize(s string, size int) string {
 	}
 	return s
 }
+
+// MediaFromState returns the media content with the given hash and the file
+// type of the draft or edit it belongs to.
+func MediaFromState(s *state.State, hash crypto.Hash) ([]byte, string, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	file, ok := s.Media[hash]
+	if !ok {
+		return nil, "", false
+	}
+	var ext string
+	if edit, ok := s.Edits[hash]; ok {
+		ext = edit.EditType
+	} else if draft, ok := s.Drafts[hash]; ok {
+		ext = draft.DraftType
+	} else if draft, ok := s.Proposals.Draft[hash]; ok {
+		ext = draft.DraftType
+	} else if edit, ok := s.Proposals.Edit[hash]; ok {
+		ext = edit.EditType
+	}
+	return file, ext, true
+}
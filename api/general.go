@@ -2,10 +2,10 @@ package api
 
 import (
 	"bytes"
-	"crypto/rand"
 	"fmt"
 	"log"
 	"net/http"
+	"net/mail"
 	"strings"
 	"time"
 
@@ -20,8 +20,10 @@ func (a *AttorneyGeneral) CredentialsHandler(w http.ResponseWriter, r *http.Requ
 	}
 	handle := r.FormValue("handle")
 	password := r.FormValue("password")
+	a.state.RLock()
 	token, ok := a.state.MembersIndex[handle]
-	if !ok || !a.credentials.Check(token, crypto.Hasher([]byte(password))) {
+	a.state.RUnlock()
+	if !ok || !a.credentials.Check(token, password) {
 		header := HeaderInfo{
 			Error: "invalid credentials",
 		}
@@ -43,6 +45,7 @@ func (a *AttorneyGeneral) CredentialsHandler(w http.ResponseWriter, r *http.Requ
 		http.SetCookie(w, newCookie(cookie))
 		header := HeaderInfo{
 			UserHandle: handle,
+			CSRF:       a.csrfToken(cookie),
 		}
 		if err := a.templates.ExecuteTemplate(w, "main.html", header); err != nil {
 			log.Println(err)
@@ -55,11 +58,20 @@ func (a *AttorneyGeneral) NewUserHandler(w http.ResponseWriter, r *http.Request)
 		fmt.Fprintf(w, "ParseForm() err: %v", err)
 		return
 	}
-	email := r.FormValue("email")
 	handle := r.FormValue("handle")
+	address, err := mail.ParseAddress(r.FormValue("email"))
+	if handle == "" || err != nil {
+		if err := a.templates.ExecuteTemplate(w, "signin.html", HeaderInfo{Error: "a handle and a valid email are required"}); err != nil {
+			log.Println(err)
+		}
+		return
+	}
+	email := address.Address
+	a.state.RLock()
 	token, isMember := a.state.MembersIndex[handle]
+	a.state.RUnlock()
 	if isMember && a.credentials.Has(token) {
-		if err := a.templates.ExecuteTemplate(w, "login.html", "you are already a user: please log in"); err != nil {
+		if err := a.templates.ExecuteTemplate(w, "login.html", HeaderInfo{Error: "you are already a user: please log in"}); err != nil {
 			log.Println(err)
 		}
 		return
@@ -67,18 +79,21 @@ func (a *AttorneyGeneral) NewUserHandler(w http.ResponseWriter, r *http.Request)
 	if !isMember {
 		token, _ = crypto.RandomAsymetricKey()
 		signin := actions.Signin{
-			Epoch:   a.epoch,
+			Epoch:   a.epoch.Load(),
 			Author:  token,
 			Reasons: "new user",
 			Handle:  handle,
 		}
 		a.Send([]actions.Action{&signin}, token)
 	}
-	fingerprint := make([]byte, 32)
-	rand.Read(fingerprint)
-	//a.sendEmail(handle, email, crypto.EncodeHash(crypto.Hasher(fingerprint)), a.emailPassword)
-	a.credentials.Set(token, crypto.Hasher([]byte("1234")), email)
-	http.Redirect(w, r, "/login", http.StatusSeeOther)
+	if err := a.sendVerification(handle, email, token, isMember); err != nil {
+		log.Printf("could not send verification email: %v", err)
+		if err := a.templates.ExecuteTemplate(w, "signin.html", HeaderInfo{Error: "could not send verification email"}); err != nil {
+			log.Println(err)
+		}
+		return
+	}
+	a.renderVerify(w, VerifyView{Message: "a link to confirm your email and choose your password was sent to " + email})
 }
 
 func (a *AttorneyGeneral) ApiHandler(w http.ResponseWriter, r *http.Request) {
@@ -89,19 +104,27 @@ func (a *AttorneyGeneral) ApiHandler(w http.ResponseWriter, r *http.Request) {
 	var actionArray []actions.Action
 	var err error
 	author := a.Author(r)
+	// forms resolve handles to tokens
+	a.state.RLock()
 	switch r.FormValue("action") {
 	case "BoardEditor":
 		actionArray, err = BoardEditorForm(r, a.state.MembersIndex, author).ToAction()
 	case "CancelEvent":
 		actionArray, err = CancelEventForm(r).ToAction()
+	case "ChangeHandle":
+		actionArray, err = ChangeHandleForm(r).ToAction()
 	case "CheckinEvent":
 		actionArray, err = CheckinEventForm(r, a.ephemeralpub).ToAction()
+	case "Comment":
+		actionArray, err = CommentForm(r).ToAction()
 	case "CreateBoard":
 		actionArray, err = CreateBoardForm(r).ToAction()
 	case "CreateCollective":
 		actionArray, err = CreateCollectiveForm(r).ToAction()
 	case "CreateEvent":
 		actionArray, err = CreateEventForm(r, a.state.MembersIndex, author).ToAction()
+	case "GrantPowerOfAttorney":
+		actionArray, err = GrantPowerOfAttorneyForm(r).ToAction()
 	case "GreetCheckinEvent":
 		actionArray, err = GreetCheckinEventForm(r, a.state.MembersIndex).ToAction()
 	case "ImprintStamp":
@@ -116,6 +139,8 @@ func (a *AttorneyGeneral) ApiHandler(w http.ResponseWriter, r *http.Request) {
 		actionArray, err = RemoveMemberForm(r, a.state.MembersIndex).ToAction()
 	case "RequestMembership":
 		actionArray, err = RequestMembershipForm(r).ToAction()
+	case "RevokePowerOfAttorney":
+		actionArray, err = RevokePowerOfAttorneyForm(r).ToAction()
 	case "UpdateBoard":
 		actionArray, err = UpdateBoardForm(r).ToAction()
 	case "UpdateCollective":
@@ -124,17 +149,24 @@ func (a *AttorneyGeneral) ApiHandler(w http.ResponseWriter, r *http.Request) {
 		actionArray, err = UpdateEventForm(r, a.state.MembersIndex).ToAction()
 	case "Vote":
 		actionArray, err = VoteForm(r).ToAction()
+	case "Withdraw":
+		actionArray, err = WithdrawForm(r).ToAction()
 	}
-	if err == nil && len(actionArray) > 0 {
+	a.state.RUnlock()
+	if err != nil {
+		log.Printf("invalid %v form: %v", r.FormValue("action"), err)
+	} else if len(actionArray) > 0 {
 		a.Send(actionArray, author)
 	}
-	redirect := fmt.Sprintf("/%v", r.FormValue("redirect"))
+	// only local redirects
+	redirect := fmt.Sprintf("/%v", strings.TrimLeft(r.FormValue("redirect"), "/\\"))
 	http.Redirect(w, r, redirect, http.StatusSeeOther)
 }
 
 func (a *AttorneyGeneral) MainHandler(w http.ResponseWriter, r *http.Request) {
 	header := HeaderInfo{
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", header); err != nil {
 		log.Println(err)
@@ -160,9 +192,12 @@ func (a *AttorneyGeneral) SigninHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (a *AttorneyGeneral) SignoutHandler(w http.ResponseWriter, r *http.Request) {
-	cookie, _ := r.Cookie(cookieName)
-	author := a.Author(r)
-	a.session.Unset(author, cookie.Value)
+	if cookie, err := r.Cookie(cookieName); err == nil {
+		a.session.Unset(a.Author(r), cookie.Value)
+	}
+	expired := newCookie("")
+	expired.MaxAge = -1
+	http.SetCookie(w, expired)
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
@@ -173,6 +208,7 @@ func (a *AttorneyGeneral) CreateCollectiveHandler(w http.ResponseWriter, r *http
 		EndPath:  "create collective",
 		Section:  "venture",
 		UserName: a.Handle(r),
+		CSRF:     a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "createcollective.html", head); err != nil {
 		log.Println(err)
@@ -187,6 +223,7 @@ func (a *AttorneyGeneral) NewDraft2Handler(w http.ResponseWriter, r *http.Reques
 	view := NewDraftVersion(a.state, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "newdraft2.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -196,6 +233,7 @@ func (a *AttorneyGeneral) NewDraft2Handler(w http.ResponseWriter, r *http.Reques
 	head := HeaderInfo{
 		Error:      "draft not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -208,6 +246,7 @@ func (a *AttorneyGeneral) EditViewHandler(w http.ResponseWriter, r *http.Request
 	view := EditDetailFromState(a.state, a.indexer, hash, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "editview.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -217,6 +256,7 @@ func (a *AttorneyGeneral) EditViewHandler(w http.ResponseWriter, r *http.Request
 	head := HeaderInfo{
 		Error:      "edit not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -228,24 +268,14 @@ func (a *AttorneyGeneral) MediaHandler(w http.ResponseWriter, r *http.Request) {
 	hashtext = strings.Replace(hashtext, "/media/", "", 1)
 	hash := crypto.DecodeHash(hashtext)
 
-	file, ok := a.state.Media[hash]
+	file, ext, ok := MediaFromState(a.state, hash)
 	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("file not found"))
 		return
 	}
 	title := hashtext
-	var ext string
-	if edit, ok := a.state.Edits[hash]; ok {
-		ext = edit.EditType
-	} else if draft, ok := a.state.Drafts[hash]; ok {
-		ext = draft.DraftType
-	} else if draft, ok := a.state.Proposals.Draft[hash]; ok {
-		ext = draft.DraftType
-	} else if edit, ok := a.state.Proposals.Edit[hash]; ok {
-		ext = edit.EditType
-	}
-	name := fmt.Sprintf("%v", title, ext)
+	name := fmt.Sprintf("%v.%v", title, ext)
 	//cd := mime.FormatMediaType("attachment", map[string]string{"filename": name})
 	//w.Header().Set("Content-Disposition", cd)
 	//w.Header().Set("Content-Type", "application/octet-stream")
@@ -261,6 +291,7 @@ func (a *AttorneyGeneral) NewEditHandler(w http.ResponseWriter, r *http.Request)
 	view := NewEdit(a.state, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "edit.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -270,6 +301,7 @@ func (a *AttorneyGeneral) NewEditHandler(w http.ResponseWriter, r *http.Request)
 	head := HeaderInfo{
 		Error:      "could not render new edit form",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -279,6 +311,7 @@ func (a *AttorneyGeneral) NewEditHandler(w http.ResponseWriter, r *http.Request)
 func (a *AttorneyGeneral) BoardsHandler(w http.ResponseWriter, r *http.Request) {
 	view := BoardsFromState(a.state)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "boards.html", view); err != nil {
 		log.Println(err)
 	} else {
@@ -293,6 +326,7 @@ func (a *AttorneyGeneral) BoardHandler(w http.ResponseWriter, r *http.Request) {
 	view := BoardDetailFromState(a.state, boardName, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "board.html", view); err != nil {
 			log.Println(err)
 		}
@@ -300,6 +334,7 @@ func (a *AttorneyGeneral) BoardHandler(w http.ResponseWriter, r *http.Request) {
 	head := HeaderInfo{
 		Error:      "board not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -309,6 +344,7 @@ func (a *AttorneyGeneral) BoardHandler(w http.ResponseWriter, r *http.Request) {
 func (a *AttorneyGeneral) CollectivesHandler(w http.ResponseWriter, r *http.Request) {
 	view := ColletivesFromState(a.state)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "collectives.html", view); err != nil {
 		log.Println(err)
 	}
@@ -321,6 +357,7 @@ func (a *AttorneyGeneral) CollectiveHandler(w http.ResponseWriter, r *http.Reque
 	view := CollectiveDetailFromState(a.state, a.indexer, name, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "collective.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -330,6 +367,7 @@ func (a *AttorneyGeneral) CollectiveHandler(w http.ResponseWriter, r *http.Reque
 	head := HeaderInfo{
 		Error:      "collective not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -339,6 +377,7 @@ func (a *AttorneyGeneral) CollectiveHandler(w http.ResponseWriter, r *http.Reque
 func (a *AttorneyGeneral) DraftsHandler(w http.ResponseWriter, r *http.Request) {
 	view := DraftsFromState(a.state)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "drafts.html", view); err != nil {
 		log.Println(err)
 	}
@@ -352,6 +391,7 @@ func (a *AttorneyGeneral) DraftHandler(w http.ResponseWriter, r *http.Request) {
 	view := DraftDetailFromState(a.state, a.indexer, hash, author, a.genesisTime)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "draft.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -361,6 +401,7 @@ func (a *AttorneyGeneral) DraftHandler(w http.ResponseWriter, r *http.Request) {
 	head := HeaderInfo{
 		Error:      "draft not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -371,6 +412,7 @@ func (a *AttorneyGeneral) EditsHandler(w http.ResponseWriter, r *http.Request) {
 	hash := getHash(r.URL.Path, "/edits/")
 	view := EditsFromState(a.state, hash)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "edits.html", view); err != nil {
 		log.Println(err)
 	}
@@ -379,6 +421,7 @@ func (a *AttorneyGeneral) EditsHandler(w http.ResponseWriter, r *http.Request) {
 func (a *AttorneyGeneral) EventsHandler(w http.ResponseWriter, r *http.Request) {
 	view := EventsFromState(a.state)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "events.html", view); err != nil {
 		log.Println(err)
 	}
@@ -392,6 +435,7 @@ func (a *AttorneyGeneral) EventHandler(w http.ResponseWriter, r *http.Request) {
 	view := EventDetailFromState(a.state, a.indexer, hash, author, a.ephemeralprv)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "event.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -401,6 +445,7 @@ func (a *AttorneyGeneral) EventHandler(w http.ResponseWriter, r *http.Request) {
 	head := HeaderInfo{
 		Error:      "event not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -411,6 +456,7 @@ func (a *AttorneyGeneral) VotesHandler(w http.ResponseWriter, r *http.Request) {
 	author := a.Author(r)
 	view := VotesFromState(a.state, a.indexer, author)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "votes.html", view); err != nil {
 		log.Println(err)
 	}
@@ -419,6 +465,7 @@ func (a *AttorneyGeneral) VotesHandler(w http.ResponseWriter, r *http.Request) {
 func (a *AttorneyGeneral) MembersHandler(w http.ResponseWriter, r *http.Request) {
 	view := MembersFromState(a.state)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "members.html", view); err != nil {
 		log.Println(err)
 	}
@@ -430,6 +477,7 @@ func (a *AttorneyGeneral) MemberHandler(w http.ResponseWriter, r *http.Request)
 	view := MemberViewFromState(a.state, a.indexer, name)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "member.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -439,6 +487,7 @@ func (a *AttorneyGeneral) MemberHandler(w http.ResponseWriter, r *http.Request)
 	head := HeaderInfo{
 		Error:      "member not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -457,6 +506,7 @@ func (a *AttorneyGeneral) CreateBoardHandler(w http.ResponseWriter, r *http.Requ
 		EndPath:    "create board",
 		Section:    "venture",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	info := TemplateInfo{
 		Head:           head,
@@ -472,6 +522,7 @@ func (a *AttorneyGeneral) VoteCreateBoardHandler(w http.ResponseWriter, r *http.
 	view := PendingBoardFromState(a.state, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "votecreateboard.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -481,6 +532,7 @@ func (a *AttorneyGeneral) VoteCreateBoardHandler(w http.ResponseWriter, r *http.
 	head := HeaderInfo{
 		Error:      "pending board not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -492,6 +544,7 @@ func (a *AttorneyGeneral) VoteCreateEventHandler(w http.ResponseWriter, r *http.
 	view := PendingEventFromState(a.state, a.indexer, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "votecreateevent.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -501,6 +554,7 @@ func (a *AttorneyGeneral) VoteCreateEventHandler(w http.ResponseWriter, r *http.
 	head := HeaderInfo{
 		Error:      "proposed event not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -512,6 +566,7 @@ func (a *AttorneyGeneral) VoteCancelEventHandler(w http.ResponseWriter, r *http.
 	view := CancelEventFromState(a.state, a.indexer, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "votecancelevent.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -521,6 +576,7 @@ func (a *AttorneyGeneral) VoteCancelEventHandler(w http.ResponseWriter, r *http.
 	head := HeaderInfo{
 		Error:      "event to be cancelled not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -532,6 +588,7 @@ func (a *AttorneyGeneral) UpdateCollectiveHandler(w http.ResponseWriter, r *http
 	view := CollectiveToUpdateFromState(a.state, collective)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "updatecollective.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -541,6 +598,7 @@ func (a *AttorneyGeneral) UpdateCollectiveHandler(w http.ResponseWriter, r *http
 	head := HeaderInfo{
 		Error:      "collective to be updated not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -553,6 +611,7 @@ func (a *AttorneyGeneral) VoteUpdateCollectiveHandler(w http.ResponseWriter, r *
 	view := CollectiveUpdateFromState(a.state, hash, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "voteupdatecollective.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -562,6 +621,7 @@ func (a *AttorneyGeneral) VoteUpdateCollectiveHandler(w http.ResponseWriter, r *
 	head := HeaderInfo{
 		Error:      "proposed collective update not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -573,6 +633,7 @@ func (a *AttorneyGeneral) UpdateBoardHandler(w http.ResponseWriter, r *http.Requ
 	view := BoardToUpdateFromState(a.state, board)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "updateboard.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -582,6 +643,7 @@ func (a *AttorneyGeneral) UpdateBoardHandler(w http.ResponseWriter, r *http.Requ
 	head := HeaderInfo{
 		Error:      "board to de updated not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -593,6 +655,7 @@ func (a *AttorneyGeneral) VoteUpdateBoardHandler(w http.ResponseWriter, r *http.
 	view := BoardUpdateFromState(a.state, hash)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "votecreateboard.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -602,6 +665,7 @@ func (a *AttorneyGeneral) VoteUpdateBoardHandler(w http.ResponseWriter, r *http.
 	head := HeaderInfo{
 		Error:      "proposed board not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -614,6 +678,7 @@ func (a *AttorneyGeneral) UpdateEventHandler(w http.ResponseWriter, r *http.Requ
 	view := EventUpdateDetailFromState(a.state, a.indexer, hash, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "updateevent.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -623,6 +688,7 @@ func (a *AttorneyGeneral) UpdateEventHandler(w http.ResponseWriter, r *http.Requ
 	head := HeaderInfo{
 		Error:      "event to be updated not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -641,6 +707,7 @@ func (a *AttorneyGeneral) CreateEventHandler(w http.ResponseWriter, r *http.Requ
 		EndPath:    "create event",
 		Section:    "venture",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	info := TemplateInfo{
 		Head:           head,
@@ -656,6 +723,7 @@ func (a *AttorneyGeneral) VoteUpdateEventHandler(w http.ResponseWriter, r *http.
 	hash := getHash(r.URL.Path, "/voteupdateevent/")
 	view := EventUpdateFromState(a.state, hash, author)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "voteupdateevent.html", view); err != nil {
 		log.Println(err)
 	}
@@ -680,6 +748,7 @@ func (a *AttorneyGeneral) ConnectionsHandler(w http.ResponseWriter, r *http.Requ
 	author := a.Author(r)
 	view := ConnectionsFromState(a.state, a.indexer, author, a.genesisTime)
 	view.Head.UserHandle = a.Handle(r)
+	view.Head.CSRF = a.CSRF(r)
 	if err := a.templates.ExecuteTemplate(w, "connections.html", view); err != nil {
 		log.Println(err)
 	}
@@ -690,6 +759,7 @@ func (a *AttorneyGeneral) UpdatesHandler(w http.ResponseWriter, r *http.Request)
 	view := UpdatesViewFromState(a.state, a.indexer, author, a.genesisTime)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "updates.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -699,6 +769,7 @@ func (a *AttorneyGeneral) UpdatesHandler(w http.ResponseWriter, r *http.Request)
 	head := HeaderInfo{
 		Error:      "could not load updates",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -710,6 +781,7 @@ func (a *AttorneyGeneral) PendingActionsHandler(w http.ResponseWriter, r *http.R
 	view := PendingActionsFromState(a.state, a.indexer, author, a.genesisTime)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "pending.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -719,6 +791,7 @@ func (a *AttorneyGeneral) PendingActionsHandler(w http.ResponseWriter, r *http.R
 	head := HeaderInfo{
 		Error:      "could not load pending actions",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -730,6 +803,7 @@ func (a *AttorneyGeneral) MyMediaHandler(w http.ResponseWriter, r *http.Request)
 	view := MyMediaFromState(a.state, a.indexer, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "mymedia.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -739,6 +813,7 @@ func (a *AttorneyGeneral) MyMediaHandler(w http.ResponseWriter, r *http.Request)
 	head := HeaderInfo{
 		Error:      "could not load my media",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -750,6 +825,7 @@ func (a *AttorneyGeneral) MyEventsHandler(w http.ResponseWriter, r *http.Request
 	view := MyEventsFromState(a.state, a.indexer, author)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "myevents.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -759,6 +835,7 @@ func (a *AttorneyGeneral) MyEventsHandler(w http.ResponseWriter, r *http.Request
 	head := HeaderInfo{
 		Error:      "could not load my events",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -769,6 +846,7 @@ func (a *AttorneyGeneral) NewsHandler(w http.ResponseWriter, r *http.Request) {
 	view := NewActionsFromState(a.state, a.indexer, a.genesisTime)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "news.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -778,6 +856,7 @@ func (a *AttorneyGeneral) NewsHandler(w http.ResponseWriter, r *http.Request) {
 	head := HeaderInfo{
 		Error:      "could not load news",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -789,6 +868,7 @@ func (a *AttorneyGeneral) DetailedVoteHandler(w http.ResponseWriter, r *http.Req
 	view := DetailedVoteFromState(a.state, a.indexer, hash, a.genesisTime)
 	if view != nil {
 		view.Head.UserHandle = a.Handle(r)
+		view.Head.CSRF = a.CSRF(r)
 		if err := a.templates.ExecuteTemplate(w, "detailedvote.html", view); err != nil {
 			log.Println(err)
 		} else {
@@ -798,6 +878,7 @@ func (a *AttorneyGeneral) DetailedVoteHandler(w http.ResponseWriter, r *http.Req
 	head := HeaderInfo{
 		Error:      "votes details not found",
 		UserHandle: a.Handle(r),
+		CSRF:       a.CSRF(r),
 	}
 	if err := a.templates.ExecuteTemplate(w, "main.html", head); err != nil {
 		log.Println(err)
@@ -30,24 +30,14 @@ func (a *Attorney) MediaHandler(w http.ResponseWriter, r *http.Request) {
 	hashtext = strings.Replace(hashtext, "/media/", "", 1)
 	hash := crypto.DecodeHash(hashtext)
 
-	file, ok := a.state.Media[hash]
+	file, ext, ok := MediaFromState(a.state, hash)
 	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("file not found"))
 		return
 	}
 	title := hashtext
-	var ext string
-	if edit, ok := a.state.Edits[hash]; ok {
-		ext = edit.EditType
-	} else if draft, ok := a.state.Drafts[hash]; ok {
-		ext = draft.DraftType
-	} else if draft, ok := a.state.Proposals.Draft[hash]; ok {
-		ext = draft.DraftType
-	} else if edit, ok := a.state.Proposals.Edit[hash]; ok {
-		ext = edit.EditType
-	}
-	name := fmt.Sprintf("%v", title, ext)
+	name := fmt.Sprintf("%v.%v", title, ext)
 	//cd := mime.FormatMediaType("attachment", map[string]string{"filename": name})
 	//w.Header().Set("Content-Disposition", cd)
 	//w.Header().Set("Content-Type", "application/octet-stream")
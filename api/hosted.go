@@ -6,11 +6,11 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
-	"github.com/lienkolabs/breeze/util"
 	"github.com/lienkolabs/synergy/social"
 	"github.com/lienkolabs/synergy/social/actions"
 	"github.com/lienkolabs/synergy/social/index"
@@ -25,24 +25,28 @@ func newCookie(value string) *http.Cookie {
 	return &http.Cookie{
 		Name:     cookieName,
 		Value:    url.QueryEscape(value),
+		Path:     "/",
 		MaxAge:   cookieLifeItemSeconds,
 		Secure:   true,
 		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
 	}
 }
 
 type AttorneyGeneral struct {
-	epoch         uint64
-	pk            crypto.PrivateKey
-	credentials   PasswordManager
-	wallet        crypto.PrivateKey
-	pending       map[crypto.Hash]actions.Action
-	gateway       social.Gatewayer
-	state         *state.State
-	templates     *template.Template
-	indexer       *index.Index
-	session       *CookieStore
-	emailPassword string
+	epoch       atomic.Uint64 // written on new blocks, read by the handlers
+	pk          crypto.PrivateKey
+	credentials PasswordManager
+	wallet      crypto.PrivateKey
+	tracker     *ActionTracker
+	gateway     social.Gatewayer
+	state       *state.State
+	templates   *template.Template
+	indexer     *index.Index
+	session     *CookieStore
+	mailer      Mailer
+	mailTokens  *MailTokens
+	url         string // public address of the server for emailed links
 	//session      map[string]crypto.Token
 	//sessionend   map[uint64][]string
 	genesisTime  time.Time
@@ -55,7 +59,9 @@ func (a *AttorneyGeneral) DestroySession(token crypto.Token, cookie string) {
 }
 
 func (a *AttorneyGeneral) CreateSession(handle string) string {
+	a.state.RLock()
 	token, ok := a.state.MembersIndex[handle]
+	a.state.RUnlock()
 	if !ok {
 		return ""
 	}
@@ -65,7 +71,7 @@ func (a *AttorneyGeneral) CreateSession(handle string) string {
 		return ""
 	}
 	cookie := hex.EncodeToString(seed)
-	a.session.Set(token, cookie, a.epoch)
+	a.session.Set(token, cookie, a.epoch.Load())
 	return cookie
 }
 
@@ -82,8 +88,9 @@ func (a *AttorneyGeneral) Author(r *http.Request) crypto.Token {
 
 func (a *AttorneyGeneral) Handle(r *http.Request) string {
 	author := a.Author(r)
-	handle := a.state.Members[crypto.HashToken(author)]
-	return handle
+	a.state.RLock()
+	defer a.state.RUnlock()
+	return a.state.Members[crypto.HashToken(author)]
 }
 
 func (a *AttorneyGeneral) Send(all []actions.Action, author crypto.Token) {
@@ -96,22 +103,5 @@ func (a *AttorneyGeneral) Send(all []actions.Action, author crypto.Token) {
 // Dress a giving action with current epoch, attorney´s author
 // attorneys signature, attorneys wallet and wallet signature
 func (a *AttorneyGeneral) DressAction(action actions.Action, author crypto.Token) []byte {
-	bytes := action.Serialize()
-	dress := []byte{0}
-	util.PutUint64(a.epoch, &dress)
-	util.PutToken(author, &dress)
-	dress = append(dress, 0, 1, 0, 0) // axe void synergy
-	dress = append(dress, bytes[8+crypto.TokenSize:]...)
-	util.PutToken(a.pk.PublicKey(), &dress)
-	signature := a.pk.Sign(dress)
-	util.PutSignature(signature, &dress)
-	util.PutToken(a.wallet.PublicKey(), &dress)
-	util.PutUint64(0, &dress) // zero fee
-	signature = a.wallet.Sign(dress)
-	util.PutSignature(signature, &dress)
-	return dress
-}
-
-func (a *AttorneyGeneral) Confirmed(hash crypto.Hash) {
-	delete(a.pending, hash)
+	return actions.Dress(action.Serialize(), a.epoch.Load(), author, a.pk, a.wallet, 0)
 }
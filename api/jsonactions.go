@@ -2,7 +2,6 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
@@ -14,12 +13,15 @@ import (
 	actions
 		BoardEditor
 		CancelEvent
+		ChangeHandle
 		CheckinEvent
+		Comment
 		CreateBoard
 		CreateCollective
 		CreateEvent
 		Draft
 		Edit
+		GrantPowerOfAttorney
 		GreetCheckinEvent
 		ImprintStamp
 		Pin
@@ -27,15 +29,22 @@ import (
 		ReleaseDraft
 		RemoveMember
 		RequestMembership
+		RevokePowerOfAttorney
+		RotateKey
 		UpdateBoard
 		UpdateCollective
 		UpdateEvent
 		Vote
+		Withdraw
 */
 
 type Policy struct {
-	Majority      int `json:"majority"`
-	SuperMajority int `json:"superMajority"`
+	Majority       int              `json:"majority"`
+	SuperMajority  int              `json:"superMajority"`
+	Quorum         int              `json:"quorum"`
+	AcceptOnExpiry bool             `json:"acceptOnExpiry"`
+	VotingWindow   map[byte]uint64  `json:"votingWindow,omitempty"`
+	Strategy       actions.Strategy `json:"strategy"`
 }
 
 type Action struct {
@@ -75,7 +84,6 @@ func (a MultiGreetCheckinEvent) ToAction() ([]actions.Action, error) {
 		action.EphemeralToken = pub
 		action.SecretKey = dhCipher.Seal(key)
 		all = append(all, &action)
-		fmt.Println("%+v", action)
 	}
 	return all, nil
 }
@@ -141,6 +149,21 @@ func (a CancelEvent) ToAction() ([]actions.Action, error) {
 	return []actions.Action{&action}, nil
 }
 
+type ChangeHandle struct {
+	Action  string `json:"action"`
+	ID      int    `json:"id"`
+	Reasons string `json:"reasons"`
+	Handle  string `json:"handle"`
+}
+
+func (a ChangeHandle) ToAction() ([]actions.Action, error) {
+	action := actions.ChangeHandle{
+		Reasons: a.Reasons,
+		Handle:  a.Handle,
+	}
+	return []actions.Action{&action}, nil
+}
+
 type CheckinEvent struct {
 	Action         string       `json:"action"`
 	ID             int          `json:"id"`
@@ -158,6 +181,27 @@ func (a CheckinEvent) ToAction() ([]actions.Action, error) {
 	return []actions.Action{&action}, nil
 }
 
+type Comment struct {
+	Action     string      `json:"action"`
+	ID         int         `json:"id"`
+	Reasons    string      `json:"reasons"`
+	OnBehalfOf string      `json:"onBehalfOf,omitempty"`
+	Object     crypto.Hash `json:"object"`
+	Parent     crypto.Hash `json:"parent"`
+	Content    string      `json:"content"`
+}
+
+func (a Comment) ToAction() ([]actions.Action, error) {
+	action := actions.Comment{
+		Reasons:    a.Reasons,
+		OnBehalfOf: a.OnBehalfOf,
+		Object:     a.Object,
+		Parent:     a.Parent,
+		Content:    a.Content,
+	}
+	return []actions.Action{&action}, nil
+}
+
 type CreateBoard struct {
 	Action      string   `json:"action"`
 	ID          int      `json:"id"`
@@ -311,6 +355,21 @@ func (a Edit) ToAction() ([]actions.Action, error) {
 	return allActions, nil
 }
 
+type GrantPowerOfAttorney struct {
+	Action   string       `json:"action"`
+	ID       int          `json:"id"`
+	Reasons  string       `json:"reasons"`
+	Attorney crypto.Token `json:"attorney"`
+}
+
+func (a GrantPowerOfAttorney) ToAction() ([]actions.Action, error) {
+	action := actions.GrantPowerOfAttorney{
+		Reasons:  a.Reasons,
+		Attorney: a.Attorney,
+	}
+	return []actions.Action{&action}, nil
+}
+
 type ImprintStamp struct {
 	Action     string      `json:"action"`
 	ID         int         `json:"id"`
@@ -415,6 +474,39 @@ func (a RequestMembership) ToAction() ([]actions.Action, error) {
 	return []actions.Action{&action}, nil
 }
 
+type RevokePowerOfAttorney struct {
+	Action   string       `json:"action"`
+	ID       int          `json:"id"`
+	Reasons  string       `json:"reasons"`
+	Attorney crypto.Token `json:"attorney"`
+}
+
+func (a RevokePowerOfAttorney) ToAction() ([]actions.Action, error) {
+	action := actions.RevokePowerOfAttorney{
+		Reasons:  a.Reasons,
+		Attorney: a.Attorney,
+	}
+	return []actions.Action{&action}, nil
+}
+
+// RotateKey must be signed by the key of the member, through /api/v1/unsigned
+type RotateKey struct {
+	Action   string       `json:"action"`
+	ID       int          `json:"id"`
+	Reasons  string       `json:"reasons"`
+	NewKey   crypto.Token `json:"newKey"`
+	TimeLock uint64       `json:"timeLock,omitempty"`
+}
+
+func (a RotateKey) ToAction() ([]actions.Action, error) {
+	action := actions.RotateKey{
+		Reasons:  a.Reasons,
+		NewKey:   a.NewKey,
+		TimeLock: a.TimeLock,
+	}
+	return []actions.Action{&action}, nil
+}
+
 type UpdateBoard struct {
 	Action      string    `json:"action"`
 	ID          int       `json:"id"`
@@ -437,22 +529,30 @@ func (a UpdateBoard) ToAction() ([]actions.Action, error) {
 }
 
 type UpdateCollective struct {
-	Action        string  `json:"action"`
-	ID            int     `json:"id"`
-	Reasons       string  `json:"reasons"`
-	OnBehalfOf    string  `json:"onBehalfOf"`
-	Description   *string `json:"description,omitempty"`
-	Majority      *byte   `json:"majority,omitempty"`
-	SuperMajority *byte   `json:"superMajority,omitempty"`
+	Action         string            `json:"action"`
+	ID             int               `json:"id"`
+	Reasons        string            `json:"reasons"`
+	OnBehalfOf     string            `json:"onBehalfOf"`
+	Description    *string           `json:"description,omitempty"`
+	Majority       *byte             `json:"majority,omitempty"`
+	SuperMajority  *byte             `json:"superMajority,omitempty"`
+	Quorum         *byte             `json:"quorum,omitempty"`
+	AcceptOnExpiry *bool             `json:"acceptOnExpiry,omitempty"`
+	VotingWindow   map[byte]uint64   `json:"votingWindow,omitempty"`
+	Strategy       *actions.Strategy `json:"strategy,omitempty"`
 }
 
 func (a UpdateCollective) ToAction() ([]actions.Action, error) {
 	action := actions.UpdateCollective{
-		Reasons:       a.Reasons,
-		OnBehalfOf:    a.OnBehalfOf,
-		Description:   a.Description,
-		Majority:      a.Majority,
-		SuperMajority: a.SuperMajority,
+		Reasons:        a.Reasons,
+		OnBehalfOf:     a.OnBehalfOf,
+		Description:    a.Description,
+		Majority:       a.Majority,
+		SuperMajority:  a.SuperMajority,
+		Quorum:         a.Quorum,
+		AcceptOnExpiry: a.AcceptOnExpiry,
+		VotingWindow:   a.VotingWindow,
+		Strategy:       a.Strategy,
 	}
 	return []actions.Action{&action}, nil
 }
@@ -494,6 +594,7 @@ type Vote struct {
 	Reasons string      `json:"reasons,omitempty"`
 	Hash    crypto.Hash `json:"hash"`
 	Approve bool        `json:"approve"`
+	Abstain bool        `json:"abstain,omitempty"`
 }
 
 func (a Vote) ToAction() ([]actions.Action, error) {
@@ -501,6 +602,26 @@ func (a Vote) ToAction() ([]actions.Action, error) {
 		Reasons: a.Reasons,
 		Hash:    a.Hash,
 		Approve: a.Approve,
+		Abstain: a.Abstain,
+	}
+	return []actions.Action{&action}, nil
+}
+
+// Withdraw takes back a pending proposal of the author, or of the collective
+// OnBehalfOf after its consensus.
+type Withdraw struct {
+	Action     string      `json:"action"`
+	ID         int         `json:"id"`
+	Reasons    string      `json:"reasons"`
+	OnBehalfOf string      `json:"onBehalfOf,omitempty"`
+	Hash       crypto.Hash `json:"hash"`
+}
+
+func (a Withdraw) ToAction() ([]actions.Action, error) {
+	action := actions.Withdraw{
+		Reasons:    a.Reasons,
+		OnBehalfOf: a.OnBehalfOf,
+		Hash:       a.Hash,
 	}
 	return []actions.Action{&action}, nil
 }
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+const (
+	liveKeepAlive    = 15 * time.Second
+	liveWriteTimeout = 5 * time.Second
+)
+
+// kinds of live events
+const (
+	LiveVote      = "vote"      // a proposal the member needs to vote on
+	LiveConsensus = "consensus" // a proposal of the member's collectives was approved
+	LiveExpired   = "expired"   // a proposal the member had to vote on expired
+	LivePin       = "pin"       // a draft was pinned on a board the member follows
+	LiveGreet     = "greet"     // the member's check-in on an event was greeted
+	LiveMissed    = "missed"    // updates were lost, the page should be reloaded
+)
+
+var objectNames = map[state.Object]string{
+	state.DraftObject:      "draft",
+	state.EditObject:       "edit",
+	state.BoardObject:      "board",
+	state.JournalObject:    "journal",
+	state.EventObject:      "event",
+	state.CollectiveObject: "collective",
+	state.MemberObject:     "member",
+	state.MediaObject:      "media",
+}
+
+type LiveEvent struct {
+	Kind       string `json:"kind"`
+	Object     string `json:"object,omitempty"`
+	Hash       string `json:"hash,omitempty"`
+	Collective string `json:"collective,omitempty"`
+	Board      string `json:"board,omitempty"`
+	Epoch      uint64 `json:"epoch"`
+	Link       string `json:"link,omitempty"` // page to be reloaded
+}
+
+func liveEvent(kind string, u state.Updated) LiveEvent {
+	hashText, _ := u.Hash.MarshalText()
+	event := LiveEvent{
+		Kind:       kind,
+		Object:     objectNames[u.Object],
+		Hash:       string(hashText),
+		Collective: u.Collective,
+		Board:      u.Board,
+		Epoch:      u.Epoch,
+	}
+	switch kind {
+	case LiveVote, LiveExpired:
+		event.Link = "/votes"
+	case LivePin:
+		event.Link = fmt.Sprintf("/board/%v", url.QueryEscape(u.Board))
+	case LiveGreet:
+		event.Link = fmt.Sprintf("/event/%v", event.Hash)
+	case LiveConsensus:
+		event.Link = "/updates"
+	}
+	return event
+}
+
+// liveMember keeps track of the proposals open to the vote of a member and
+// of the collectives and boards it follows so that updates can be turned
+// into the events concerning it.
+type liveMember struct {
+	token crypto.Token
+	state *state.State
+	index interface {
+		GetVotes(crypto.Token) map[crypto.Hash]struct{}
+		CollectivesOnMember(crypto.Token) []string
+		BoardsOnMember(crypto.Token) []string
+	}
+	votes       map[crypto.Hash]struct{}
+	collectives map[string]struct{}
+	boards      map[string]struct{}
+}
+
+func setOf(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+// refresh reads the open votes and the collectives and boards of the member
+// from the index.
+func (m *liveMember) refresh() {
+	m.state.RLock()
+	defer m.state.RUnlock()
+	m.votes = m.index.GetVotes(m.token)
+	m.collectives = setOf(m.index.CollectivesOnMember(m.token))
+	m.boards = setOf(m.index.BoardsOnMember(m.token))
+}
+
+// topic matches the updates concerning the member or on the collectives,
+// boards and proposals it follows.
+func (m *liveMember) topic() state.Topic {
+	// refresh replaces the sets instead of modifying them, so they can be
+	// shared with the subscription
+	following := &state.Following{Collectives: m.collectives, Boards: m.boards, Hashes: m.votes}
+	return state.Topic{Member: m.token, Following: following}
+}
+
+// touchesVotes checks if the update may open or close proposals to the vote
+// of the member or change what it follows.
+func (m *liveMember) touchesVotes(u state.Updated) bool {
+	switch u.Action {
+	case state.ReactAction, state.CommentAction, state.MediaAction, state.MediaUpload, state.SigninAction,
+		state.AttorneyAction, state.MemberAction, state.GreetAction, state.RejectAction:
+		return false
+	case state.VoteAction:
+		return u.Author.Equal(m.token)
+	}
+	return true
+}
+
+// events returns the live events the update produces for the member and
+// whether the topic of the member must be refreshed.
+func (m *liveMember) events(u state.Updated) ([]LiveEvent, bool) {
+	events := make([]LiveEvent, 0)
+	switch u.Action {
+	case state.AcceptProposal:
+		_, voter := m.votes[u.Hash]
+		_, member := m.collectives[u.Collective]
+		if voter || (u.Collective != "" && member) {
+			events = append(events, liveEvent(LiveConsensus, u))
+		}
+	case state.ExpireProposal:
+		if _, ok := m.votes[u.Hash]; ok {
+			events = append(events, liveEvent(LiveExpired, u))
+		}
+	case state.PinAction:
+		if _, follows := m.boards[u.Board]; follows && u.Board != "" && !u.Author.Equal(m.token) {
+			events = append(events, liveEvent(LivePin, u))
+		}
+	case state.GreetAction:
+		if u.Concerns(m.token) && !u.Author.Equal(m.token) {
+			events = append(events, liveEvent(LiveGreet, u))
+		}
+	}
+	if !m.touchesVotes(u) {
+		return events, false
+	}
+	previous := m.votes
+	m.refresh()
+	for hash := range m.votes {
+		if _, ok := previous[hash]; !ok {
+			events = append(events, liveEvent(LiveVote, state.Updated{Hash: hash, Epoch: u.Epoch}))
+		}
+	}
+	return events, true
+}
+
+func writeLiveEvent(conn net.Conn, buffer *bufio.ReadWriter, event LiveEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+	fmt.Fprintf(buffer, "event: %v\ndata: %s\n\n", event.Kind, data)
+	return buffer.Flush()
+}
+
+// LiveHandler streams the events concerning the signed in member as
+// server-sent events. The connection is hijacked since the server write
+// timeout would otherwise close the stream.
+func (a *AttorneyGeneral) LiveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	author := a.Author(r)
+	if author.Equal(crypto.ZeroToken) {
+		http.Error(w, "not signed in", http.StatusUnauthorized)
+		return
+	}
+	if a.indexer == nil {
+		http.Error(w, "live updates not available", http.StatusNotFound)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	member := &liveMember{token: author, state: a.state, index: a.indexer}
+	member.refresh()
+	subscription := a.state.Subscribe(member.topic())
+	defer subscription.Close()
+
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		log.Printf("could not hijack live connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Time{})
+	conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+	fmt.Fprint(buffer, "HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nCache-Control: no-cache\r\nConnection: close\r\n\r\n")
+	fmt.Fprintf(buffer, "retry: %v\n\n", (5 * time.Second).Milliseconds())
+	if err := buffer.Flush(); err != nil {
+		return
+	}
+
+	// the client sends nothing else, a read returns once it goes away
+	gone := make(chan struct{})
+	go func() {
+		buffer.ReadByte()
+		close(gone)
+	}()
+
+	keepAlive := time.NewTicker(liveKeepAlive)
+	defer keepAlive.Stop()
+	missed := uint64(0)
+	for {
+		select {
+		case <-gone:
+			return
+		case <-keepAlive.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			fmt.Fprint(buffer, ": keep alive\n\n")
+			if err := buffer.Flush(); err != nil {
+				return
+			}
+		case update, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+			if count := subscription.Missed(); count > missed {
+				missed = count
+				if writeLiveEvent(conn, buffer, LiveEvent{Kind: LiveMissed, Epoch: update.Epoch}) != nil {
+					return
+				}
+			}
+			events, refreshed := member.events(update)
+			if refreshed {
+				subscription.SetTopic(member.topic())
+			}
+			for _, event := range events {
+				if writeLiveEvent(conn, buffer, event) != nil {
+					return
+				}
+			}
+		}
+	}
+}
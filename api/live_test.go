@@ -0,0 +1,58 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+type liveIndex struct {
+	votes       map[crypto.Hash]struct{}
+	collectives []string
+	reads       int
+}
+
+func (i *liveIndex) GetVotes(crypto.Token) map[crypto.Hash]struct{} {
+	i.reads += 1
+	votes := make(map[crypto.Hash]struct{}, len(i.votes))
+	for hash := range i.votes {
+		votes[hash] = struct{}{}
+	}
+	return votes
+}
+
+func (i *liveIndex) CollectivesOnMember(crypto.Token) []string { return i.collectives }
+
+func (i *liveIndex) BoardsOnMember(crypto.Token) []string { return nil }
+
+func TestLiveMemberEvents(t *testing.T) {
+	token, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	index := &liveIndex{votes: make(map[crypto.Hash]struct{}), collectives: []string{"synergy"}}
+	member := &liveMember{token: token, state: state.GenesisState(nil), index: index}
+	member.refresh()
+
+	events, refreshed := member.events(state.Updated{Action: state.ReactAction, Author: other})
+	if len(events) != 0 || refreshed || index.reads != 1 {
+		t.Errorf("reaction read the open votes: %v events, %v reads", len(events), index.reads)
+	}
+	proposal := crypto.Hasher([]byte("proposal"))
+	index.votes[proposal] = struct{}{}
+	events, refreshed = member.events(state.Updated{Action: state.CollectiveAction, Author: other, Collective: "synergy"})
+	if len(events) != 1 || events[0].Kind != LiveVote || !refreshed {
+		t.Fatalf("expected a vote event, got %+v", events)
+	}
+	if !member.topic().Following.Has(state.Updated{Hash: proposal}) {
+		t.Error("topic does not follow the open proposal")
+	}
+	events, _ = member.events(state.Updated{Action: state.VoteAction, Author: other, Hash: proposal})
+	if len(events) != 0 || index.reads != 2 {
+		t.Errorf("vote of another member read the open votes: %v reads", index.reads)
+	}
+	delete(index.votes, proposal)
+	events, _ = member.events(state.Updated{Action: state.ExpireProposal, Hash: proposal})
+	if len(events) != 1 || events[0].Kind != LiveExpired {
+		t.Errorf("expected an expired event, got %+v", events)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OpenAPI describes the json api. The schemas are generated from the view
+// types of restRoutes by their json tags.
+func OpenAPI() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]any)
+	for _, route := range restRoutes {
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": route.summary,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(route.sample), schemas)},
+				},
+			},
+			"404": errorResponse("not found"),
+		}
+		operation := map[string]any{
+			"summary":   route.summary,
+			"responses": responses,
+		}
+		if param := routeParameter(route.path); param != "" {
+			operation["parameters"] = []any{
+				map[string]any{"name": param, "in": "path", "required": true, "schema": map[string]any{"type": "string"}},
+			}
+			if param == "hash" {
+				responses["400"] = errorResponse("invalid hash")
+			}
+		}
+		if route.member {
+			responses["401"] = errorResponse("not signed in")
+		}
+		if route.signed {
+			parameters, _ := operation["parameters"].([]any)
+			operation["parameters"] = append(parameters, map[string]any{
+				"name": "signature", "in": "query", "required": false, "schema": map[string]any{"type": "string"},
+				"description": "hex of the signature of the author on the hash, instead of a session",
+			})
+		}
+		paths[RestPrefix+route.path] = map[string]any{"get": operation}
+	}
+	paths[RestPrefix+"/actions"] = map[string]any{"post": submitOperation(schemas)}
+	paths[RestPrefix+"/unsigned"] = map[string]any{"post": custodyOperation(schemas,
+		"envelopes of an action to be signed by the author with its own key",
+		UnsignedRequest{}, "200", UnsignedResponse{})}
+	paths[RestPrefix+"/signed"] = map[string]any{"post": custodyOperation(schemas,
+		"relay envelopes signed by their authors, the status of each action is on /actions/{hash}",
+		SignedRequest{}, "202", SubmitResponse{})}
+	schemas["Error"] = schemaOf(reflect.TypeOf(restError{}), schemas)
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "synergy",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+// submitOperation describes the submission of any of the jsonActions
+func submitOperation(schemas map[string]any) map[string]any {
+	kinds := make([]string, 0, len(jsonActions))
+	for kind := range jsonActions {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	bodies := make([]any, 0, len(kinds))
+	for _, kind := range kinds {
+		bodies = append(bodies, schemaOf(reflect.TypeOf(jsonActions[kind]()), schemas))
+	}
+	return map[string]any{
+		"summary": "submit an action on behalf of the member, the status of each action is on /actions/{hash}",
+		"requestBody": map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": map[string]any{"oneOf": bodies}},
+			},
+		},
+		"responses": map[string]any{
+			"202": map[string]any{
+				"description": "hashes of the actions sent to the gateway",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(SubmitResponse{}), schemas)},
+				},
+			},
+			"400": map[string]any{
+				"description": "validation errors",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(SubmitErrors{}), schemas)},
+				},
+			},
+			"401": errorResponse("not signed in"),
+		},
+	}
+}
+
+// custodyOperation describes the endpoints of self-custodied members
+func custodyOperation(schemas map[string]any, summary string, request any, status string, response any) map[string]any {
+	return map[string]any{
+		"summary": summary,
+		"requestBody": map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(request), schemas)},
+			},
+		},
+		"responses": map[string]any{
+			status: map[string]any{
+				"description": summary,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(response), schemas)},
+				},
+			},
+			"400": map[string]any{
+				"description": "validation errors",
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaOf(reflect.TypeOf(SubmitErrors{}), schemas)},
+				},
+			},
+		},
+	}
+}
+
+func errorResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			"application/json": map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Error"}},
+		},
+	}
+}
+
+func routeParameter(path string) string {
+	start := strings.Index(path, "{")
+	end := strings.Index(path, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return path[start+1 : end]
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaOf returns the schema of the type, structs are added to schemas and
+// referenced by name.
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t == rawMessageType {
+		return map[string]any{"type": "object"}
+	}
+	if t.Implements(textMarshalerType) {
+		// hashes and tokens
+		return map[string]any{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem(), schemas)
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		properties := make(map[string]any)
+		schemas[t.Name()] = map[string]any{"type": "object", "properties": properties}
+		for n := 0; n < t.NumField(); n++ {
+			field := t.Field(n)
+			if !field.IsExported() {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = schemaOf(field.Type, schemas)
+		}
+		return ref
+	}
+	return map[string]any{}
+}
@@ -1,95 +1,296 @@
 package api
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
 	"log"
 	"os"
 	"sync"
 
 	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+	"golang.org/x/crypto/argon2"
 )
 
+/*
+passwords.dat starts with passwordsHeader followed by the version of the
+format. Each record is
+
+	version (1) | token (32) | salt | time (4) | memory (4) | threads (1) | key | email
+
+and the last record of a token replaces the previous ones. Records are
+appended, so a crash while writing one leaves it partial at the end of the
+file: it is dropped, and the file truncated, on open. Version 0 records
+hold only the unsalted hash of the password as key. Files without header
+are of the legacy format (token | hash of the password) and are rewritten on
+open as version 0 records, each replaced by an argon2id record on the first
+successful check.
+*/
+
+const (
+	passwordsHeader      = "synergy-passwords"
+	passwordsFormat byte = 1
+)
+
+const (
+	legacyRecord   byte = 0 // unsalted hash of the password
+	argon2idRecord byte = 1
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// kdfParams are the argon2id parameters of new records. Old records keep the
+// parameters they were created with.
+var kdfParams = struct {
+	time    uint32
+	memory  uint32 // KiB
+	threads uint8
+}{time: 1, memory: 64 * 1024, threads: 4}
+
+var errCorruptedPasswords = errors.New("corrupted password file")
+
+type passwordRecord struct {
+	version byte
+	salt    []byte
+	time    uint32
+	memory  uint32
+	threads uint8
+	key     []byte
+	email   string
+}
+
+func newPasswordRecord(password, email string) *passwordRecord {
+	record := &passwordRecord{
+		version: argon2idRecord,
+		salt:    make([]byte, saltSize),
+		time:    kdfParams.time,
+		memory:  kdfParams.memory,
+		threads: kdfParams.threads,
+		email:   email,
+	}
+	if _, err := rand.Read(record.salt); err != nil {
+		log.Printf("could not generate salt: %v", err)
+		return nil
+	}
+	record.key = record.derive(password)
+	return record
+}
+
+func (p *passwordRecord) derive(password string) []byte {
+	return argon2.IDKey([]byte(password), p.salt, p.time, p.memory, p.threads, keySize)
+}
+
+func (p *passwordRecord) check(password string) bool {
+	switch p.version {
+	case legacyRecord:
+		hash := crypto.Hasher([]byte(password))
+		return subtle.ConstantTimeCompare(hash[:], p.key) == 1
+	case argon2idRecord:
+		return subtle.ConstantTimeCompare(p.derive(password), p.key) == 1
+	}
+	return false
+}
+
+func (p *passwordRecord) serialize(user crypto.Token) []byte {
+	data := []byte{p.version}
+	util.PutToken(user, &data)
+	if p.version == legacyRecord {
+		util.PutByteArray(p.key, &data)
+		util.PutString(p.email, &data)
+		return data
+	}
+	util.PutByteArray(p.salt, &data)
+	util.PutUint32(p.time, &data)
+	util.PutUint32(p.memory, &data)
+	util.PutByte(p.threads, &data)
+	util.PutByteArray(p.key, &data)
+	util.PutString(p.email, &data)
+	return data
+}
+
+// parsePasswordRecord returns the record at position and the position of the
+// next one.
+func parsePasswordRecord(data []byte, position int) (crypto.Token, *passwordRecord, int) {
+	var user crypto.Token
+	if position >= len(data) || data[position] > argon2idRecord {
+		return user, nil, len(data) + 1
+	}
+	record := passwordRecord{version: data[position]}
+	position += 1
+	user, position = util.ParseToken(data, position)
+	if record.version == legacyRecord {
+		record.key, position = util.ParseByteArray(data, position)
+		record.email, position = util.ParseString(data, position)
+		if position > len(data) || len(record.key) != crypto.Size {
+			return user, nil, len(data) + 1
+		}
+		return user, &record, position
+	}
+	record.salt, position = util.ParseByteArray(data, position)
+	record.time, position = util.ParseUint32(data, position)
+	record.memory, position = util.ParseUint32(data, position)
+	record.threads, position = util.ParseByte(data, position)
+	record.key, position = util.ParseByteArray(data, position)
+	record.email, position = util.ParseString(data, position)
+	if position > len(data) || len(record.key) != keySize {
+		return user, nil, len(data) + 1
+	}
+	return user, &record, position
+}
+
 type filePasswordManager struct {
 	mu        sync.Mutex
-	file      os.File
-	hashes    []crypto.Hash
-	passwords map[crypto.Token]int
+	file      *os.File
+	passwords map[crypto.Token]*passwordRecord
 }
 
-func (f *filePasswordManager) Check(user crypto.Token, password crypto.Hash) bool {
-	if n, ok := f.passwords[user]; ok {
-		if n >= len(f.hashes) {
-			log.Printf("unexpected error in file password manager")
-			return false
-		}
-		return password.Equal(f.hashes[n])
+func (f *filePasswordManager) Check(user crypto.Token, password string) bool {
+	f.mu.Lock()
+	record, ok := f.passwords[user]
+	f.mu.Unlock()
+	if !ok || !record.check(password) {
+		return false
 	}
-	return false
+	if record.version == legacyRecord {
+		f.Set(user, password, record.email)
+	}
+	return true
 }
 
 func (f *filePasswordManager) Has(user crypto.Token) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	_, ok := f.passwords[user]
 	return ok
 }
 
-func (f *filePasswordManager) Set(user crypto.Token, password crypto.Hash, email string) bool {
+func (f *filePasswordManager) Email(user crypto.Token) (string, bool) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	data := append(user[:], password[:]...)
-	n, ok := f.passwords[user]
-	if ok {
-		if n > len(f.hashes) {
-			log.Printf("unexpected error in file password manager")
-			return false
-		}
-		if n, err := f.file.WriteAt(data, int64(n)*2*crypto.Size); n != 64 || err != nil {
-			log.Printf("unexpected error in file password manager: %v", err)
-			return false
+	record, ok := f.passwords[user]
+	if !ok || record.email == "" {
+		return "", false
+	}
+	return record.email, true
+}
+
+func (f *filePasswordManager) Set(user crypto.Token, password string, email string) bool {
+	record := newPasswordRecord(password, email)
+	if record == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if email == "" {
+		if old, ok := f.passwords[user]; ok {
+			record.email = old.email
 		}
-		f.hashes[n] = password
 	}
-	f.file.Seek(0, 2)
-	if n, err := f.file.Write(data); n != 64 || err != nil {
+	data := record.serialize(user)
+	if _, err := f.file.Seek(0, io.SeekEnd); err != nil {
+		log.Printf("unexpected error in file password manager: %v", err)
+		return false
+	}
+	if n, err := f.file.Write(data); n != len(data) || err != nil {
 		log.Printf("unexpected error in file password manager: %v", err)
 		return false
 	}
-	f.hashes = append(f.hashes, password)
-	f.passwords[user] = len(f.hashes) - 1
+	f.passwords[user] = record
 	return true
 }
 
+func (f *filePasswordManager) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.file.Close()
+}
+
+// readPasswords parses the content of a password file. It returns the
+// records up to the first one that cannot be parsed and the position where it
+// starts, the length of data if every record is complete. Files of the legacy
+// format are reported so that they can be rewritten.
+func readPasswords(data []byte) (map[crypto.Token]*passwordRecord, bool, int, error) {
+	passwords := make(map[crypto.Token]*passwordRecord)
+	head := len(passwordsHeader) + 1
+	if len(data) >= head && string(data[:len(passwordsHeader)]) == passwordsHeader {
+		if data[len(passwordsHeader)] != passwordsFormat {
+			return nil, false, 0, errCorruptedPasswords
+		}
+		position := head
+		for position < len(data) {
+			user, record, next := parsePasswordRecord(data, position)
+			if record == nil {
+				break
+			}
+			passwords[user] = record
+			position = next
+		}
+		return passwords, false, position, nil
+	}
+	if len(data)%(2*crypto.Size) != 0 {
+		return nil, false, 0, errCorruptedPasswords
+	}
+	for position := 0; position < len(data); position += 2 * crypto.Size {
+		var user crypto.Token
+		copy(user[:], data[position:position+crypto.Size])
+		passwords[user] = &passwordRecord{
+			version: legacyRecord,
+			key:     append([]byte{}, data[position+crypto.Size:position+2*crypto.Size]...),
+		}
+	}
+	return passwords, true, len(data), nil
+}
+
+// writePasswords replaces the file by one in the current format with a
+// single record for each user.
+func writePasswords(filename string, passwords map[crypto.Token]*passwordRecord) error {
+	data := append([]byte(passwordsHeader), passwordsFormat)
+	for user, record := range passwords {
+		data = append(data, record.serialize(user)...)
+	}
+	temporary := filename + ".tmp"
+	if err := os.WriteFile(temporary, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temporary, filename)
+}
+
 func NewFilePasswordManager(filename string) PasswordManager {
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0666)
-	if err != nil {
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
 		log.Fatalf("could not open password manager file: %v", err)
 	}
-	stat, err := file.Stat()
+	passwords, legacy, end, err := readPasswords(data)
 	if err != nil {
-		log.Fatalf("could not stat password manager file: %v", err)
-	}
-	size := stat.Size()
-	if size%64 != 0 {
-		log.Fatal("corrupted password manager file: incompatible size")
+		log.Fatalf("could not read password manager file: %v", err)
 	}
-	manager := filePasswordManager{
-		file:      *file,
-		hashes:    make([]crypto.Hash, size/64),
-		passwords: make(map[crypto.Token]int),
+	if end < len(data) {
+		log.Printf("password manager file: partial record at offset %v, dropping %v bytes", end, len(data)-end)
+		if err := os.Truncate(filename, int64(end)); err != nil {
+			log.Fatalf("could not truncate password manager file: %v", err)
+		}
 	}
-	entry := make([]byte, 64)
-	for n := 0; n < int(size)/64; n++ {
-		if n, err := file.Read(entry); n != 64 {
-			log.Fatalf("corrupted password manager file: %v", err)
+	if legacy || len(data) == 0 {
+		if err := writePasswords(filename, passwords); err != nil {
+			log.Fatalf("could not rewrite password manager file: %v", err)
 		}
-		var token crypto.Token
-		copy(token[:], entry[:32])
-		copy(manager.hashes[n][:], entry[32:])
-		manager.passwords[token] = n
 	}
-	return &manager
+	file, err := os.OpenFile(filename, os.O_RDWR, 0600)
+	if err != nil {
+		log.Fatalf("could not open password manager file: %v", err)
+	}
+	return &filePasswordManager{file: file, passwords: passwords}
 }
 
 type PasswordManager interface {
-	Check(user crypto.Token, password crypto.Hash) bool
-	Set(user crypto.Token, password crypto.Hash, email string) bool
+	Check(user crypto.Token, password string) bool
+	Set(user crypto.Token, password string, email string) bool
 	Has(user crypto.Token) bool
+	Email(user crypto.Token) (string, bool)
+	Close()
 }
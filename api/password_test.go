@@ -0,0 +1,137 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+func cheapKDF(t *testing.T) {
+	params := kdfParams
+	kdfParams.time, kdfParams.memory, kdfParams.threads = 1, 64, 1
+	t.Cleanup(func() { kdfParams = params })
+}
+
+func TestFilePasswordManager(t *testing.T) {
+	cheapKDF(t)
+	path := filepath.Join(t.TempDir(), "passwords.dat")
+	manager := NewFilePasswordManager(path)
+	user, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	if manager.Has(user) || manager.Check(user, "") {
+		t.Fatal("Empty password manager knows user")
+	}
+	if !manager.Set(user, "first password", "user@example.com") || !manager.Set(other, "first password", "other@example.com") {
+		t.Fatal("Could not set passwords")
+	}
+	if !manager.Check(user, "first password") || manager.Check(user, "second password") {
+		t.Error("Check not working")
+	}
+	// same password, different salts
+	first := manager.(*filePasswordManager).passwords[user]
+	second := manager.(*filePasswordManager).passwords[other]
+	if string(first.key) == string(second.key) {
+		t.Error("Passwords are not salted")
+	}
+	if !manager.Set(user, "second password", "") {
+		t.Fatal("Could not change password")
+	}
+	manager.(*filePasswordManager).Close()
+
+	reopened := NewFilePasswordManager(path)
+	if !reopened.Check(user, "second password") || reopened.Check(user, "first password") {
+		t.Error("Changed password not persisted")
+	}
+	if email, ok := reopened.Email(user); !ok || email != "user@example.com" {
+		t.Errorf("Email not kept on password change: %v", email)
+	}
+	if !reopened.Check(other, "first password") {
+		t.Error("Password of other user lost")
+	}
+}
+
+func TestLegacyPasswords(t *testing.T) {
+	cheapKDF(t)
+	path := filepath.Join(t.TempDir(), "passwords.dat")
+	user, _ := crypto.RandomAsymetricKey()
+	hash := crypto.Hasher([]byte("1234"))
+	if err := os.WriteFile(path, append(user[:], hash[:]...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manager := NewFilePasswordManager(path)
+	data, _ := os.ReadFile(path)
+	if string(data[:len(passwordsHeader)]) != passwordsHeader {
+		t.Fatal("Legacy file not rewritten")
+	}
+	if manager.Check(user, "4321") {
+		t.Error("Legacy check accepts wrong password")
+	}
+	if !manager.Check(user, "1234") {
+		t.Fatal("Legacy password not accepted")
+	}
+	if record := manager.(*filePasswordManager).passwords[user]; record.version != argon2idRecord {
+		t.Error("Legacy password not upgraded on check")
+	}
+	manager.(*filePasswordManager).Close()
+	reopened := NewFilePasswordManager(path)
+	if record := reopened.(*filePasswordManager).passwords[user]; record.version != argon2idRecord || !reopened.Check(user, "1234") {
+		t.Error("Upgraded password not persisted")
+	}
+}
+
+func TestCorruptedPasswords(t *testing.T) {
+	user, _ := crypto.RandomAsymetricKey()
+	record := (&passwordRecord{version: argon2idRecord, salt: make([]byte, saltSize), key: make([]byte, keySize)}).serialize(user)
+	data := append([]byte(passwordsHeader), passwordsFormat)
+	if passwords, _, end, err := readPasswords(append(data, record[:len(record)-1]...)); err != nil || len(passwords) != 0 || end != len(data) {
+		t.Error("Truncated record accepted")
+	}
+	if _, _, _, err := readPasswords(make([]byte, 10)); err == nil {
+		t.Error("Legacy file of wrong size accepted")
+	}
+	if _, _, _, err := readPasswords(append([]byte(passwordsHeader), passwordsFormat+1)); err == nil {
+		t.Error("Unknown format accepted")
+	}
+	valid := append(append([]byte{}, data...), record...)
+	if passwords, _, end, err := readPasswords(valid); err != nil || len(passwords) != 1 || end != len(valid) {
+		t.Errorf("Valid file rejected: %v", err)
+	}
+}
+
+func TestPartialPasswordRecord(t *testing.T) {
+	cheapKDF(t)
+	path := filepath.Join(t.TempDir(), "passwords.dat")
+	manager := NewFilePasswordManager(path)
+	user, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	if !manager.Set(user, "password", "user@example.com") {
+		t.Fatal("Could not set password")
+	}
+	manager.Close()
+	complete, _ := os.ReadFile(path)
+	// crash while appending the record of other
+	record := newPasswordRecord("password", "other@example.com").serialize(other)
+	for cut := 1; cut < len(record); cut++ {
+		if err := os.WriteFile(path, append(append([]byte{}, complete...), record[:cut]...), 0600); err != nil {
+			t.Fatal(err)
+		}
+		reopened := NewFilePasswordManager(path)
+		if !reopened.Check(user, "password") || reopened.Has(other) {
+			t.Fatalf("cut at %v: records before the partial one not kept", cut)
+		}
+		if stat, _ := os.Stat(path); stat.Size() != int64(len(complete)) {
+			t.Fatalf("cut at %v: partial record not truncated", cut)
+		}
+		if !reopened.Set(other, "password", "other@example.com") {
+			t.Fatalf("cut at %v: could not set password after recovery", cut)
+		}
+		reopened.Close()
+		again := NewFilePasswordManager(path)
+		if !again.Check(other, "password") || !again.Check(user, "password") {
+			t.Fatalf("cut at %v: password set after recovery not persisted", cut)
+		}
+		again.Close()
+	}
+}
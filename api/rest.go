@@ -0,0 +1,321 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+// RestPrefix is the root of the read-only json api. The views are the same
+// rendered by the html templates without the page header.
+const RestPrefix = "/api/v1"
+
+// restRoute is a read-only resource of the json api. A {hash}, {name} or
+// {handle} segment of the path is given to view as item.
+type restRoute struct {
+	path    string
+	summary string
+	member  bool // only for the signed in member
+	signed  bool // or for the author of the hash with its signature on it
+	sample  any  // value of the type returned by view, for the openapi description
+	view    func(a *AttorneyGeneral, author crypto.Token, item string) any
+}
+
+var restRoutes = []restRoute{
+	{
+		path: "/drafts", summary: "drafts", sample: DraftsListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return DraftsFromState(a.state)
+		},
+	},
+	{
+		path: "/drafts/{hash}", summary: "draft with its authors, votes, pins and stamps", sample: DraftDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return DraftDetailFromState(a.state, a.indexer, crypto.DecodeHash(item), author, a.genesisTime)
+		},
+	},
+	{
+		path: "/drafts/{hash}/edits", summary: "edits on a draft", sample: EditsListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EditsFromState(a.state, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/edits/{hash}", summary: "edit", sample: EditDetailedView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EditDetailFromState(a.state, a.indexer, crypto.DecodeHash(item), author)
+		},
+	},
+	{
+		path: "/boards", summary: "boards", sample: BoardsListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return BoardsFromState(a.state)
+		},
+	},
+	{
+		path: "/boards/{name}", summary: "board with its pinned drafts", sample: BoardDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return BoardDetailFromState(a.state, item, author)
+		},
+	},
+	{
+		path: "/boards/{name}/update", summary: "current values of a board to be updated", sample: BoardUpdateView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return BoardToUpdateFromState(a.state, item)
+		},
+	},
+	{
+		path: "/collectives", summary: "collectives", sample: CollectivesListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return ColletivesFromState(a.state)
+		},
+	},
+	{
+		path: "/collectives/{name}", summary: "collective with its members, boards, stamps and events", sample: CollectiveDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return CollectiveDetailFromState(a.state, a.indexer, item, author)
+		},
+	},
+	{
+		path: "/collectives/{name}/update", summary: "current values of a collective to be updated", sample: CollectiveUpdateView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return CollectiveToUpdateFromState(a.state, item)
+		},
+	},
+	{
+		path: "/events", summary: "events", sample: EventsListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EventsFromState(a.state)
+		},
+	},
+	{
+		path: "/events/{hash}", summary: "event with its check-ins", sample: EventDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EventDetailFromState(a.state, a.indexer, crypto.DecodeHash(item), author, a.ephemeralprv)
+		},
+	},
+	{
+		path: "/events/{hash}/update", summary: "current values of an event to be updated", sample: EventDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EventUpdateDetailFromState(a.state, a.indexer, crypto.DecodeHash(item), author)
+		},
+	},
+	{
+		path: "/members", summary: "members", sample: MembersListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return MembersFromState(a.state)
+		},
+	},
+	{
+		path: "/members/{handle}", summary: "member", sample: MemberView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return MemberViewFromState(a.state, a.indexer, item)
+		},
+	},
+	{
+		path: "/news", summary: "recent actions", sample: NewActionsView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return NewActionsFromState(a.state, a.indexer, a.genesisTime)
+		},
+	},
+	{
+		path: "/votes/{hash}", summary: "votes cast on a proposal", sample: DetailedPool{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return DetailedVoteFromState(a.state, a.indexer, crypto.DecodeHash(item), a.genesisTime)
+		},
+	},
+	{
+		path: "/proposals/memberships/{hash}", summary: "request to join a collective", sample: RequestMembershipView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return RequestMembershipFromState(a.state, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/proposals/collective-updates/{hash}", summary: "proposed update of a collective", sample: CollectiveUpdateView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return CollectiveUpdateFromState(a.state, crypto.DecodeHash(item), author)
+		},
+	},
+	{
+		path: "/proposals/boards/{hash}", summary: "proposed board", sample: BoardDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return PendingBoardFromState(a.state, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/proposals/board-updates/{hash}", summary: "proposed update of a board", sample: BoardUpdateView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return BoardUpdateFromState(a.state, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/proposals/events/{hash}", summary: "proposed event", sample: EventDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return PendingEventFromState(a.state, a.indexer, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/proposals/event-updates/{hash}", summary: "proposed update of an event", sample: VoteUpdateEventView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return EventUpdateFromState(a.state, crypto.DecodeHash(item), author)
+		},
+	},
+	{
+		path: "/proposals/event-cancels/{hash}", summary: "proposed cancellation of an event", sample: EventDetailView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return CancelEventFromState(a.state, a.indexer, crypto.DecodeHash(item))
+		},
+	},
+	{
+		path: "/actions/{hash}", summary: "status of an action submitted by the member", member: true, signed: true, sample: ActionStatus{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return a.tracker.Status(crypto.DecodeHash(item), author)
+		},
+	},
+	{
+		path: "/me/votes", summary: "proposals waiting for the member's vote", member: true, sample: VotesListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return VotesFromState(a.state, a.indexer, author)
+		},
+	},
+	{
+		path: "/me/updates", summary: "updates on the member's objects", member: true, sample: UpdatesView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return UpdatesViewFromState(a.state, a.indexer, author, a.genesisTime)
+		},
+	},
+	{
+		path: "/me/pending", summary: "member's actions waiting for consensus", member: true, sample: PendingActionsView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return PendingActionsFromState(a.state, a.indexer, author, a.genesisTime)
+		},
+	},
+	{
+		path: "/me/media", summary: "member's drafts and edits", member: true, sample: MyMediaView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return MyMediaFromState(a.state, a.indexer, author)
+		},
+	},
+	{
+		path: "/me/events", summary: "member's events", member: true, sample: MyEventsView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return MyEventsFromState(a.state, a.indexer, author)
+		},
+	},
+	{
+		path: "/me/connections", summary: "member's collectives, boards, events and edits", member: true, sample: ConnectionsListView{},
+		view: func(a *AttorneyGeneral, author crypto.Token, item string) any {
+			return ConnectionsFromState(a.state, a.indexer, author, a.genesisTime)
+		},
+	},
+}
+
+// match returns the path segment on the position of the route parameter
+func (r restRoute) match(path string) (string, bool) {
+	pattern := strings.Split(strings.Trim(r.path, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pattern) != len(segments) {
+		return "", false
+	}
+	item := ""
+	for n, segment := range pattern {
+		if strings.HasPrefix(segment, "{") {
+			unescaped, err := url.PathUnescape(segments[n])
+			if err != nil || unescaped == "" {
+				return "", false
+			}
+			item = unescaped
+		} else if segment != segments[n] {
+			return "", false
+		}
+	}
+	return item, true
+}
+
+// hashed checks if the route parameter is a hash
+func (r restRoute) hashed() bool {
+	return strings.Contains(r.path, "{hash}")
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("could not encode json response: %v", err)
+	}
+}
+
+type restError struct {
+	Error string `json:"error"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, restError{Error: msg})
+}
+
+// isNil checks for views returned as nil pointers
+func isNil(view any) bool {
+	if view == nil {
+		return true
+	}
+	value := reflect.ValueOf(view)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// RestHandler serves the json api under RestPrefix.
+func (a *AttorneyGeneral) RestHandler(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.EscapedPath(), RestPrefix)
+	if r.Method == http.MethodPost {
+		switch path {
+		case "/actions":
+			a.SubmitHandler(w, r)
+			return
+		case "/unsigned":
+			a.UnsignedHandler(w, r)
+			return
+		case "/signed":
+			a.SignedHandler(w, r)
+			return
+		}
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if path == "/openapi.json" {
+		writeJSON(w, http.StatusOK, OpenAPI())
+		return
+	}
+	for _, route := range restRoutes {
+		item, ok := route.match(path)
+		if !ok {
+			continue
+		}
+		if route.hashed() && crypto.DecodeHash(item).Equal(crypto.ZeroValueHash) {
+			writeJSONError(w, http.StatusBadRequest, "invalid hash")
+			return
+		}
+		author := a.Author(r)
+		if route.signed && author.Equal(crypto.ZeroToken) {
+			author = a.signedBy(r, crypto.DecodeHash(item))
+		}
+		if route.member && author.Equal(crypto.ZeroToken) {
+			writeJSONError(w, http.StatusUnauthorized, "not signed in")
+			return
+		}
+		view := route.view(a, author, item)
+		if isNil(view) {
+			writeJSONError(w, http.StatusNotFound, "not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, view)
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, "unknown resource")
+}
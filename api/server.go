@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"text/template"
 	"time"
 
@@ -16,15 +17,16 @@ import (
 )
 
 type ServerConfig struct {
-	Vault         *vault.SecureVault
-	Attorney      crypto.Token
-	Ephemeral     crypto.Token
-	Passwords     PasswordManager
-	CookieStore   *CookieStore
-	Gateway       social.Gatewayer
-	Indexer       *index.Index
-	EmailPassword string
-	Port          int
+	Vault       *vault.SecureVault
+	Attorney    crypto.Token
+	Ephemeral   crypto.Token
+	Passwords   PasswordManager
+	CookieStore *CookieStore
+	Gateway     social.Gatewayer
+	Indexer     *index.Index
+	Mailer      Mailer
+	URL         string // public address, defaults to localhost on Port
+	Port        int
 }
 
 type AuthorAction struct {
@@ -32,37 +34,54 @@ type AuthorAction struct {
 	action actions.Action
 }
 
-func NewGeneralAttorneyServer(config ServerConfig) chan error {
+// NewGeneralAttorneyServer starts serving the web interface. It returns the
+// http server, nil if it could not be started, and the channel on which the
+// reason the server stopped is sent.
+func NewGeneralAttorneyServer(config ServerConfig) (*http.Server, chan error) {
 	finalize := make(chan error, 2)
 
 	attorneySecret, ok := config.Vault.Secrets[config.Attorney]
 	if !ok {
 		finalize <- fmt.Errorf("attorney secret key not found in vault")
-		return finalize
+		return nil, finalize
 	}
 	ephemeralSecret, ok := config.Vault.Secrets[config.Ephemeral]
 	if !ok {
 		finalize <- fmt.Errorf("ephemeral secret key not found in vault")
-		return finalize
+		return nil, finalize
 	}
 
+	s := config.Gateway.State()
+	s.RLock()
+	epoch, genesisTime := s.Epoch, s.GenesisTime
+	s.RUnlock()
+
 	attorney := AttorneyGeneral{
-		epoch:         config.Gateway.State().Epoch,
-		pk:            attorneySecret,
-		credentials:   config.Passwords,
-		wallet:        attorneySecret,
-		pending:       make(map[crypto.Hash]actions.Action),
-		gateway:       config.Gateway,
-		state:         config.Gateway.State(),
-		indexer:       config.Indexer,
-		session:       config.CookieStore,
-		emailPassword: config.EmailPassword,
+		pk:          attorneySecret,
+		credentials: config.Passwords,
+		wallet:      attorneySecret,
+		tracker:     NewActionTracker(),
+		gateway:     config.Gateway,
+		state:       config.Gateway.State(),
+		indexer:     config.Indexer,
+		session:     config.CookieStore,
+		mailer:      config.Mailer,
+		mailTokens:  NewMailTokens(),
+		url:         strings.TrimSuffix(config.URL, "/"),
 		//sessionend:   make(map[uint64][]string),
-		genesisTime:  config.Gateway.State().GenesisTime,
+		genesisTime:  genesisTime,
 		ephemeralpub: config.Ephemeral,
 		ephemeralprv: ephemeralSecret,
 	}
 
+	attorney.epoch.Store(epoch)
+	if attorney.mailer == nil {
+		attorney.mailer = LogMailer{}
+	}
+	if attorney.url == "" {
+		attorney.url = fmt.Sprintf("http://localhost:%v", config.Port)
+	}
+
 	attorney.templates = template.New("root")
 	files := make([]string, len(templateFiles))
 	for n, file := range templateFiles {
@@ -76,23 +95,30 @@ func NewGeneralAttorneyServer(config ServerConfig) chan error {
 
 	blockEvent := config.Gateway.Register()
 	send := make(chan *AuthorAction)
+	attorney.tracker.Listen(attorney.state)
 
 	go func() {
 		for {
 			select {
-			case attorney.epoch = <-blockEvent:
+			case epoch := <-blockEvent:
+				attorney.epoch.Store(epoch)
+				attorney.tracker.Expire(epoch)
+				attorney.session.Clean(epoch)
 			case action := <-send:
 				config.Gateway.Action(attorney.DressAction(action.action, action.author))
 			}
 		}
 	}()
 
-	go NewServer(&attorney, config.Port, finalize)
+	srv := NewServer(&attorney, config.Port)
+	go func() {
+		finalize <- srv.ListenAndServe()
+	}()
 
-	return finalize
+	return srv, finalize
 }
 
-func NewServer(attorney *AttorneyGeneral, port int, finalize chan error) {
+func NewServer(attorney *AttorneyGeneral, port int) *http.Server {
 
 	mux := http.NewServeMux()
 	fs := http.FileServer(http.Dir("./api/static"))
@@ -140,12 +166,17 @@ func NewServer(attorney *AttorneyGeneral, port int, finalize chan error) {
 	mux.HandleFunc("/signout", attorney.SignoutHandler)
 	mux.HandleFunc("/credentials", attorney.CredentialsHandler)
 	mux.HandleFunc("/newuser", attorney.NewUserHandler)
+	mux.HandleFunc("/verify", attorney.VerifyHandler)
+	mux.HandleFunc("/reset", attorney.ResetHandler)
+	mux.HandleFunc("/sessions", attorney.SessionsHandler)
+	mux.HandleFunc("/live", attorney.LiveHandler)
+	mux.HandleFunc(RestPrefix+"/", attorney.RestHandler)
 	// mux.HandleFunc("/member/votes", attorney.VotesHandler)
 
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%v", port),
-		Handler:      mux,
+		Handler:      attorney.Harden(mux),
 		WriteTimeout: 2 * time.Second,
 	}
-	finalize <- srv.ListenAndServe()
+	return srv
 }
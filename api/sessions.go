@@ -0,0 +1,70 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+type SessionView struct {
+	ID      string
+	Issued  string
+	Expires string
+	Current bool
+}
+
+type SessionsView struct {
+	Head     HeaderInfo
+	Sessions []SessionView
+}
+
+func (a *AttorneyGeneral) epochTime(epoch uint64) string {
+	return a.genesisTime.Add(time.Second * time.Duration(epoch)).Format("02 Jan 06 15:04")
+}
+
+// SessionsHandler lists the sessions of the signed in member. A post with
+// the id of a session revokes it, or every other session with id others.
+func (a *AttorneyGeneral) SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	author := a.Author(r)
+	if author.Equal(crypto.ZeroToken) {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	cookie, _ := r.Cookie(cookieName)
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "invalid form", http.StatusBadRequest)
+			return
+		}
+		if id := r.FormValue("session"); id == "others" {
+			a.session.RevokeOthers(author, cookie.Value)
+		} else {
+			a.session.Revoke(author, id)
+		}
+		http.Redirect(w, r, "/sessions", http.StatusSeeOther)
+		return
+	}
+	view := SessionsView{
+		Head: HeaderInfo{
+			Active:     "Sessions",
+			Path:       "venture / ",
+			EndPath:    "sessions",
+			Section:    "venture",
+			UserHandle: a.Handle(r),
+			CSRF:       a.CSRF(r),
+		},
+	}
+	for _, session := range a.session.Sessions(author) {
+		view.Sessions = append(view.Sessions, SessionView{
+			ID:      session.ID(),
+			Issued:  a.epochTime(session.Issued),
+			Expires: a.epochTime(session.Expires),
+			Current: session.Cookie == cookie.Value,
+		})
+	}
+	if err := a.templates.ExecuteTemplate(w, "sessions.html", view); err != nil {
+		log.Println(err)
+	}
+}
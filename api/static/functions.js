@@ -12,6 +12,55 @@ window.onload = function () {
       el.addEventListener("focusout", hideinfo(id+"info"));
     }
   }
+
+  liveupdates();
+}
+
+// live updates
+
+const livekinds = ["vote", "consensus", "expired", "pin", "greet", "missed"];
+
+function liveupdates() {
+  // only signed in members receive live updates
+  if (!document.querySelector('#user form[action="/signout"]') || !window.EventSource) {
+    return;
+  }
+  let source = new EventSource("/live");
+  for (let kind of livekinds) {
+    source.addEventListener(kind, (msg) => {
+      let event = JSON.parse(msg.data);
+      markupdated(event.link);
+      // pages can react to events with a listener for "synergy:<kind>"
+      document.dispatchEvent(new CustomEvent("synergy:" + kind, {detail: event}));
+      if (kind === "missed" || event.link === window.location.pathname) {
+        showreload();
+      }
+    });
+  }
+}
+
+function markupdated(link) {
+  if (!link) {
+    return;
+  }
+  let el = document.querySelector('#nav a[href="' + link + '"]');
+  if (el) {
+    el.classList.add("liveupdated");
+  }
+}
+
+function showreload() {
+  if (document.getElementById("livereload")) {
+    return;
+  }
+  let center = document.getElementById("center");
+  if (!center) {
+    return;
+  }
+  let el = document.createElement("div");
+  el.id = "livereload";
+  el.innerHTML = 'this page has been updated, <a href="' + window.location.pathname + '">reload</a>';
+  center.prepend(el);
 }
 
 function selectFile() {
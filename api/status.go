@@ -0,0 +1,346 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+// status of an action submitted through the json api
+const (
+	StatusSubmitted = "submitted" // sent to the gateway
+	StatusIncluded  = "included"  // incorporated on the state at Epoch
+	StatusConsensus = "consensus" // the proposal of the action was approved
+	StatusRejected  = "rejected"  // not included, or the proposal was rejected or expired
+)
+
+// statusRetention is the number of epochs a final status is kept
+const statusRetention = 60 * 60
+
+type ActionStatus struct {
+	Hash     string `json:"hash"`
+	Status   string `json:"status"`
+	Epoch    uint64 `json:"epoch,omitempty"`    // epoch the action was included
+	Proposal string `json:"proposal,omitempty"` // hash of the proposal waiting for consensus
+	Reason   string `json:"reason,omitempty"`
+	author   crypto.Token
+	proposal crypto.Hash
+	dressed  uint64 // epoch the action was dressed with
+	closed   uint64 // epoch the status became final
+}
+
+func (s *ActionStatus) close(status string, epoch uint64, reason string) {
+	s.Status, s.Reason, s.closed = status, reason, epoch
+}
+
+// ActionTracker follows the actions submitted by the attorney from the
+// gateway to the consensus on their proposals.
+type ActionTracker struct {
+	mu        sync.Mutex
+	statuses  map[crypto.Hash]*ActionStatus
+	proposals map[crypto.Hash]crypto.Hash // proposal hash to action hash
+}
+
+func NewActionTracker() *ActionTracker {
+	return &ActionTracker{
+		statuses:  make(map[crypto.Hash]*ActionStatus),
+		proposals: make(map[crypto.Hash]crypto.Hash),
+	}
+}
+
+// proposalOf is the hash under which the proposal opened by the undressed
+// action is voted. Drafts and edits are voted by their content hash.
+func proposalOf(action []byte, hash crypto.Hash) crypto.Hash {
+	switch actions.ActionKind(action) {
+	case actions.ADraft:
+		if draft := actions.ParseDraft(action); draft != nil {
+			return draft.ContentHash
+		}
+	case actions.AEdit:
+		if edit := actions.ParseEdit(action); edit != nil {
+			return edit.ContentHash
+		}
+	}
+	return hash
+}
+
+// Submitted registers the envelope of a dressed action sent to the gateway
+func (t *ActionTracker) Submitted(envelope *actions.Envelope) crypto.Hash {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hash := crypto.Hasher(envelope.Action)
+	hashText, _ := hash.MarshalText()
+	t.statuses[hash] = &ActionStatus{
+		Hash:     string(hashText),
+		Status:   StatusSubmitted,
+		author:   envelope.Author,
+		proposal: proposalOf(envelope.Action, hash),
+		dressed:  envelope.Epoch,
+	}
+	return hash
+}
+
+// Confirmed marks a submitted action as included on the state at epoch.
+// If its proposal is still open the status waits for consensus.
+func (t *ActionTracker) Confirmed(hash crypto.Hash, epoch uint64, open bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.statuses[hash]
+	if !ok || status.Status != StatusSubmitted {
+		return
+	}
+	status.Status, status.Epoch = StatusIncluded, epoch
+	// the proposal may have been closed before the update was tracked, so
+	// it is followed even if it is not open anymore
+	t.proposals[status.proposal] = hash
+	if open {
+		proposalText, _ := status.proposal.MarshalText()
+		status.Proposal = string(proposalText)
+	} else {
+		status.closed = epoch
+	}
+}
+
+// Consensus closes the status of the action that opened the proposal
+func (t *ActionTracker) Consensus(proposal crypto.Hash, epoch uint64, status string, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hash, ok := t.proposals[proposal]
+	if !ok {
+		// proposals decided on the action itself are closed before the
+		// action is confirmed
+		for submitted, tracked := range t.statuses {
+			if tracked.Status == StatusSubmitted && tracked.proposal.Equal(proposal) {
+				hash, ok = submitted, true
+				tracked.Epoch = epoch
+				break
+			}
+		}
+		if !ok {
+			return
+		}
+	}
+	delete(t.proposals, proposal)
+	if tracked, ok := t.statuses[hash]; ok {
+		tracked.close(status, epoch, reason)
+	}
+}
+
+// Rejected closes a submitted action that the state refused to incorporate
+func (t *ActionTracker) Rejected(hash crypto.Hash, epoch uint64, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.statuses[hash]
+	if !ok || status.Status != StatusSubmitted {
+		return
+	}
+	status.close(StatusRejected, epoch, reason)
+}
+
+// Expire rejects submitted actions that can no longer be included and
+// forgets final statuses after statusRetention epochs.
+func (t *ActionTracker) Expire(epoch uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for hash, status := range t.statuses {
+		if status.Status == StatusSubmitted && status.dressed+state.ActionWindow < epoch {
+			status.close(StatusRejected, epoch, "not included")
+		}
+		if status.closed > 0 && status.closed+statusRetention < epoch {
+			delete(t.statuses, hash)
+			if t.proposals[status.proposal].Equal(hash) {
+				delete(t.proposals, status.proposal)
+			}
+		}
+	}
+}
+
+// Author returns the author of a tracked action
+func (t *ActionTracker) Author(hash crypto.Hash) (crypto.Token, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.statuses[hash]
+	if !ok {
+		return crypto.ZeroToken, false
+	}
+	return status.author, true
+}
+
+// Status returns a copy of the status of the action if it was submitted by
+// author.
+func (t *ActionTracker) Status(hash crypto.Hash, author crypto.Token) *ActionStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.statuses[hash]
+	if !ok || !status.author.Equal(author) {
+		return nil
+	}
+	copied := *status
+	return &copied
+}
+
+// Listen follows every update of the state. Updates are not delivered
+// through a subscription, a missed confirmation would leave the action to be
+// rejected by Expire.
+func (t *ActionTracker) Listen(s *state.State) {
+	s.Listen(state.Topic{}, func(update state.Updated) {
+		t.track(s, update)
+	})
+}
+
+// track is called by the state with its lock held
+func (t *ActionTracker) track(s *state.State, update state.Updated) {
+	switch update.Action {
+	case state.AcceptProposal:
+		t.Consensus(update.Hash, update.Epoch, StatusConsensus, "")
+	case state.RejectProposal:
+		t.Consensus(update.Hash, update.Epoch, StatusRejected, "proposal rejected")
+	case state.ExpireProposal:
+		t.Consensus(update.Hash, update.Epoch, StatusRejected, "proposal expired")
+	case state.WithdrawnProposal:
+		t.Consensus(update.Hash, update.Epoch, StatusRejected, "proposal withdrawn")
+	case state.RejectAction:
+		t.Rejected(update.Origin, update.Epoch, update.Reason)
+	default:
+		if update.Origin.Equal(crypto.ZeroValueHash) {
+			return
+		}
+		t.mu.Lock()
+		status, ok := t.statuses[update.Origin]
+		t.mu.Unlock()
+		if !ok {
+			return
+		}
+		t.Confirmed(update.Origin, update.Epoch, s.Proposals.Has(status.proposal))
+	}
+}
+
+// jsonAction is any of the structs of jsonactions.go
+type jsonAction interface {
+	ToAction() ([]actions.Action, error)
+}
+
+// jsonActions creates the struct for each action of the json api
+var jsonActions = map[string]func() jsonAction{
+	"BoardEditor":            func() jsonAction { return &BoardEditor{} },
+	"CancelEvent":            func() jsonAction { return &CancelEvent{} },
+	"ChangeHandle":           func() jsonAction { return &ChangeHandle{} },
+	"CheckinEvent":           func() jsonAction { return &CheckinEvent{} },
+	"Comment":                func() jsonAction { return &Comment{} },
+	"CreateBoard":            func() jsonAction { return &CreateBoard{} },
+	"CreateCollective":       func() jsonAction { return &CreateCollective{} },
+	"CreateEvent":            func() jsonAction { return &CreateEvent{} },
+	"Draft":                  func() jsonAction { return &Draft{} },
+	"Edit":                   func() jsonAction { return &Edit{} },
+	"GrantPowerOfAttorney":   func() jsonAction { return &GrantPowerOfAttorney{} },
+	"GreetCheckinEvent":      func() jsonAction { return &GreetCheckinEvent{} },
+	"MultiGreetCheckinEvent": func() jsonAction { return &MultiGreetCheckinEvent{} },
+	"ImprintStamp":           func() jsonAction { return &ImprintStamp{} },
+	"Pin":                    func() jsonAction { return &Pin{} },
+	"React":                  func() jsonAction { return &React{} },
+	"ReleaseDraft":           func() jsonAction { return &ReleaseDraft{} },
+	"RemoveMember":           func() jsonAction { return &RemoveMember{} },
+	"RequestMembership":      func() jsonAction { return &RequestMembership{} },
+	"RevokePowerOfAttorney":  func() jsonAction { return &RevokePowerOfAttorney{} },
+	"RotateKey":              func() jsonAction { return &RotateKey{} },
+	"UpdateBoard":            func() jsonAction { return &UpdateBoard{} },
+	"UpdateCollective":       func() jsonAction { return &UpdateCollective{} },
+	"UpdateEvent":            func() jsonAction { return &UpdateEvent{} },
+	"Vote":                   func() jsonAction { return &Vote{} },
+	"Withdraw":               func() jsonAction { return &Withdraw{} },
+}
+
+// parseJSONAction returns the actions described by the json of one of the
+// structs of jsonactions.go
+func parseJSONAction(data []byte) ([]actions.Action, error) {
+	var head Action
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid json: %v", err)
+	}
+	if head.Action == "" {
+		return nil, errors.New("missing action")
+	}
+	create, ok := jsonActions[head.Action]
+	if !ok {
+		return nil, fmt.Errorf("unknown action: %v", head.Action)
+	}
+	parsed := create()
+	if err := json.Unmarshal(data, parsed); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("invalid value for %v: expected %v", typeErr.Field, typeErr.Type)
+		}
+		return nil, fmt.Errorf("invalid %v: %v", head.Action, err)
+	}
+	all, err := parsed.ToAction()
+	if err != nil {
+		return nil, err
+	}
+	if len(all) == 0 {
+		return nil, errors.New("no action to submit")
+	}
+	return all, nil
+}
+
+type SubmittedAction struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"` // url of the status of the action
+}
+
+type SubmitResponse struct {
+	Actions []SubmittedAction `json:"actions"`
+}
+
+type SubmitErrors struct {
+	Errors []string `json:"errors"`
+}
+
+// SubmitHandler takes one of the structs of jsonactions.go, dresses and
+// sends its actions to the gateway and answers with their hashes.
+func (a *AttorneyGeneral) SubmitHandler(w http.ResponseWriter, r *http.Request) {
+	author := a.Author(r)
+	if author.Equal(crypto.ZeroToken) {
+		writeJSONError(w, http.StatusUnauthorized, "not signed in")
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "could not read request")
+		return
+	}
+	all, err := parseJSONAction(data)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{err.Error()}})
+		return
+	}
+	response := SubmitResponse{Actions: make([]SubmittedAction, 0, len(all))}
+	epoch := a.epoch.Load()
+	for _, action := range all {
+		dressed := actions.Dress(action.Serialize(), epoch, author, a.pk, a.wallet, 0)
+		envelope, err := actions.ParseEnvelope(dressed)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, SubmitErrors{Errors: []string{err.Error()}})
+			return
+		}
+		response.Actions = append(response.Actions, a.submit(dressed, envelope))
+	}
+	writeJSON(w, http.StatusAccepted, response)
+}
+
+// submit sends a dressed action to the gateway and tracks its status
+func (a *AttorneyGeneral) submit(dressed []byte, envelope *actions.Envelope) SubmittedAction {
+	hash := a.tracker.Submitted(envelope)
+	a.gateway.Action(dressed)
+	hashText, _ := hash.MarshalText()
+	return SubmittedAction{
+		Hash:   string(hashText),
+		Status: fmt.Sprintf("%v/actions/%v", RestPrefix, string(hashText)),
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+func TestRejectedStatus(t *testing.T) {
+	s := state.GenesisState(nil)
+	tracker := NewActionTracker()
+	tracker.Listen(s)
+	author, key := crypto.RandomAsymetricKey()
+	react := &actions.React{Epoch: 5, Author: author, Hash: crypto.Hasher([]byte("draft")), Reaction: 1}
+	dressed := actions.Dress(react.Serialize(), 5, author, key, key, 0)
+	envelope, err := actions.ParseEnvelope(dressed)
+	if err != nil {
+		t.Fatalf("could not parse envelope: %v", err)
+	}
+	hash := tracker.Submitted(envelope)
+	rejection := s.Action(dressed)
+	if rejection == nil {
+		t.Fatal("reaction from a future epoch accepted")
+	}
+	status := tracker.Status(hash, author)
+	if status == nil || status.Status != StatusRejected || status.Reason != rejection.Error() {
+		t.Errorf("unexpected status of a rejected action: %+v", status)
+	}
+}
+
+// TestConfirmedStatus submits more actions than a subscription can hold
+// without a reader, none of the confirmations is missed.
+func TestConfirmedStatus(t *testing.T) {
+	s := state.GenesisState(nil)
+	tracker := NewActionTracker()
+	tracker.Listen(s)
+	hashes := make(map[crypto.Hash]crypto.Token)
+	for n := 0; n < 2*state.SubscriptionBuffer; n++ {
+		author, key := crypto.RandomAsymetricKey()
+		signin := &actions.Signin{Epoch: 0, Author: author, Handle: fmt.Sprintf("user_%v", n)}
+		dressed := actions.Dress(signin.Serialize(), 0, author, key, key, 0)
+		envelope, err := actions.ParseEnvelope(dressed)
+		if err != nil {
+			t.Fatalf("could not parse envelope: %v", err)
+		}
+		hashes[tracker.Submitted(envelope)] = author
+		if err := s.Action(dressed); err != nil {
+			t.Fatalf("could not sign in: %v", err)
+		}
+	}
+	s.NextBlock()
+	tracker.Expire(state.ActionWindow + 2)
+	for hash, author := range hashes {
+		if status := tracker.Status(hash, author); status == nil || status.Status != StatusIncluded {
+			t.Fatalf("unexpected status of an included action: %+v", status)
+		}
+	}
+}
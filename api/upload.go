@@ -49,12 +49,15 @@ func (a *Attorney) UploadHandler(w http.ResponseWriter, r *http.Request) {
 	name := handle.Filename
 	parts := strings.Split(name, ".")
 	ext := parts[len(parts)-1]
+	// forms resolve handles to tokens
+	a.state.RLock()
 	switch r.FormValue("action") {
 	case "Draft":
 		actionArray, err = DraftForm(r, a.state.MembersIndex, fileBytes, ext).ToAction()
 	case "Edit":
 		actionArray, err = EditForm(r, a.state.MembersIndex, fileBytes, ext).ToAction()
 	}
+	a.state.RUnlock()
 	if err == nil && len(actionArray) > 0 {
 		a.Send(actionArray)
 	}
@@ -78,12 +81,15 @@ func (a *AttorneyGeneral) UploadHandler(w http.ResponseWriter, r *http.Request)
 	name := handle.Filename
 	parts := strings.Split(name, ".")
 	ext := parts[len(parts)-1]
+	// forms resolve handles to tokens
+	a.state.RLock()
 	switch r.FormValue("action") {
 	case "Draft":
 		actionArray, err = DraftForm(r, a.state.MembersIndex, fileBytes, ext).ToAction()
 	case "Edit":
 		actionArray, err = EditForm(r, a.state.MembersIndex, fileBytes, ext).ToAction()
 	}
+	a.state.RUnlock()
 	if err == nil && len(actionArray) > 0 {
 		a.Send(actionArray, author)
 	}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+const mailTokenDuration = 24 * 60 * 60 // epochs
+
+const minPasswordLength = 8
+
+// mailToken is sent by email to confirm the address of a new user or to
+// reset the password of a user.
+type mailToken struct {
+	user    crypto.Token
+	email   string
+	reset   bool
+	expires uint64
+}
+
+// MailTokens keeps the hashes of the tokens sent by email, each valid for a
+// single use until mailTokenDuration epochs after it is issued.
+type MailTokens struct {
+	mu     sync.Mutex
+	tokens map[crypto.Hash]*mailToken
+}
+
+func NewMailTokens() *MailTokens {
+	return &MailTokens{tokens: make(map[crypto.Hash]*mailToken)}
+}
+
+// Issue returns a new token for user. Older tokens of the user for the same
+// purpose are discarded.
+func (m *MailTokens) Issue(user crypto.Token, email string, reset bool, epoch uint64) string {
+	secret := make([]byte, crypto.Size)
+	if _, err := rand.Read(secret); err != nil {
+		log.Printf("could not generate mail token: %v", err)
+		return ""
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for hash, token := range m.tokens {
+		if token.expires < epoch || (token.user.Equal(user) && token.reset == reset) {
+			delete(m.tokens, hash)
+		}
+	}
+	m.tokens[crypto.Hasher(secret)] = &mailToken{user: user, email: email, reset: reset, expires: epoch + mailTokenDuration}
+	return hex.EncodeToString(secret)
+}
+
+func (m *MailTokens) find(text string, epoch uint64) (crypto.Hash, *mailToken) {
+	secret, err := hex.DecodeString(text)
+	if err != nil || len(secret) != crypto.Size {
+		return crypto.ZeroValueHash, nil
+	}
+	hash := crypto.Hasher(secret)
+	token, ok := m.tokens[hash]
+	if !ok || token.expires < epoch {
+		return hash, nil
+	}
+	return hash, token
+}
+
+// Valid checks if the token can be redeemed at epoch
+func (m *MailTokens) Valid(text string, epoch uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, token := m.find(text, epoch)
+	return token != nil
+}
+
+// Redeem returns the token and discards it
+func (m *MailTokens) Redeem(text string, epoch uint64) (*mailToken, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hash, token := m.find(text, epoch)
+	if token == nil {
+		return nil, false
+	}
+	delete(m.tokens, hash)
+	return token, true
+}
+
+func (a *AttorneyGeneral) mailLink(path, token string) string {
+	return fmt.Sprintf("%v%v?token=%v", a.url, path, url.QueryEscape(token))
+}
+
+// sendVerification emails the link to confirm the address of a new user
+func (a *AttorneyGeneral) sendVerification(handle, email string, token crypto.Token, member bool) error {
+	secret := a.mailTokens.Issue(token, email, false, a.epoch.Load())
+	if secret == "" {
+		return fmt.Errorf("could not generate verification token")
+	}
+	attorney := ""
+	if member {
+		attorney = fmt.Sprintf(attorneyMessage, a.pk.PublicKey())
+	}
+	body := fmt.Sprintf(verifyMessage, handle, a.mailLink("/verify", secret), attorney)
+	return a.mailer.Send(email, "Synergy Protocol Signin", body)
+}
+
+type VerifyView struct {
+	Head    HeaderInfo
+	Token   string
+	Reset   bool
+	Message string
+}
+
+func (a *AttorneyGeneral) renderVerify(w http.ResponseWriter, view VerifyView) {
+	if err := a.templates.ExecuteTemplate(w, "verify.html", view); err != nil {
+		log.Println(err)
+	}
+}
+
+// VerifyHandler sets the password of the user of an emailed token. The same
+// page serves the confirmation of new users and the password reset.
+func (a *AttorneyGeneral) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		token := r.URL.Query().Get("token")
+		if !a.mailTokens.Valid(token, a.epoch.Load()) {
+			a.renderVerify(w, VerifyView{Head: HeaderInfo{Error: "invalid or expired link"}})
+			return
+		}
+		a.renderVerify(w, VerifyView{Token: token})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		fmt.Fprintf(w, "ParseForm() err: %v", err)
+		return
+	}
+	token := r.FormValue("token")
+	password := r.FormValue("password")
+	// the token is echoed on the page only once checked to be a valid hex
+	if !a.mailTokens.Valid(token, a.epoch.Load()) {
+		a.renderVerify(w, VerifyView{Head: HeaderInfo{Error: "invalid or expired link"}})
+		return
+	}
+	if len(password) < minPasswordLength || password != r.FormValue("confirm") {
+		view := VerifyView{Token: token}
+		view.Head.Error = fmt.Sprintf("passwords must match and have at least %v characters", minPasswordLength)
+		a.renderVerify(w, view)
+		return
+	}
+	redeemed, ok := a.mailTokens.Redeem(token, a.epoch.Load())
+	if !ok {
+		a.renderVerify(w, VerifyView{Head: HeaderInfo{Error: "invalid or expired link"}})
+		return
+	}
+	if !a.credentials.Set(redeemed.user, password, redeemed.email) {
+		a.renderVerify(w, VerifyView{Head: HeaderInfo{Error: "internal error: could not store password"}})
+		return
+	}
+	if redeemed.reset {
+		// the account may have been compromised: sign out every browser
+		a.session.RevokeAll(redeemed.user)
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// ResetHandler emails a password reset link to the address registered for
+// the handle. The answer is the same whether the handle is known or not.
+func (a *AttorneyGeneral) ResetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.renderVerify(w, VerifyView{Reset: true})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		fmt.Fprintf(w, "ParseForm() err: %v", err)
+		return
+	}
+	handle := r.FormValue("handle")
+	a.state.RLock()
+	token, ok := a.state.MembersIndex[handle]
+	a.state.RUnlock()
+	if ok {
+		if email, ok := a.credentials.Email(token); ok {
+			if secret := a.mailTokens.Issue(token, email, true, a.epoch.Load()); secret != "" {
+				body := fmt.Sprintf(resetMessage, handle, a.mailLink("/verify", secret))
+				if err := a.mailer.Send(email, "Synergy Protocol Password Reset", body); err != nil {
+					log.Printf("could not send reset email: %v", err)
+				}
+			}
+		}
+	}
+	a.renderVerify(w, VerifyView{Message: "if the handle has a registered email, a reset link was sent to it"})
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+// smtpStandIn is an in-process smtp server that keeps the messages it gets
+type smtpStandIn struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	s := &smtpStandIn{listener: listener, messages: make(chan string, 10)}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	fmt.Fprint(conn, "220 localhost\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			fmt.Fprint(conn, "250 localhost\r\n")
+		case command == "DATA":
+			fmt.Fprint(conn, "354 go ahead\r\n")
+			var message strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				message.WriteString(line)
+			}
+			s.messages <- message.String()
+			fmt.Fprint(conn, "250 ok\r\n")
+		case command == "QUIT":
+			fmt.Fprint(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprint(conn, "250 ok\r\n")
+		}
+	}
+}
+
+var linkToken = regexp.MustCompile(`/verify\?token=([0-9a-f]+)`)
+
+func (s *smtpStandIn) token(t *testing.T, to string) string {
+	select {
+	case message := <-s.messages:
+		if !strings.Contains(message, "To: "+to) {
+			t.Fatalf("email sent to wrong address: %v", message)
+		}
+		match := linkToken.FindStringSubmatch(message)
+		if match == nil {
+			t.Fatalf("no link on email: %v", message)
+		}
+		return match[1]
+	case <-time.After(5 * time.Second):
+		t.Fatal("no email sent")
+	}
+	return ""
+}
+
+type recordGateway struct {
+	actions [][]byte
+}
+
+func (g *recordGateway) Stop()                 {}
+func (g *recordGateway) Action(dressed []byte) { g.actions = append(g.actions, dressed) }
+func (g *recordGateway) Register() chan uint64 { return nil }
+func (g *recordGateway) State() *state.State   { return nil }
+
+func postForm(handler http.HandlerFunc, path string, values url.Values) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestVerifyAndReset(t *testing.T) {
+	cheapKDF(t)
+	smtpServer := newSMTPStandIn(t)
+	templates, err := template.ParseFiles("templates/main.html", "templates/login.html", "templates/signin.html", "templates/verify.html")
+	if err != nil {
+		t.Fatalf("could not parse templates: %v", err)
+	}
+	_, attorneyKey := crypto.RandomAsymetricKey()
+	gateway := &recordGateway{}
+	a := &AttorneyGeneral{
+		pk:          attorneyKey,
+		wallet:      attorneyKey,
+		credentials: NewFilePasswordManager(filepath.Join(t.TempDir(), "passwords.dat")),
+		gateway:     gateway,
+		state:       state.GenesisState(nil),
+		templates:   templates,
+		mailer:      &SMTPMailer{Addr: smtpServer.listener.Addr().String(), From: "synergy@example.com"},
+		mailTokens:  NewMailTokens(),
+		url:         "http://synergy.example.com",
+	}
+	a.epoch.Store(10)
+	a.session = OpenCokieStore(filepath.Join(t.TempDir(), "cookies.dat"), a.state)
+	defer a.session.Close()
+
+	// sign in a new user
+	w := postForm(a.NewUserHandler, "/newuser", url.Values{"handle": {"newuser"}, "email": {"not an email"}})
+	if len(gateway.actions) != 0 || !strings.Contains(w.Body.String(), "valid email") {
+		t.Fatal("Invalid email accepted")
+	}
+	postForm(a.NewUserHandler, "/newuser", url.Values{"handle": {"newuser"}, "email": {"New User <new@example.com>"}})
+	if len(gateway.actions) != 1 {
+		t.Fatalf("Signin not sent to gateway")
+	}
+	token := smtpServer.token(t, "new@example.com")
+
+	w = postForm(a.VerifyHandler, "/verify", url.Values{"token": {token}, "password": {"short"}, "confirm": {"short"}})
+	if w.Code == http.StatusSeeOther {
+		t.Error("Short password accepted")
+	}
+	w = postForm(a.VerifyHandler, "/verify", url.Values{"token": {token}, "password": {"first password"}, "confirm": {"first password"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Verification failed: %v", w.Body.String())
+	}
+	w = postForm(a.VerifyHandler, "/verify", url.Values{"token": {token}, "password": {"other password"}, "confirm": {"other password"}})
+	if w.Code == http.StatusSeeOther {
+		t.Error("Verification token used twice")
+	}
+	user := onlyUser(t, a.credentials)
+	if !a.credentials.Check(user, "first password") {
+		t.Fatal("Password not set on verification")
+	}
+
+	// reset the password
+	a.state.MembersIndex["newuser"] = user
+	cookie := hex.EncodeToString(user[:])
+	if !a.session.Set(user, cookie, a.epoch.Load()) {
+		t.Fatal("Could not set session")
+	}
+	postForm(a.ResetHandler, "/reset", url.Values{"handle": {"unknown"}})
+	w = postForm(a.ResetHandler, "/reset", url.Values{"handle": {"newuser"}})
+	if !strings.Contains(w.Body.String(), "reset link") {
+		t.Errorf("Unexpected reset answer: %v", w.Body.String())
+	}
+	token = smtpServer.token(t, "new@example.com")
+	a.epoch.Add(mailTokenDuration + 1)
+	w = postForm(a.VerifyHandler, "/verify", url.Values{"token": {token}, "password": {"second password"}, "confirm": {"second password"}})
+	if w.Code == http.StatusSeeOther {
+		t.Error("Expired token accepted")
+	}
+	postForm(a.ResetHandler, "/reset", url.Values{"handle": {"newuser"}})
+	token = smtpServer.token(t, "new@example.com")
+	w = postForm(a.VerifyHandler, "/verify", url.Values{"token": {token}, "password": {"second password"}, "confirm": {"second password"}})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Reset failed: %v", w.Body.String())
+	}
+	if a.credentials.Check(user, "first password") || !a.credentials.Check(user, "second password") {
+		t.Error("Password not reset")
+	}
+	if _, ok := a.session.Get(cookie); ok {
+		t.Error("Session kept after password reset")
+	}
+}
+
+// onlyUser returns the only user with a password
+func onlyUser(t *testing.T, manager PasswordManager) crypto.Token {
+	passwords := manager.(*filePasswordManager).passwords
+	if len(passwords) != 1 {
+		t.Fatalf("expected one user, got %v", len(passwords))
+	}
+	for user := range passwords {
+		return user
+	}
+	return crypto.ZeroToken
+}
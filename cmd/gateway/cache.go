@@ -2,18 +2,55 @@ package main
 
 import (
 	"errors"
+	"expvar"
 	"fmt"
+	"sync"
+	"sync/atomic"
 
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/network/trusted"
+	"github.com/lienkolabs/synergy/social"
+)
+
+// SlowConsumer is what the gateway does with a connection whose send queue is
+// full.
+type SlowConsumer byte
+
+const (
+	// DropSlowConsumer shuts the connection down. The proxy reconnects and
+	// syncs from its last block.
+	DropSlowConsumer SlowConsumer = iota
+	// ResyncSlowConsumer discards the queue and syncs the connection again
+	// from the last block delivered to it, in multiblock batches.
+	ResyncSlowConsumer
+)
+
+type QueuePolicy struct {
+	Limit  int // maximum number of messages queued per connection
+	OnFull SlowConsumer
+}
+
+var DefaultQueuePolicy = QueuePolicy{Limit: 10000, OnFull: ResyncSlowConsumer}
+
+// metrics of the send queues, published by expvar on /debug/vars
+var (
+	queueDepth   = expvar.NewMap("gateway_queue_depth") // messages queued per connection
+	queueDrops   = expvar.NewInt("gateway_queue_drops")
+	queueResyncs = expvar.NewInt("gateway_queue_resyncs")
 )
 
 type ConnectionPool map[crypto.Token]*CachedConnection
 
-func (p ConnectionPool) Broadcast(data []byte) {
+// Broadcast queues data on every connection and returns the connections
+// with a full queue.
+func (p ConnectionPool) Broadcast(data []byte) []*CachedConnection {
+	slow := make([]*CachedConnection, 0)
 	for _, conn := range p {
-		conn.Send(data)
+		if !conn.Send(data) {
+			slow = append(slow, conn)
+		}
 	}
+	return slow
 }
 
 func (p ConnectionPool) Connect(c *CachedConnection) {
@@ -23,7 +60,7 @@ func (p ConnectionPool) Connect(c *CachedConnection) {
 func (p ConnectionPool) Drop(token crypto.Token) {
 	if conn, ok := p[token]; ok {
 		if conn != nil {
-			conn.Live = false
+			conn.live.Store(false)
 			conn.Close()
 		}
 		delete(p, token)
@@ -31,94 +68,218 @@ func (p ConnectionPool) Drop(token crypto.Token) {
 }
 
 type CachedConnection struct {
-	Live    bool
-	conn    *trusted.SignedConnection
-	ready   bool
-	receive chan []byte
-	queue   chan struct{}
+	live     atomic.Bool
+	conn     *trusted.SignedConnection
+	policy   QueuePolicy
+	mu       sync.Mutex
+	ready    bool
+	overflow bool // queue filled while syncing, resync once the sync is done
+	queue    [][]byte
+	epoch    uint64      // next block the connection needs
+	parent   crypto.Hash // hash of the last block delivered
+	writing  sync.Mutex  // serializes writes on conn
+	wake     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	depth    *expvar.Int
 }
 
-func (c *CachedConnection) Send(data []byte) {
+// Send queues data to be sent once the connection is synced. Returns false
+// if the queue is full.
+func (c *CachedConnection) Send(data []byte) bool {
 	if len(data) == 0 {
 		fmt.Println("empty data")
-		return
+		return true
+	}
+	if !c.live.Load() {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overflow {
+		// data is incorporated in the deferred resync
+		return true
+	}
+	if len(c.queue) >= c.policy.Limit {
+		return false
 	}
-	if c.Live {
-		c.receive <- data
+	c.queue = append(c.queue, data)
+	c.depth.Set(int64(len(c.queue)))
+	if c.ready {
+		c.signal()
 	}
+	return true
 }
 
+// SendDirect writes data on the connection bypassing the queue. Only valid
+// while the connection is being synced.
 func (c *CachedConnection) SendDirect(data []byte) error {
-	if (!c.Live) || c.ready {
+	if !c.live.Load() || c.isReady() {
 		return errors.New("connection is dead")
 	}
-	if err := c.conn.Send(data); err != nil {
+	c.writing.Lock()
+	err := c.conn.Send(data)
+	c.writing.Unlock()
+	if err != nil {
 		fmt.Println("error sending data:", err)
-		c.conn.Shutdown()
-		c.Live = false
+		c.live.Store(false)
 		c.Close()
 		return err
 	}
 	return nil
 }
 
-func (c *CachedConnection) Ready() {
-	c.ready = true
-	if c.Live {
-		c.queue <- struct{}{}
+// Ready starts sending the queue after a sync. Returns false if the queue
+// filled during the sync: the connection must then be resynced.
+func (c *CachedConnection) Ready() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overflow {
+		return false
 	}
+	c.ready = true
+	c.signal()
+	return true
+}
+
+func (c *CachedConnection) Depth() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
 }
 
 func (c *CachedConnection) Close() {
-	c.receive <- nil
+	c.once.Do(func() { close(c.done) })
 }
 
-func NewCachedConnection(conn *trusted.SignedConnection) *CachedConnection {
+func (c *CachedConnection) isReady() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ready
+}
 
-	cached := &CachedConnection{
-		Live:    true,
-		conn:    conn,
-		ready:   false,
-		receive: make(chan []byte),
-		queue:   make(chan struct{}, 2),
+func (c *CachedConnection) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
 	}
+}
 
-	msgCache := make([][]byte, 0)
+// resync discards the queue and takes the connection out of ready so that
+// it can be synced again. Returns false if the connection is being synced:
+// the resync is then deferred until the sync is done.
+func (c *CachedConnection) resync() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queue = nil
+	c.depth.Set(0)
+	if !c.ready {
+		c.overflow = true
+		return false
+	}
+	c.ready = false
+	return true
+}
+
+// restart clears a deferred resync so that the connection can be synced
+// again.
+func (c *CachedConnection) restart() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.overflow = false
+}
+
+// position returns the epoch and parent of the next block the connection
+// needs. It waits for any write in flight.
+func (c *CachedConnection) position() (uint64, crypto.Hash) {
+	c.writing.Lock()
+	defer c.writing.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch, c.parent
+}
+
+func (c *CachedConnection) setPosition(epoch uint64, parent crypto.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epoch, c.parent = epoch, parent
+}
+
+// delivered records that the block with the given header was sent
+func (c *CachedConnection) delivered(header *social.BlockHeader) {
+	c.setPosition(header.Epoch+1, header.Hash())
+}
+
+// next pops the next message to be sent, nil if there is none or the
+// connection is being synced.
+func (c *CachedConnection) next() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ready || len(c.queue) == 0 {
+		return nil
+	}
+	data := c.queue[0]
+	c.queue[0] = nil
+	c.queue = c.queue[1:]
+	c.depth.Set(int64(len(c.queue)))
+	return data
+}
+
+// flush sends the queued messages until the queue is empty
+func (c *CachedConnection) flush() error {
+	for {
+		c.writing.Lock()
+		data := c.next()
+		if data == nil {
+			c.writing.Unlock()
+			return nil
+		}
+		err := c.conn.Send(data)
+		if err == nil && data[0] == blocksignal {
+			if header, _ := social.ParseBlockHeader(data, 1); header != nil {
+				c.delivered(header)
+			}
+		}
+		c.writing.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func NewCachedConnection(conn *trusted.SignedConnection, policy QueuePolicy) *CachedConnection {
+
+	cached := &CachedConnection{
+		conn:   conn,
+		policy: policy,
+		ready:  false,
+		queue:  make([][]byte, 0),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		depth:  new(expvar.Int),
+	}
+	cached.live.Store(true)
+	key := fmt.Sprintf("%v", conn.Token)
+	queueDepth.Set(key, cached.depth)
 
 	// send loop
 	go func() {
 		defer func() {
 			conn.Shutdown()
-			cached.Live = false
-			close(cached.receive)
-			close(cached.queue)
+			cached.live.Store(false)
+			queueDepth.Delete(key)
 			fmt.Println("shut down connection")
 		}()
 		for {
 			select {
-			case <-cached.queue:
-				if N := len(msgCache); N > 0 {
-					data := msgCache[0]
-					msgCache = msgCache[1:]
-					if err := conn.Send(data); err != nil {
-						fmt.Println("error sending data:", err)
-						return
-					}
-					if N > 1 {
-						// this will never block because there will be one
-						// buffer slot on the channel
-						cached.queue <- struct{}{}
-					}
-				}
-			case data := <-cached.receive:
-				if data == nil {
-					fmt.Println("shutting down connection")
+			case <-cached.done:
+				fmt.Println("shutting down connection")
+				return
+			case <-cached.wake:
+				if err := cached.flush(); err != nil {
+					fmt.Println("error sending data:", err)
 					return
 				}
-				msgCache = append(msgCache, data)
-				if cached.ready && len(cached.queue) < cap(cached.queue) {
-					cached.queue <- struct{}{}
-				}
 			}
 		}
 	}()
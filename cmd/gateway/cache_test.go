@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/network/trusted"
+)
+
+func TestSlowConsumerDuringSync(t *testing.T) {
+	_, credentials := crypto.RandomAsymetricKey()
+	chain, err := openBlockchain(filepath.Join(t.TempDir(), "chain.dat"), credentials)
+	if err != nil {
+		t.Fatalf("could not create chain: %v", err)
+	}
+	defer chain.Close()
+	for _, policy := range []QueuePolicy{{Limit: 1, OnFull: ResyncSlowConsumer}, {Limit: 1, OnFull: DropSlowConsumer}} {
+		token, _ := crypto.RandomAsymetricKey()
+		conn := NewCachedConnection(&trusted.SignedConnection{Token: token}, policy)
+		pool := make(ConnectionPool)
+		pool.Connect(conn)
+		// the connection is not synced yet: its queue fills
+		chain.NewAction([]byte("first action"), pool)
+		chain.NewAction([]byte("second action"), pool)
+		if policy.OnFull == DropSlowConsumer {
+			if _, ok := pool[token]; ok {
+				t.Error("slow consumer not dropped")
+			}
+			continue
+		}
+		if _, ok := pool[token]; !ok {
+			t.Fatal("connection dropped while syncing")
+		}
+		go chain.Sync(&syncRequest{conn: conn}, len(chain.blocks)-1, len(chain.current.data))
+		select {
+		case request := <-chain.resyncs:
+			chain.Resync(request)
+		case <-time.After(5 * time.Second):
+			t.Fatal("resync not deferred to the end of the sync")
+		}
+		for start := time.Now(); !conn.isReady(); time.Sleep(time.Millisecond) {
+			if time.Since(start) > 5*time.Second {
+				t.Fatal("connection not ready after resync")
+			}
+		}
+		if !conn.Send([]byte("third action")) || conn.Depth() > 1 {
+			t.Error("connection not queuing after resync")
+		}
+		pool.Drop(token)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lienkolabs/synergy/social"
+)
+
+// Duration is a time.Duration written as "1s", "500ms" on the config file.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// GenesisMember is signed in on the first block of a new chain. Its signin is
+// signed by the gateway key, which thereby becomes an attorney of the member
+// until revoked by the member key.
+type GenesisMember struct {
+	Handle string `json:"handle"`
+	Token  string `json:"token"` // hex encoded
+}
+
+type Config struct {
+	Listen        string          `json:"listen"`   // address for proxy connections
+	DataDir       string          `json:"data"`     // chain and snapshot files
+	KeyFile       string          `json:"key"`      // gateway key, created if missing
+	Metrics       string          `json:"metrics"`  // address for /debug/vars, empty to disable
+	BlockInterval Duration        `json:"interval"` // the state takes an epoch as one second
+	QueueLimit    int             `json:"queue"`
+	SlowConsumer  string          `json:"slow"` // "resync" or "drop"
+	Genesis       []GenesisMember `json:"genesis"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Listen:        ":4100",
+		DataDir:       ".",
+		KeyFile:       "gateway.key",
+		Metrics:       "localhost:4101",
+		BlockInterval: Duration{time.Second},
+		QueueLimit:    DefaultQueuePolicy.Limit,
+		SlowConsumer:  "resync",
+	}
+}
+
+func (c *Config) ChainPath() string {
+	return filepath.Join(c.DataDir, "chain.dat")
+}
+
+func (c *Config) SnapshotPath() string {
+	return filepath.Join(c.DataDir, "state.dat")
+}
+
+func (c *Config) QueuePolicy() QueuePolicy {
+	policy := QueuePolicy{Limit: c.QueueLimit, OnFull: ResyncSlowConsumer}
+	if c.SlowConsumer == "drop" {
+		policy.OnFull = DropSlowConsumer
+	}
+	return policy
+}
+
+func (c *Config) check() error {
+	if c.BlockInterval.Duration <= 0 {
+		return errors.New("block interval must be positive")
+	}
+	if c.QueueLimit <= 0 {
+		return errors.New("queue limit must be positive")
+	}
+	if c.SlowConsumer != "resync" && c.SlowConsumer != "drop" {
+		return fmt.Errorf("unknown slow consumer policy: %v", c.SlowConsumer)
+	}
+	for _, member := range c.Genesis {
+		if member.Handle == "" {
+			return errors.New("genesis members need a handle")
+		}
+		if _, err := social.ParseToken(member.Token); err != nil {
+			return fmt.Errorf("genesis member %v: %v", member.Handle, err)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads the config file given by -config (if any) and overrides
+// it with the flags explicitly set. Genesis members are only read from the
+// config file.
+func LoadConfig(args []string) (*Config, error) {
+	config := defaultConfig()
+	flags := flag.NewFlagSet("gateway", flag.ContinueOnError)
+	path := flags.String("config", "", "json config file")
+	listen := flags.String("listen", config.Listen, "address for proxy connections")
+	data := flags.String("data", config.DataDir, "directory of chain and snapshot files")
+	key := flags.String("key", config.KeyFile, "gateway key file (created if missing)")
+	metrics := flags.String("metrics", config.Metrics, "address for queue metrics on /debug/vars (empty to disable)")
+	interval := flags.Duration("interval", config.BlockInterval.Duration, "block interval")
+	queue := flags.Int("queue", config.QueueLimit, "maximum messages queued per proxy")
+	slow := flags.String("slow", config.SlowConsumer, "policy for proxies with a full queue: resync or drop")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if *path != "" {
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("invalid config file: %v", err)
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			config.Listen = *listen
+		case "data":
+			config.DataDir = *data
+		case "key":
+			config.KeyFile = *key
+		case "metrics":
+			config.Metrics = *metrics
+		case "interval":
+			config.BlockInterval.Duration = *interval
+		case "queue":
+			config.QueueLimit = *queue
+		case "slow":
+			config.SlowConsumer = *slow
+		}
+	})
+	if err := config.check(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.json")
+	file := `{"listen": ":5100", "data": "/var/synergy", "interval": "500ms", "slow": "drop",
+		"genesis": [{"handle": "user_0", "token": "` + strings.Repeat("ab", crypto.TokenSize) + `"}]}`
+	if err := os.WriteFile(path, []byte(file), 0666); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig([]string{"-config", path, "-listen", ":6100"})
+	if err != nil {
+		t.Fatalf("could not load config: %v", err)
+	}
+	if config.Listen != ":6100" {
+		t.Errorf("flag does not override config file: %v", config.Listen)
+	}
+	if config.ChainPath() != filepath.Join("/var/synergy", "chain.dat") || config.BlockInterval.Duration != 500*time.Millisecond {
+		t.Errorf("config file not read: %+v", config)
+	}
+	if config.QueuePolicy().OnFull != DropSlowConsumer || config.QueuePolicy().Limit != DefaultQueuePolicy.Limit {
+		t.Errorf("unexpected queue policy: %+v", config.QueuePolicy())
+	}
+	if len(config.Genesis) != 1 || config.Genesis[0].Handle != "user_0" {
+		t.Errorf("genesis members not read: %+v", config.Genesis)
+	}
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"genesis": [{"handle": "user_0", "key": "user_0.key"}]}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig([]string{"-config", invalid}); err == nil {
+		t.Error("genesis member without token accepted")
+	}
+	if _, err := LoadConfig([]string{"-slow", "block"}); err == nil {
+		t.Error("unknown slow consumer policy accepted")
+	}
+}
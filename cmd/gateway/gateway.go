@@ -9,43 +9,74 @@ import (
 
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/network/trusted"
+	"github.com/lienkolabs/synergy/social"
+	"github.com/lienkolabs/synergy/social/actions"
 	"github.com/lienkolabs/synergy/social/state"
 )
 
-func GetState(chain *blockchain) (*state.State, error) {
-	genesis := state.GenesisState(nil)
+// GetState starts from the latest state snapshot (if any) and replays the
+// blocks after it.
+func GetState(chain *blockchain, snapshotPath string) (*state.State, error) {
+	genesis, start := snapshotState(snapshotPath, chain)
+	if genesis == nil {
+		genesis = state.GenesisState(nil)
+	}
 	if genesis == nil {
 		return nil, errors.New("could not create genesis state")
 	}
-	for _, block := range chain.blocks {
+	for _, block := range chain.blocks[start:] {
 		for _, action := range block.data {
 			if err := genesis.Action(action); err != nil {
 				return nil, fmt.Errorf("blockchain has invalid action: %v", err)
 			}
 
 		}
+		if block.header == nil {
+			// current block is still open
+			break
+		}
+		if root := genesis.NextBlock(); !root.Equal(block.header.StateRoot) {
+			return nil, fmt.Errorf("state root mismatch at block %v", block.header.Epoch)
+		}
 	}
 	return genesis, nil
 }
 
-func NewActionsGateway(port int, credentials crypto.PrivateKey, chain *blockchain) (chan trusted.Message, error) {
+type ActionsGateway struct {
+	Messages chan trusted.Message // actions submitted to the gateway
+	stop     chan chan struct{}
+}
+
+// Stop stops accepting connections, drops every proxy and closes the chain
+// file once the current block step is done.
+func (g *ActionsGateway) Stop() {
+	resp := make(chan struct{})
+	g.stop <- resp
+	<-resp
+}
+
+// NewActionsGateway listens for proxies on the configured address. Each proxy
+// connection has a send queue bounded by the configured policy.
+func NewActionsGateway(config *Config, credentials crypto.PrivateKey, chain *blockchain) (*ActionsGateway, error) {
 	validate := trusted.AcceptAllConnections
-	listeners, err := net.Listen("tcp", fmt.Sprintf(":%v", port))
+	policy := config.QueuePolicy()
+	snapshotPath := config.SnapshotPath()
+	genesis, err := GetState(chain, snapshotPath)
 	if err != nil {
 		return nil, err
 	}
-
-	var genesis *state.State
-	if genesis, err = GetState(chain); err != nil {
+	listeners, err := net.Listen("tcp", config.Listen)
+	if err != nil {
 		return nil, err
 	}
 
 	pool := make(ConnectionPool)
-	incorporate := make(chan *CachedConnection)
+	incorporate := make(chan *syncRequest)
 
 	shutDown := make(chan crypto.Token) // receive connection shutdown
 	messages := make(chan trusted.Message)
-	click := time.NewTicker(time.Second)
+	stop := make(chan chan struct{})
+	click := time.NewTicker(config.BlockInterval.Duration)
 
 	go func() {
 		for {
@@ -54,9 +85,23 @@ func NewActionsGateway(port int, credentials crypto.PrivateKey, chain *blockchai
 				if err != nil {
 					conn.Close()
 				} else {
-					cached := NewCachedConnection(trustedConn)
-					incorporate <- cached
-					trustedConn.Listen(messages, shutDown)
+					go func() {
+						// first message is the sync request
+						data, err := trustedConn.Read()
+						if err != nil {
+							trustedConn.Shutdown()
+							return
+						}
+						epoch, parent, ok := social.ParseSyncRequest(data)
+						if !ok {
+							log.Printf("invalid sync request from %v", trustedConn.Token)
+							trustedConn.Shutdown()
+							return
+						}
+						cached := NewCachedConnection(trustedConn, policy)
+						incorporate <- &syncRequest{conn: cached, epoch: epoch, parent: parent}
+						trustedConn.Listen(messages, shutDown)
+					}()
 				}
 			} else {
 				return
@@ -67,25 +112,58 @@ func NewActionsGateway(port int, credentials crypto.PrivateKey, chain *blockchai
 	go func() {
 		for {
 			select {
+			case resp := <-stop:
+				click.Stop()
+				listeners.Close()
+				for token := range pool {
+					pool.Drop(token)
+				}
+				chain.Close()
+				close(resp)
+				return
 			case <-click.C:
 				// next block and broadcast
-				chain.NewBlock(pool)
-			case cached := <-incorporate:
-				pool.Connect(cached)
+				chain.NewBlock(genesis.NextBlock(), pool)
+				if sealed := uint64(len(chain.blocks) - 1); sealed%snapshotInterval == 0 {
+					snapshot, parent := genesis.Snapshot(), chain.parent
+					go func() {
+						if err := social.WriteSnapshot(snapshotPath, sealed, parent, snapshot); err != nil {
+							log.Printf("could not write state snapshot: %v", err)
+						}
+					}()
+				}
+			case request := <-chain.resyncs:
+				if conn, ok := pool[request.conn.conn.Token]; ok && conn == request.conn {
+					chain.Resync(request)
+				}
+			case request := <-incorporate:
+				pool.Connect(request.conn)
 				// start sync node
-				go chain.Sync(cached, len(chain.blocks)-1, len(chain.current.data))
+				go chain.Sync(request, len(chain.blocks)-1, len(chain.current.data))
 			case token := <-shutDown:
 				pool.Drop(token)
 			case msg := <-messages:
+				if len(msg.Data)+1 > maxRecordSize {
+					log.Printf("rejected action from %v: %v bytes is too long", msg.Token, len(msg.Data))
+					continue
+				}
+				// state checks signatures and power of attorney of the
+				// dressed action. chain keeps the dressed action so that
+				// proxies can check them as well.
 				if err := genesis.Action(msg.Data); err == nil {
 					// incorporate to chain and broadcast
 					chain.NewAction(msg.Data, pool)
 				} else {
-					log.Println(err)
+					log.Printf("rejected action from %v: %v", msg.Token, err)
+					if hash, ok := actions.ActionHash(msg.Data); ok {
+						if conn, ok := pool[msg.Token]; ok {
+							conn.Send(append([]byte{rejectsignal}, social.RejectionMessage(hash, err.Error())...))
+						}
+					}
 				}
 			}
 		}
 	}()
 
-	return messages, nil
+	return &ActionsGateway{Messages: messages, stop: stop}, nil
 }
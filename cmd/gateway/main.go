@@ -1,44 +1,85 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/network/trusted"
+	"github.com/lienkolabs/synergy/social"
 	"github.com/lienkolabs/synergy/social/actions"
 )
 
-var pks []crypto.PrivateKey = []crypto.PrivateKey{
-	{118, 35, 197, 163, 215, 20, 35, 190, 110, 151, 246, 231, 86, 177, 156, 89, 122, 69, 28, 233, 185, 150, 126, 169, 237, 173, 83, 120, 145, 238, 242, 137,
-		171, 216, 111, 131, 116, 217, 38, 148, 28, 178, 174, 63, 166, 4, 50, 6, 20, 133, 15, 153, 41, 252, 164, 165, 2, 127, 163, 204, 24, 24, 188, 240},
-	{152, 224, 227, 154, 131, 1, 186, 147, 73, 37, 4, 253, 11, 148, 195, 67, 86, 85, 28, 162, 78, 239, 168, 42, 204, 222, 144, 41, 186, 246, 250, 57, 125, 202,
-		107, 133, 63, 39, 136, 246, 120, 222, 29, 73, 106, 213, 95, 132, 50, 130, 162, 42, 95, 159, 10, 246, 213, 217, 160, 125, 181, 194, 37, 174},
-	{125, 86, 238, 128, 237, 4, 143, 47, 214, 72, 71, 47, 72, 45, 214, 45, 178, 98, 105, 154, 171, 151, 73, 183, 234, 120, 128, 38, 174, 253, 105, 162, 189,
-		253, 40, 134, 214, 5, 229, 224, 171, 175, 152, 114, 72, 167, 9, 215, 75, 171, 3, 255, 30, 255, 110, 127, 9, 3, 129, 24, 230, 246, 109, 184},
+// genesisSignins dresses the signin of each genesis member with the gateway
+// key as attorney: the private keys of the members are not needed.
+func genesisSignins(members []GenesisMember, credentials crypto.PrivateKey) ([][]byte, error) {
+	signins := make([][]byte, 0, len(members))
+	for _, member := range members {
+		token, err := social.ParseToken(member.Token)
+		if err != nil {
+			return nil, fmt.Errorf("genesis member %v: %v", member.Handle, err)
+		}
+		action := &actions.Signin{
+			Epoch:   uint64(0),
+			Author:  token,
+			Reasons: "genesis",
+			Handle:  member.Handle,
+		}
+		signins = append(signins, actions.Dress(action.Serialize(), 0, token, credentials, credentials, 0))
+	}
+	return signins, nil
 }
 
-var gatewayPK = crypto.PrivateKey{121, 98, 124, 72, 181, 150, 37, 34, 195, 97, 127, 65, 198, 38, 114, 116, 94, 244, 191, 249, 171, 114, 54, 232, 84, 87, 151, 146, 40, 249, 220, 89, 52, 170, 195, 171,
-	223, 79, 238, 175, 43, 29, 241, 31, 238, 42, 141, 254, 202, 212, 102, 132, 0, 53, 249, 84, 179, 102, 229, 5, 205, 10, 145, 246}
-
 func main() {
-	chain, exists := OpenBlockchain()
-	message, _ := NewActionsGateway(4100, gatewayPK, chain)
+	config, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	credentials, created, err := social.OpenKeyFile(config.KeyFile)
+	if err != nil {
+		log.Fatalf("could not open key file: %v", err)
+	}
+	if created {
+		log.Printf("new gateway key written to %v", config.KeyFile)
+	}
+	token := credentials.PublicKey()
+	log.Printf("gateway token %v", hex.EncodeToString(token[:]))
+
+	chain, exists, err := OpenBlockchain(config.ChainPath(), credentials)
+	if err != nil {
+		log.Fatalf("could not open chain file: %v", err)
+	}
+	gateway, err := NewActionsGateway(config, credentials, chain)
+	if err != nil {
+		chain.Close()
+		log.Fatalf("could not start gateway: %v", err)
+	}
 	if !exists {
-		for n, pk := range pks {
-			action := &actions.Signin{
-				Epoch:   uint64(0),
-				Author:  pk.PublicKey(),
-				Reasons: "test",
-				Handle:  fmt.Sprintf("user_%v", n),
-			}
-			fmt.Println(action)
-			message <- trusted.Message{
-				Token: gatewayPK.PublicKey(),
-				Data:  action.Serialize(),
-			}
+		signins, err := genesisSignins(config.Genesis, credentials)
+		if err != nil {
+			gateway.Stop()
+			log.Fatal(err)
+		}
+		for _, signin := range signins {
+			gateway.Messages <- trusted.Message{Token: credentials.PublicKey(), Data: signin}
 		}
 	}
-	fmt.Println("listening on port 4100")
-	for {
+	if config.Metrics != "" {
+		// queue metrics on /debug/vars
+		go func() {
+			log.Print(http.ListenAndServe(config.Metrics, nil))
+		}()
 	}
+	log.Printf("listening on %v", config.Listen)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	log.Printf("%v: shutting down", sig)
+	gateway.Stop()
 }
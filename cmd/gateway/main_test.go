@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+func TestGenesisSignins(t *testing.T) {
+	member, _ := crypto.RandomAsymetricKey()
+	_, credentials := crypto.RandomAsymetricKey()
+	signins, err := genesisSignins([]GenesisMember{{Handle: "user_0", Token: hex.EncodeToString(member[:])}}, credentials)
+	if err != nil {
+		t.Fatalf("could not sign genesis members: %v", err)
+	}
+	s := state.GenesisState(nil)
+	for _, signin := range signins {
+		if err := s.Action(signin); err != nil {
+			t.Fatalf("genesis signin rejected: %v", err)
+		}
+	}
+	if !s.MembersIndex["user_0"].Equal(member) || !s.IsAttorney(member, credentials.PublicKey()) {
+		t.Error("genesis member not signed in by the gateway")
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/lienkolabs/synergy/social"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+// snapshot of the state is written every snapshotInterval blocks
+const snapshotInterval = 600
+
+// snapshotState returns the state and the first block to replay on top of it
+// if the snapshot file is consistent with the chain.
+func snapshotState(path string, chain *blockchain) (*state.State, int) {
+	epoch, parent, restored, err := social.ReadSnapshot(path, nil)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("ignoring state snapshot: %v", err)
+		}
+		return nil, 0
+	}
+	sealed := uint64(len(chain.blocks) - 1)
+	if epoch == 0 || epoch > sealed || !chain.blocks[epoch-1].header.Hash().Equal(parent) {
+		log.Print("ignoring state snapshot: not consistent with chain")
+		return nil, 0
+	}
+	return restored, int(epoch)
+}
@@ -1,98 +1,246 @@
 package main
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
 	"log"
 	"os"
 	"sync"
 
+	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/util"
+	"github.com/lienkolabs/synergy/social"
 )
 
 type block struct {
-	data [][]byte
+	header *social.BlockHeader // nil while the block is open
+	data   [][]byte
 }
 
-func multiblock(startEpoch uint64, blocks []*block) []byte {
-	bytes := []byte{2}
-	for n, block := range blocks {
-		util.PutUint64(startEpoch+uint64(n), &bytes)
+func multiblock(blocks []*block) []byte {
+	bytes := []byte{multiblocksignal}
+	for _, block := range blocks {
+		bytes = append(bytes, block.header.Serialize()...)
 		util.PutActionsArray(block.data, &bytes)
 	}
 	return bytes
 }
 
 type blockchain struct {
-	mu      sync.Mutex
-	io      *os.File
-	blocks  []*block
-	current *block
+	mu          sync.Mutex
+	io          *os.File
+	blocks      []*block
+	current     *block
+	credentials crypto.PrivateKey
+	parent      crypto.Hash       // hash of the last sealed block header
+	resyncs     chan *syncRequest // deferred resyncs, started by the gateway loop
 }
 
-// sync a new connection
-func (b *blockchain) Sync(conn *CachedConnection, epoch, actionCount int) {
+type syncRequest struct {
+	conn   *CachedConnection
+	epoch  uint64      // first block the connection is missing
+	parent crypto.Hash // hash of the last block the connection has
+	resync bool        // resync of a slow connection from its last block
+}
+
+// from returns the first block to stream for the request: the requested
+// epoch if the connection is on the same chain, genesis otherwise.
+func (b *blockchain) from(request *syncRequest, epoch int) int {
+	if request.epoch == 0 || request.epoch > uint64(epoch) {
+		return 0
+	}
+	if !b.blocks[request.epoch-1].header.Hash().Equal(request.parent) {
+		return 0
+	}
+	return int(request.epoch)
+}
+
+// sync a new connection from the requested epoch. A slow connection being
+// resynced starts from the last block delivered to it.
+func (b *blockchain) Sync(request *syncRequest, epoch, actionCount int) {
+	conn := request.conn
+	if request.resync {
+		request.epoch, request.parent = conn.position()
+		// proxy discards the actions of the open block received so far
+		if err := conn.SendDirect([]byte{resyncsignal}); err != nil {
+			return
+		}
+	}
 	b.mu.Lock()
+	// sealed blocks are not modified anymore
+	from := b.from(request, epoch)
+	sealed := b.blocks[from:epoch]
 	currentBlockCache := make([][]byte, actionCount)
-	for n := 0; n < actionCount; n++ {
-		currentBlockCache[n] = b.blocks[epoch].data[n]
-	}
+	copy(currentBlockCache, b.blocks[epoch].data[:actionCount])
 	b.mu.Unlock()
-	for n := 0; n <= (epoch-1)/1000; n++ {
-		count := 1000
-		if (n+1)*1000 > (epoch - 1) {
-			count = (epoch - 1) % 1000
+	if from == 0 {
+		conn.setPosition(0, crypto.ZeroHash)
+	} else {
+		conn.setPosition(request.epoch, request.parent)
+	}
+	for start := 0; start < len(sealed); start += 1000 {
+		end := start + 1000
+		if end > len(sealed) {
+			end = len(sealed)
 		}
-		blocks := make([]*block, count)
-		for c := 0; c < count; c++ {
-			blocks[c] = &block{
-				data: make([][]byte, 0),
-			}
-			blocks[c].data = append(blocks[c].data, b.blocks[n*1000+c].data...)
+		if err := conn.SendDirect(multiblock(sealed[start:end])); err != nil {
+			return
 		}
-		conn.SendDirect(multiblock(uint64(n*1000), blocks))
+		conn.delivered(sealed[end-1].header)
 	}
-	conn.SendDirect(newBlockBytes(uint64(epoch)))
 	for _, action := range currentBlockCache {
 		conn.SendDirect(append([]byte{actionsignal}, action...))
 	}
-	/*for n := 0; n <= epoch; n++ {
-		conn.SendDirect(newBlockBytes(uint64(n)))
-		if n == epoch {
-			for _, action := range currentBlockCache {
-				conn.SendDirect(append([]byte{actionsignal}, action...))
+	if !conn.Ready() {
+		// queue filled during the sync
+		select {
+		case b.resyncs <- &syncRequest{conn: conn, resync: true}:
+		case <-conn.done:
+		}
+	}
+}
+
+// Resync starts a deferred resync. Must be called on the same goroutine that
+// calls broadcast, so that the resync and the queue do not overlap.
+func (b *blockchain) Resync(request *syncRequest) {
+	request.conn.restart()
+	go b.Sync(request, len(b.blocks)-1, len(b.current.data))
+}
+
+// broadcast queues data on every connection of the pool. Connections with a
+// full queue are dropped or resynced according to their policy.
+func (b *blockchain) broadcast(data []byte, pool ConnectionPool) {
+	for _, conn := range pool.Broadcast(data) {
+		if conn.policy.OnFull == ResyncSlowConsumer {
+			queueResyncs.Add(1)
+			// data is incorporated in the resync. A connection still being
+			// synced is resynced once done.
+			if conn.resync() {
+				go b.Sync(&syncRequest{conn: conn, resync: true}, len(b.blocks)-1, len(b.current.data))
 			}
 		} else {
-			for _, action := range b.blocks[n].data {
-				conn.SendDirect(append([]byte{actionsignal}, action...))
-			}
+			queueDrops.Add(1)
+			pool.Drop(conn.conn.Token)
 		}
-	}*/
-	conn.Ready()
+	}
 }
 
 const (
-	blocksignal  byte = 0
-	actionsignal byte = 1
+	blocksignal      byte = 0
+	actionsignal     byte = 1
+	multiblocksignal byte = 2
+	resyncsignal     byte = 3
+	rejectsignal     byte = 4 // sent only to the connection that sent the action
+)
+
+func newBlockBytes(header *social.BlockHeader) []byte {
+	return append([]byte{blocksignal}, header.Serialize()...)
+}
+
+// Records on the chain file are
+//
+//	length (4 bytes) | signal | body | crc32 checksum of signal and body
+//
+// with length covering signal and body. Block records carry the serialized
+// header and action records the dressed action.
+
+const recordOverhead = 4 + 4
+
+// maxRecordSize bounds the length of a record (signal and body). Longer
+// actions are not accepted by the gateway, so a longer length on file can
+// only be a corrupted one.
+const maxRecordSize = 1 << 24
+
+var (
+	errTornRecord      = errors.New("torn record")
+	errCorruptedRecord = errors.New("record checksum mismatch")
 )
 
-func newBlockBytes(epoch uint64) []byte {
-	data := []byte{blocksignal}
-	util.PutUint64(epoch, &data)
+func record(signal byte, body []byte) []byte {
+	data := make([]byte, 0, recordOverhead+1+len(body))
+	util.PutUint32(uint32(1+len(body)), &data)
+	data = append(data, signal)
+	data = append(data, body...)
+	util.PutUint32(crc32.ChecksumIEEE(data[4:]), &data)
 	return data
 }
 
-func (b *blockchain) NewBlock(pool ConnectionPool) {
-	newBlock := &block{data: make([][]byte, 0)}
-	b.current = newBlock
-	b.blocks = append(b.blocks, b.current)
-	// pool = nil when reading data from file at initialization
-	if pool != nil {
-		epoch := uint64(len(b.blocks) - 1)
-		data := newBlockBytes(epoch)
-		if n, err := b.io.Write(data); n != len(data) || err != nil {
-			log.Fatalf("could not write block: %v", err)
+// readRecord reads the record at the current position of reader returning
+// signal, body and the number of bytes taken by the record on file.
+// errTornRecord is returned if the file ends before a record of valid length
+// does.
+func readRecord(reader io.Reader) (byte, []byte, int64, error) {
+	size := make([]byte, 4)
+	if n, err := io.ReadFull(reader, size); err != nil {
+		if n == 0 && err == io.EOF {
+			return 0, nil, 0, io.EOF
+		}
+		return 0, nil, int64(n), errTornRecord
+	}
+	length, _ := util.ParseUint32(size, 0)
+	if length == 0 || length > maxRecordSize {
+		return 0, nil, 4, errCorruptedRecord
+	}
+	data := make([]byte, int(length)+4)
+	if n, err := io.ReadFull(reader, data); err != nil {
+		return 0, nil, int64(4 + n), errTornRecord
+	}
+	consumed := int64(recordOverhead + length)
+	checksum, _ := util.ParseUint32(data, int(length))
+	if checksum != crc32.ChecksumIEEE(data[:length]) {
+		return 0, nil, consumed, errCorruptedRecord
+	}
+	return data[0], data[1:length], consumed, nil
+}
+
+// zeroed reports whether the file holds only zeros from offset to size
+func zeroed(file *os.File, offset, size int64) (bool, error) {
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, size-offset))
+	for {
+		c, err := reader.ReadByte()
+		if err == io.EOF {
+			return true, nil
 		}
-		pool.Broadcast(data)
+		if err != nil {
+			return false, err
+		}
+		if c != 0 {
+			return false, nil
+		}
+	}
+}
+
+func (b *blockchain) write(data []byte) {
+	if n, err := b.io.Write(data); n != len(data) || err != nil {
+		log.Fatalf("could not write to chain file: %v", err)
+	}
+}
+
+// seal closes the current block with the given header and opens the next one
+func (b *blockchain) seal(header *social.BlockHeader) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current.header = header
+	b.parent = header.Hash()
+	b.current = &block{data: make([][]byte, 0)}
+	b.blocks = append(b.blocks, b.current)
+}
+
+// NewBlock seals the current block and the state root after it with the
+// gateway signature, writes the header to file and broadcasts it. The file is
+// synced at every block so that a sealed block is never lost.
+func (b *blockchain) NewBlock(root crypto.Hash, pool ConnectionPool) {
+	epoch := uint64(len(b.blocks) - 1)
+	header := social.SealBlock(epoch, b.parent, b.current.data, root, b.credentials)
+	b.write(record(blocksignal, header.Serialize()))
+	if err := b.io.Sync(); err != nil {
+		log.Fatalf("could not sync chain file: %v", err)
 	}
+	b.seal(header)
+	b.broadcast(newBlockBytes(header), pool)
 }
 
 func (b *blockchain) NewAction(action []byte, pool ConnectionPool) {
@@ -100,13 +248,8 @@ func (b *blockchain) NewAction(action []byte, pool ConnectionPool) {
 	b.current.data = append(b.current.data, action)
 	b.mu.Unlock() // pool = nil when reading data from file at initialization
 	if pool != nil {
-		data := []byte{actionsignal}
-		util.PutUint64(uint64(len(action)), &data)
-		data = append(data, action...)
-		if n, err := b.io.Write(data); n != len(data) || err != nil {
-			log.Fatalf("could not write action: %v", err)
-		}
-		pool.Broadcast(append([]byte{actionsignal}, action...))
+		b.write(record(actionsignal, action))
+		b.broadcast(append([]byte{actionsignal}, action...), pool)
 	}
 }
 
@@ -114,57 +257,100 @@ func (b *blockchain) Close() {
 	b.io.Close()
 }
 
-func OpenBlockchain() (*blockchain, bool) {
-	exists := true
-	if stat, err := os.Stat("../../chain.dat"); err != nil || stat.Size() == 0 {
-		exists = false
+// OpenBlockchain opens the chain file of the gateway. Returns false if the
+// chain is empty.
+func OpenBlockchain(path string, credentials crypto.PrivateKey) (*blockchain, bool, error) {
+	b, err := openBlockchain(path, credentials)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, len(b.blocks) > 1 || len(b.current.data) > 0, nil
+}
+
+// openBlockchain reads the chain file checking that every block header
+// extends the chain and is signed by the gateway credentials. A torn record
+// at the end of the file (a crash while writing), or a zero filled tail (a
+// power loss before the actions were synced), is recovered by truncating the
+// file back to the last complete block.
+func openBlockchain(path string, credentials crypto.PrivateKey) (*blockchain, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return nil, err
 	}
-	file, err := os.OpenFile("../../chain.dat", os.O_CREATE|os.O_RDWR, 0666)
+	stat, err := file.Stat()
 	if err != nil {
-		log.Fatalf("could not access chain file: %v\n", err)
+		file.Close()
+		return nil, err
 	}
 	b := &blockchain{
-		mu:     sync.Mutex{},
-		blocks: make([]*block, 0),
-		io:     file,
+		mu:          sync.Mutex{},
+		blocks:      make([]*block, 0),
+		io:          file,
+		credentials: credentials,
+		parent:      crypto.ZeroHash,
+		resyncs:     make(chan *syncRequest),
 	}
-	newBlock := &block{data: make([][]byte, 0)}
-	b.current = newBlock
+	// genesis block
+	b.current = &block{data: make([][]byte, 0)}
 	b.blocks = append(b.blocks, b.current)
-	if !exists {
-		data := make([]byte, 9)
-		data[0] = blocksignal
-		// write the creation of genesis block
-		if n, err := file.Write(data); n != len(data) || err != nil {
-			log.Fatalf("could not write block: %v", err)
-		}
-		return b, false
-	}
-	signal := make([]byte, 9)
+
+	gateway := credentials.PublicKey()
+	reader := bufio.NewReader(file)
+	var position, sealed int64 // end of last record and last block record
 	for {
-		if n, err := b.io.Read(signal); n != 9 || err != nil {
+		signal, body, consumed, err := readRecord(reader)
+		if err == io.EOF {
+			break
+		}
+		if err == errCorruptedRecord && position+consumed < stat.Size() {
+			// a bad length or checksum on the last record is a torn write,
+			// any other is genuine corruption. A torn record of valid
+			// length ends past the end of file. Actions are not synced, so
+			// after a power loss the tail may also read back as zeros.
+			zeros, err := zeroed(file, position+consumed, stat.Size())
+			if err != nil {
+				file.Close()
+				return nil, err
+			}
+			if !zeros {
+				file.Close()
+				return nil, fmt.Errorf("chain file corrupted at offset %v: %v", position, errCorruptedRecord)
+			}
+		}
+		if err != nil {
+			log.Printf("chain file: %v at offset %v, dropping %v bytes (%v actions of block %v)", err, position, stat.Size()-sealed, len(b.current.data), len(b.blocks)-1)
+			b.current.data = make([][]byte, 0)
+			if err := file.Truncate(sealed); err != nil {
+				file.Close()
+				return nil, err
+			}
+			position = sealed
 			break
 		}
-		number, _ := util.ParseUint64(signal, 1)
-		if signal[0] == blocksignal {
-			if number == 0 {
-				if len(b.blocks) != 1 {
-					log.Fatal("blockchain file corrupted: genesis block elsewhere")
-				}
-			} else if number != uint64(len(b.blocks)) {
-				log.Fatal("blockchain file corrupted: block out of order")
-			} else {
-				b.NewBlock(nil)
+		position += consumed
+		if signal == blocksignal {
+			header, n := social.ParseBlockHeader(body, 0)
+			if header == nil || n != len(body) {
+				file.Close()
+				return nil, fmt.Errorf("chain file corrupted: invalid block header at offset %v", position)
 			}
-		} else if signal[0] == actionsignal {
-			data := make([]byte, int(number))
-			if n, _ := b.io.Read(data); n != len(data) {
-				log.Fatal("blockchain file corrupted: incomplete action")
+			epoch := uint64(len(b.blocks) - 1)
+			if err := header.Verify(epoch, b.parent, b.current.data, gateway); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("chain file corrupted: block %v: %v", epoch, err)
 			}
-			b.NewAction(data, nil)
+			b.seal(header)
+			sealed = position
+		} else if signal == actionsignal {
+			b.NewAction(body, nil)
 		} else {
-			log.Fatal("blockchain file corrupted: invalid data type")
+			file.Close()
+			return nil, fmt.Errorf("chain file corrupted: invalid data type at offset %v", position)
 		}
 	}
-	return b, true
+	if _, err := file.Seek(position, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return b, nil
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// writeTestChain writes a chain with two sealed blocks and an open block
+// with two actions. Returns the file contents, the offsets at which records
+// end and the offsets at which blocks end.
+func writeTestChain(t *testing.T, path string, credentials crypto.PrivateKey) ([]byte, map[int]bool, []int) {
+	chain, err := openBlockchain(path, credentials)
+	if err != nil {
+		t.Fatalf("could not create chain: %v", err)
+	}
+	pool := make(ConnectionPool)
+	records := map[int]bool{0: true}
+	blocks := make([]int, 0)
+	offset := 0
+	action := func(data string) {
+		chain.NewAction([]byte(data), pool)
+		offset += len(record(actionsignal, []byte(data)))
+		records[offset] = true
+	}
+	seal := func() {
+		chain.NewBlock(crypto.ZeroHash, pool)
+		offset += len(record(blocksignal, chain.blocks[len(chain.blocks)-2].header.Serialize()))
+		records[offset] = true
+		blocks = append(blocks, offset)
+	}
+	action("first action")
+	action("second action")
+	seal()
+	action("third action")
+	seal()
+	action("fourth action")
+	action("fifth action")
+	chain.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != offset {
+		t.Fatalf("unexpected chain file size: %v != %v", len(data), offset)
+	}
+	return data, records, blocks
+}
+
+func TestChainRecovery(t *testing.T) {
+	_, credentials := crypto.RandomAsymetricKey()
+	dir := t.TempDir()
+	data, records, blocks := writeTestChain(t, filepath.Join(dir, "full.dat"), credentials)
+	path := filepath.Join(dir, "cut.dat")
+	// recovered checks that the chain file with the given contents is recovered
+	// with the blocks up to cut and truncated to end
+	recovered := func(name string, contents []byte, cut, end int) {
+		t.Helper()
+		if err := os.WriteFile(path, contents, 0666); err != nil {
+			t.Fatal(err)
+		}
+		chain, err := openBlockchain(path, credentials)
+		if err != nil {
+			t.Fatalf("%v: could not recover chain: %v", name, err)
+		}
+		sealed := 0
+		for _, offset := range blocks {
+			if offset <= cut {
+				sealed++
+			}
+		}
+		if len(chain.blocks) != sealed+1 {
+			t.Errorf("%v: expected %v sealed blocks, got %v", name, sealed, len(chain.blocks)-1)
+		}
+		if stat, _ := os.Stat(path); stat.Size() != int64(end) {
+			t.Errorf("%v: expected file truncated to %v, got %v", name, end, stat.Size())
+		}
+		// chain must keep working after recovery
+		chain.NewAction([]byte("after recovery"), make(ConnectionPool))
+		chain.NewBlock(crypto.ZeroHash, make(ConnectionPool))
+		chain.Close()
+		reopened, err := openBlockchain(path, credentials)
+		if err != nil {
+			t.Fatalf("%v: could not reopen recovered chain: %v", name, err)
+		}
+		if len(reopened.blocks) != sealed+2 {
+			t.Errorf("%v: block after recovery lost", name)
+		}
+		reopened.Close()
+	}
+	// lastBlock returns the end of the last block sealed up to offset
+	lastBlock := func(offset int) int {
+		end := 0
+		for _, block := range blocks {
+			if block <= offset {
+				end = block
+			}
+		}
+		return end
+	}
+	for cut := 0; cut <= len(data); cut++ {
+		end := lastBlock(cut)
+		if records[cut] {
+			end = cut
+		}
+		recovered(fmt.Sprintf("cut at %v", cut), data[:cut], cut, end)
+	}
+	// the tail after a power loss reads back as zeros up to the end of file
+	for cut := 0; cut < len(data); cut++ {
+		contents := make([]byte, len(data))
+		copy(contents, data[:cut])
+		// zeros of the chain itself are not lost
+		kept := cut
+		for kept < len(data) && data[kept] == 0 {
+			kept++
+		}
+		end := lastBlock(kept)
+		if kept == len(data) {
+			end = kept
+		}
+		recovered(fmt.Sprintf("zeros from %v", cut), contents, kept, end)
+	}
+}
+
+func TestChainCorruption(t *testing.T) {
+	_, credentials := crypto.RandomAsymetricKey()
+	dir := t.TempDir()
+	data, _, _ := writeTestChain(t, filepath.Join(dir, "full.dat"), credentials)
+	// flip a byte of the first action
+	data[6] ^= 1
+	path := filepath.Join(dir, "corrupted.dat")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openBlockchain(path, credentials); err == nil {
+		t.Error("corrupted chain file accepted")
+	}
+	// corrupted lengths before sealed blocks do not truncate the chain
+	for _, length := range []uint32{0, maxRecordSize + 1, 0xffffffff} {
+		data, _, _ := writeTestChain(t, filepath.Join(t.TempDir(), "full.dat"), credentials)
+		prefix := make([]byte, 0, 4)
+		util.PutUint32(length, &prefix)
+		copy(data, prefix)
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := openBlockchain(path, credentials); err == nil {
+			t.Errorf("chain file with record length %v accepted", length)
+		}
+		if stat, _ := os.Stat(path); stat.Size() != int64(len(data)) {
+			t.Errorf("chain file with record length %v truncated", length)
+		}
+	}
+	// chain signed by another gateway
+	_, other := crypto.RandomAsymetricKey()
+	data, _, _ = writeTestChain(t, filepath.Join(dir, "other.dat"), other)
+	path = filepath.Join(dir, "forged.dat")
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openBlockchain(path, credentials); err == nil {
+		t.Error("chain signed by another gateway accepted")
+	}
+}
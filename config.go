@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Member is indexed for its personal pages from the start.
+type Member struct {
+	Handle string `json:"handle"`
+	Token  string `json:"token"` // hex encoded
+}
+
+type Config struct {
+	Gateway      string   `json:"gateway"` // address of the gateway
+	GatewayToken string   `json:"token"`   // hex encoded token of the gateway
+	Port         int      `json:"port"`
+	URL          string   `json:"url"`  // public address for emailed links
+	DataDir      string   `json:"data"` // cookies, passwords and snapshot files
+	KeyFile      string   `json:"key"`  // attorney key, created if missing
+	Members      []Member `json:"members"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Gateway: "localhost:4100",
+		Port:    3000,
+		DataDir: ".",
+		KeyFile: "attorney.key",
+	}
+}
+
+func (c *Config) CookiesPath() string {
+	return filepath.Join(c.DataDir, "cookies.dat")
+}
+
+func (c *Config) PasswordsPath() string {
+	return filepath.Join(c.DataDir, "passwords.dat")
+}
+
+func (c *Config) SnapshotPath() string {
+	return filepath.Join(c.DataDir, "state.dat")
+}
+
+// LoadConfig reads the config file given by -config (if any) and overrides
+// it with the flags explicitly set. Indexed members are only read from the
+// config file.
+func LoadConfig(args []string) (*Config, error) {
+	config := defaultConfig()
+	flags := flag.NewFlagSet("synergy", flag.ContinueOnError)
+	path := flags.String("config", "", "json config file")
+	gateway := flags.String("gateway", config.Gateway, "address of the gateway")
+	token := flags.String("token", config.GatewayToken, "hex encoded token of the gateway")
+	port := flags.Int("port", config.Port, "http port")
+	publicURL := flags.String("url", config.URL, "public address of the interface for emailed links")
+	data := flags.String("data", config.DataDir, "directory of cookies, passwords and snapshot files")
+	key := flags.String("key", config.KeyFile, "attorney key file (created if missing)")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if *path != "" {
+		data, err := os.ReadFile(*path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("invalid config file: %v", err)
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "gateway":
+			config.Gateway = *gateway
+		case "token":
+			config.GatewayToken = *token
+		case "port":
+			config.Port = *port
+		case "url":
+			config.URL = *publicURL
+		case "data":
+			config.DataDir = *data
+		case "key":
+			config.KeyFile = *key
+		}
+	})
+	if config.GatewayToken == "" {
+		return nil, errors.New("gateway token is required")
+	}
+	return config, nil
+}
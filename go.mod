@@ -7,9 +7,10 @@ replace github.com/lienkolabs/breeze => ../breeze
 require (
 	github.com/gomarkdown/markdown v0.0.0-20230922112808-5421fefb8386
 	github.com/lienkolabs/breeze v0.0.0-00010101000000-000000000000
+	golang.org/x/crypto v0.11.0
 )
 
 require (
-	golang.org/x/sys v0.9.0 // indirect
-	golang.org/x/term v0.9.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/term v0.10.0 // indirect
 )
@@ -1,23 +1,37 @@
 package main
 
 import (
-	"fmt"
+	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
 func main() {
+	config, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
 	envs := os.Environ()
 	var emailPassword string
 	for _, env := range envs {
 		if strings.HasPrefix(env, "FREEHANDLE_SECRET=") {
 			emailPassword, _ = strings.CutPrefix(env, "FREEHANDLE_SECRET=")
-			fmt.Println(emailPassword)
 		}
 	}
 
-	server3(emailPassword)
-	for true {
-
+	node, err := NewNode(config, emailPassword)
+	if err != nil {
+		log.Fatal(err)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case sig := <-signals:
+		log.Printf("%v: shutting down", sig)
+	case err := <-node.finalize:
+		log.Printf("server stopped: %v", err)
 	}
+	node.Stop()
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social"
+	"github.com/lienkolabs/synergy/social/actions"
+	"github.com/lienkolabs/synergy/social/index"
+)
+
+func TestRestartFromSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.dat")
+	author, authorKey := crypto.RandomAsymetricKey()
+	member, memberKey := crypto.RandomAsymetricKey()
+	newIndex := func() *index.Index {
+		indexer := index.NewIndex()
+		indexer.AddMemberToIndex(author, "author")
+		indexer.AddMemberToIndex(member, "member")
+		return indexer
+	}
+
+	indexer := newIndex()
+	s, parent := openState(path, indexer)
+	if s.Epoch != 0 || !parent.Equal(crypto.ZeroHash) {
+		t.Fatal("state without snapshot not started from genesis")
+	}
+	apply := func(action actions.Action, key crypto.PrivateKey) {
+		if err := s.Action(actions.Dress(action.Serialize(), s.Epoch, action.Authored(), key, key, 0)); err != nil {
+			t.Fatalf("could not apply %T: %v", action, err)
+		}
+	}
+	apply(&actions.Signin{Author: author, Handle: "author"}, authorKey)
+	apply(&actions.Signin{Author: member, Handle: "member"}, memberKey)
+	s.NextBlock()
+	apply(&actions.CreateCollective{Epoch: 1, Author: author, Name: "synergy", Policy: actions.Policy{Majority: 50, SuperMajority: 75}}, authorKey)
+	apply(&actions.CreateBoard{Epoch: 1, Author: author, OnBehalfOf: "synergy", Name: "news", Keywords: []string{"news"}, PinMajority: 1}, authorKey)
+	apply(&actions.RequestMembership{Epoch: 1, Author: member, Collective: "synergy", Include: true}, memberKey)
+	s.NextBlock()
+
+	parent = crypto.Hasher([]byte("block 1"))
+	if err := social.WriteSnapshot(path, s.Epoch, parent, s.Snapshot()); err != nil {
+		t.Fatalf("could not write snapshot: %v", err)
+	}
+
+	restarted := newIndex()
+	resumed, resumedParent := openState(path, restarted)
+	if resumed.Epoch != s.Epoch || !resumedParent.Equal(parent) {
+		t.Fatalf("state resumed at epoch %v, expected %v", resumed.Epoch, s.Epoch)
+	}
+	if resumed.NextBlock() != s.NextBlock() {
+		t.Error("resumed state diverges from the original one")
+	}
+	for _, token := range []crypto.Token{author, member} {
+		if got, want := restarted.CollectivesOnMember(token), indexer.CollectivesOnMember(token); !reflect.DeepEqual(got, want) {
+			t.Errorf("collectives on member: got %v, expected %v", got, want)
+		}
+		if got, want := restarted.BoardsOnMember(token), indexer.BoardsOnMember(token); !reflect.DeepEqual(got, want) {
+			t.Errorf("boards on member: got %v, expected %v", got, want)
+		}
+		if got, want := restarted.GetVotes(token), indexer.GetVotes(token); !reflect.DeepEqual(got, want) {
+			t.Errorf("open votes: got %v, expected %v", got, want)
+		}
+	}
+	if len(restarted.GetVotes(author)) != 1 {
+		t.Error("membership request not open to the vote of the collective after restart")
+	}
+	collective := resumed.Collectives[crypto.Hasher([]byte("synergy"))]
+	if boards := restarted.BoardsOnCollective(collective); len(boards) != 1 || boards[0].Name != "news" {
+		t.Errorf("boards on collective not rebuilt: %v", boards)
+	}
+}
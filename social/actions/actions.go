@@ -24,6 +24,12 @@ const (
 	AUpdateEvent
 	ACheckinEvent
 	AGreetCheckinEvent
+	AGrantPowerOfAttorney
+	ARevokePowerOfAttorney
+	ARotateKey
+	AChangeHandle
+	AComment
+	AWithdraw
 	AUnknown
 )
 
@@ -0,0 +1,112 @@
+package actions
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// GrantPowerOfAttorney authorizes the Attorney token to sign synergy actions
+// on behalf of the Author.
+type GrantPowerOfAttorney struct {
+	Epoch    uint64
+	Author   crypto.Token
+	Reasons  string
+	Attorney crypto.Token
+}
+
+func (c *GrantPowerOfAttorney) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *GrantPowerOfAttorney) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta o membro que concede a procuracao
+func (c *GrantPowerOfAttorney) Affected() []crypto.Hash {
+	return []crypto.Hash{crypto.HashToken(c.Author)}
+}
+
+func (c *GrantPowerOfAttorney) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *GrantPowerOfAttorney) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(AGrantPowerOfAttorney, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutToken(c.Attorney, &bytes)
+	return bytes
+}
+
+func ParseGrantPowerOfAttorney(grant []byte) *GrantPowerOfAttorney {
+	action := GrantPowerOfAttorney{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(grant, position)
+	action.Author, position = util.ParseToken(grant, position)
+	if grant[position] != AGrantPowerOfAttorney {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(grant, position)
+	action.Attorney, position = util.ParseToken(grant, position)
+	if position != len(grant) {
+		return nil
+	}
+	return &action
+}
+
+// RevokePowerOfAttorney withdraws a power of attorney previously granted by
+// the Author.
+type RevokePowerOfAttorney struct {
+	Epoch    uint64
+	Author   crypto.Token
+	Reasons  string
+	Attorney crypto.Token
+}
+
+func (c *RevokePowerOfAttorney) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *RevokePowerOfAttorney) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta o membro que revoga a procuracao
+func (c *RevokePowerOfAttorney) Affected() []crypto.Hash {
+	return []crypto.Hash{crypto.HashToken(c.Author)}
+}
+
+func (c *RevokePowerOfAttorney) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *RevokePowerOfAttorney) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(ARevokePowerOfAttorney, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutToken(c.Attorney, &bytes)
+	return bytes
+}
+
+func ParseRevokePowerOfAttorney(revoke []byte) *RevokePowerOfAttorney {
+	action := RevokePowerOfAttorney{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(revoke, position)
+	action.Author, position = util.ParseToken(revoke, position)
+	if revoke[position] != ARevokePowerOfAttorney {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(revoke, position)
+	action.Attorney, position = util.ParseToken(revoke, position)
+	if position != len(revoke) {
+		return nil
+	}
+	return &action
+}
@@ -0,0 +1,46 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+var (
+	grant = &GrantPowerOfAttorney{
+		Epoch:    30,
+		Author:   crypto.Token{},
+		Reasons:  "grant power of attorney test",
+		Attorney: crypto.Token{},
+	}
+
+	revoke = &RevokePowerOfAttorney{
+		Epoch:    31,
+		Author:   crypto.Token{},
+		Reasons:  "revoke power of attorney test",
+		Attorney: crypto.Token{},
+	}
+)
+
+func TestGrantPowerOfAttorney(t *testing.T) {
+	g := ParseGrantPowerOfAttorney(grant.Serialize())
+	if g == nil {
+		t.Error("Could not parse actions GrantPowerOfAttorney")
+		return
+	}
+	if !reflect.DeepEqual(g, grant) {
+		t.Error("Parse and Serialize not working for actions GrantPowerOfAttorney")
+	}
+}
+
+func TestRevokePowerOfAttorney(t *testing.T) {
+	r := ParseRevokePowerOfAttorney(revoke.Serialize())
+	if r == nil {
+		t.Error("Could not parse actions RevokePowerOfAttorney")
+		return
+	}
+	if !reflect.DeepEqual(r, revoke) {
+		t.Error("Parse and Serialize not working for actions RevokePowerOfAttorney")
+	}
+}
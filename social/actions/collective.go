@@ -40,6 +40,11 @@ func (c *CreateCollective) Serialize() []byte {
 	util.PutString(c.Name, &bytes)
 	util.PutString(c.Description, &bytes)
 	PutPolicy(c.Policy, &bytes)
+	// rules added after the format was set are trailing and optional
+	if c.Policy.HasRules() {
+		util.PutByte(1, &bytes)
+		PutPolicyRules(c.Policy, &bytes)
+	}
 	return bytes
 }
 
@@ -56,6 +61,12 @@ func ParseCreateCollective(create []byte) *CreateCollective {
 	action.Name, position = util.ParseString(create, position)
 	action.Description, position = util.ParseString(create, position)
 	action.Policy, position = ParsePolicy(create, position)
+	if position < len(create) {
+		if create[position] != 1 {
+			return nil
+		}
+		position = ParsePolicyRules(create, position+1, &action.Policy)
+	}
 	if position != len(create) {
 		return nil
 	}
@@ -63,13 +74,17 @@ func ParseCreateCollective(create []byte) *CreateCollective {
 }
 
 type UpdateCollective struct {
-	Epoch         uint64
-	Author        crypto.Token
-	Reasons       string
-	OnBehalfOf    string
-	Description   *string
-	Majority      *byte
-	SuperMajority *byte
+	Epoch          uint64
+	Author         crypto.Token
+	Reasons        string
+	OnBehalfOf     string
+	Description    *string
+	Majority       *byte
+	SuperMajority  *byte
+	Quorum         *byte
+	AcceptOnExpiry *bool
+	VotingWindow   map[byte]uint64 // substitui so os tipos presentes, 0 volta ao padrao
+	Strategy       *Strategy
 }
 
 func (c *UpdateCollective) Reasoning() string {
@@ -114,6 +129,30 @@ func (c *UpdateCollective) Serialize() []byte {
 	} else {
 		util.PutByte(0, &bytes) // there is no policy
 	}
+	// rules added after the format was set are trailing and optional
+	if c.Quorum == nil && c.AcceptOnExpiry == nil && len(c.VotingWindow) == 0 && c.Strategy == nil {
+		return bytes
+	}
+	util.PutByte(1, &bytes)
+	if c.Quorum != nil {
+		util.PutByte(1, &bytes)
+		util.PutByte(*c.Quorum, &bytes)
+	} else {
+		util.PutByte(0, &bytes)
+	}
+	if c.AcceptOnExpiry != nil {
+		util.PutByte(1, &bytes)
+		util.PutBool(*c.AcceptOnExpiry, &bytes)
+	} else {
+		util.PutByte(0, &bytes)
+	}
+	PutVotingWindow(c.VotingWindow, &bytes)
+	if c.Strategy != nil {
+		util.PutByte(1, &bytes)
+		PutStrategy(*c.Strategy, &bytes)
+	} else {
+		util.PutByte(0, &bytes)
+	}
 	return bytes
 }
 
@@ -159,6 +198,50 @@ func ParseUpdateCollective(update []byte) *UpdateCollective {
 	} else {
 		position += 1
 	}
+	if position == len(update) {
+		return &action
+	}
+	if update[position] != 1 {
+		return nil
+	}
+	position += 1
+	if position >= len(update) {
+		return nil
+	}
+	if update[position] == 1 {
+		var quorum byte
+		quorum, position = util.ParseByte(update, position+1)
+		action.Quorum = &quorum
+	} else if update[position] != 0 {
+		return nil
+	} else {
+		position += 1
+	}
+	if position >= len(update) {
+		return nil
+	}
+	if update[position] == 1 {
+		var accept bool
+		accept, position = util.ParseBool(update, position+1)
+		action.AcceptOnExpiry = &accept
+	} else if update[position] != 0 {
+		return nil
+	} else {
+		position += 1
+	}
+	action.VotingWindow, position = ParseVotingWindow(update, position)
+	if position >= len(update) {
+		return nil
+	}
+	if update[position] == 1 {
+		var strategy Strategy
+		strategy, position = ParseStrategy(update, position+1)
+		action.Strategy = &strategy
+	} else if update[position] != 0 {
+		return nil
+	} else {
+		position += 1
+	}
 	if position != len(update) {
 		return nil
 	}
@@ -5,12 +5,20 @@ import (
 	"testing"
 
 	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
 )
 
 var (
 	policy = &Policy{
-		Majority:      10,
-		SuperMajority: 20,
+		Majority:       10,
+		SuperMajority:  20,
+		Quorum:         30,
+		AcceptOnExpiry: true,
+		VotingWindow:   map[byte]uint64{ARequestMembership: 100, AUpdateCollective: 200},
+		Strategy: Strategy{
+			Kind:    WeightedStrategy,
+			Weights: map[crypto.Token]int{crypto.Token{}: 3, crypto.Token{1}: 1},
+		},
 	}
 
 	collective = &CreateCollective{
@@ -68,6 +76,19 @@ func TestUpdateCollective(t *testing.T) {
 	if !reflect.DeepEqual(u, uCollective) {
 		t.Error("Parse and Serialize not working for actions UpdateCollective")
 	}
+	quorum, accept := byte(40), false
+	uPolicy := &UpdateCollective{
+		Epoch:          15,
+		Author:         crypto.Token{},
+		OnBehalfOf:     "first_collective",
+		Quorum:         &quorum,
+		AcceptOnExpiry: &accept,
+		VotingWindow:   map[byte]uint64{ARemoveMember: 0},
+		Strategy:       &Strategy{Kind: ReviewersStrategy, Roles: []crypto.Token{{1}, {2}}, Required: 1},
+	}
+	if u := ParseUpdateCollective(uPolicy.Serialize()); !reflect.DeepEqual(u, uPolicy) {
+		t.Error("Parse and Serialize not working for actions UpdateCollective policy")
+	}
 }
 
 func TestRequestMembership(t *testing.T) {
@@ -91,3 +112,32 @@ func TestRemoveMember(t *testing.T) {
 		t.Error("Parse and Serialize not working for actions RemoveMember")
 	}
 }
+
+// TestCollectiveWithoutRules parses collectives serialized before quorum,
+// expiry, voting windows and strategies were added to the policy.
+func TestCollectiveWithoutRules(t *testing.T) {
+	create := &CreateCollective{Epoch: 14, Author: crypto.Token{}, Name: "old_collective", Policy: Policy{Majority: 50, SuperMajority: 75}}
+	bytes := make([]byte, 0)
+	util.PutUint64(create.Epoch, &bytes)
+	util.PutToken(create.Author, &bytes)
+	util.PutByte(ACreateCollective, &bytes)
+	util.PutString(create.Reasons, &bytes)
+	util.PutString(create.Name, &bytes)
+	util.PutString(create.Description, &bytes)
+	bytes = append(bytes, 50, 75)
+	if !reflect.DeepEqual(ParseCreateCollective(bytes), create) || !reflect.DeepEqual(create.Serialize(), bytes) {
+		t.Error("CreateCollective without rules not compatible")
+	}
+	majority := byte(60)
+	update := &UpdateCollective{Epoch: 15, Author: crypto.Token{}, OnBehalfOf: "old_collective", Majority: &majority}
+	bytes = bytes[:0]
+	util.PutUint64(update.Epoch, &bytes)
+	util.PutToken(update.Author, &bytes)
+	util.PutByte(AUpdateCollective, &bytes)
+	util.PutString(update.Reasons, &bytes)
+	util.PutString(update.OnBehalfOf, &bytes)
+	bytes = append(bytes, 0, 1, 60, 0)
+	if !reflect.DeepEqual(ParseUpdateCollective(bytes), update) || !reflect.DeepEqual(update.Serialize(), bytes) {
+		t.Error("UpdateCollective without rules not compatible")
+	}
+}
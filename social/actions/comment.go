@@ -0,0 +1,69 @@
+package actions
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// Comment on a draft, edit or release identified by Object. Parent is the
+// comment it replies to, zero for a new thread. A comment on behalf of a
+// collective is published once the collective consents.
+type Comment struct {
+	Epoch      uint64
+	Author     crypto.Token
+	Reasons    string
+	OnBehalfOf string
+	Object     crypto.Hash
+	Parent     crypto.Hash
+	Content    string
+}
+
+func (c *Comment) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *Comment) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta o objeto comentado
+func (c *Comment) Affected() []crypto.Hash {
+	return []crypto.Hash{c.Object}
+}
+
+func (c *Comment) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *Comment) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(AComment, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutString(c.OnBehalfOf, &bytes)
+	util.PutHash(c.Object, &bytes)
+	util.PutHash(c.Parent, &bytes)
+	util.PutString(c.Content, &bytes)
+	return bytes
+}
+
+func ParseComment(comment []byte) *Comment {
+	action := Comment{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(comment, position)
+	action.Author, position = util.ParseToken(comment, position)
+	if comment[position] != AComment {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(comment, position)
+	action.OnBehalfOf, position = util.ParseString(comment, position)
+	action.Object, position = util.ParseHash(comment, position)
+	action.Parent, position = util.ParseHash(comment, position)
+	action.Content, position = util.ParseString(comment, position)
+	if position != len(comment) {
+		return nil
+	}
+	return &action
+}
@@ -0,0 +1,29 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+var comment = &Comment{
+	Epoch:      34,
+	Author:     crypto.Token{},
+	Reasons:    "comment test",
+	OnBehalfOf: "collective",
+	Object:     crypto.Hasher([]byte("draft")),
+	Parent:     crypto.Hasher([]byte("parent")),
+	Content:    "comment content",
+}
+
+func TestComment(t *testing.T) {
+	c := ParseComment(comment.Serialize())
+	if c == nil {
+		t.Error("Could not parse actions Comment")
+		return
+	}
+	if !reflect.DeepEqual(c, comment) {
+		t.Error("Parse and Serialize not working for actions Comment")
+	}
+}
@@ -51,12 +51,7 @@ func (c *Draft) Serialize() []byte {
 	util.PutString(c.Reasons, &bytes)
 	util.PutString(c.OnBehalfOf, &bytes)
 	PutTokenArray(c.CoAuthors, &bytes)
-	if c.Policy != nil {
-		util.PutByte(1, &bytes) // there is a policy
-		PutPolicy(*c.Policy, &bytes)
-	} else {
-		util.PutByte(0, &bytes) // there is no policy
-	}
+	PutOptionalPolicy(c.Policy, &bytes)
 	util.PutString(c.Title, &bytes)
 	PutKeywords(c.Keywords, &bytes)
 	util.PutString(c.Description, &bytes)
@@ -81,12 +76,16 @@ func ParseDraft(create []byte) *Draft {
 	action.Reasons, position = util.ParseString(create, position)
 	action.OnBehalfOf, position = util.ParseString(create, position)
 	action.CoAuthors, position = ParseTokenArray(create, position)
-	if create[position] == 1 {
+	if create[position] == basePolicy || create[position] == policyWithRules {
 		var policy Policy
+		rules := create[position] == policyWithRules
 		position += 1
 		policy, position = ParsePolicy(create, position)
+		if rules {
+			position = ParsePolicyRules(create, position, &policy)
+		}
 		action.Policy = &policy
-	} else if create[position] != 0 {
+	} else if create[position] != noPolicy {
 		return nil
 	} else {
 		position += 1
@@ -0,0 +1,151 @@
+package actions
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// Synergy actions travel on the breeze network dressed in an envelope
+//
+//	version (1 byte) | epoch | author | protocol (4 bytes) | synergy action |
+//	attorney | attorney signature | wallet | fee | wallet signature
+//
+// The attorney (which is the author itself when no power of attorney is
+// used) signs everything up to its own token. The wallet signs everything
+// up to the fee.
+
+const (
+	envelopeHeadSize = 1 + 8 + crypto.TokenSize + 4
+	envelopeTailSize = 2*crypto.SignatureSize + 2*crypto.TokenSize + 8
+)
+
+// synergy protocol code on the axé social protocol (axe void synergy)
+var synergyProtocol = [4]byte{0, 1, 0, 0}
+
+var (
+	ErrEnvelopeTooShort         = errors.New("envelope too short")
+	ErrNotSynergyProtocol       = errors.New("envelope is not a synergy action")
+	ErrInvalidAttorneySignature = errors.New("invalid author or attorney signature")
+	ErrInvalidWalletSignature   = errors.New("invalid wallet signature")
+)
+
+type Envelope struct {
+	Epoch    uint64
+	Author   crypto.Token
+	Attorney crypto.Token
+	Wallet   crypto.Token
+	Fee      uint64
+	Action   []byte // undressed synergy action
+}
+
+// Dress wraps the serialized synergy action with the breeze envelope. The
+// epoch and author of the envelope take precedence over those serialized
+// within the action.
+func Dress(action []byte, epoch uint64, author crypto.Token, attorney, wallet crypto.PrivateKey, fee uint64) []byte {
+	dress := Unsigned(action, epoch, author, attorney.PublicKey())
+	util.PutSignature(attorney.Sign(dress), &dress)
+	return PayFee(dress, wallet, fee)
+}
+
+// Unsigned returns the envelope of the serialized synergy action up to the
+// attorney token. These are the bytes the attorney signs. With self-custody
+// the author signs them with its own key (attorney = author) and appends the
+// signature.
+func Unsigned(action []byte, epoch uint64, author, attorney crypto.Token) []byte {
+	dress := []byte{0}
+	util.PutUint64(epoch, &dress)
+	util.PutToken(author, &dress)
+	dress = append(dress, synergyProtocol[:]...)
+	dress = append(dress, action[8+crypto.TokenSize:]...)
+	util.PutToken(attorney, &dress)
+	return dress
+}
+
+// PayFee completes an envelope signed by the attorney with the wallet that
+// pays the fee.
+func PayFee(signed []byte, wallet crypto.PrivateKey, fee uint64) []byte {
+	dress := make([]byte, 0, len(signed)+crypto.TokenSize+8+crypto.SignatureSize)
+	dress = append(dress, signed...)
+	util.PutToken(wallet.PublicKey(), &dress)
+	util.PutUint64(fee, &dress)
+	util.PutSignature(wallet.Sign(dress), &dress)
+	return dress
+}
+
+// VerifyAttorney checks the attorney signature of an envelope that is not
+// yet paid by a wallet and returns it with the undressed synergy action.
+// Wallet and Fee are left empty.
+func VerifyAttorney(signed []byte) (*Envelope, error) {
+	return verifyAttorney(signed, len(signed)-crypto.TokenSize-crypto.SignatureSize)
+}
+
+// verifyAttorney parses the envelope head, the action up to end and the
+// attorney token and signature after it.
+func verifyAttorney(data []byte, end int) (*Envelope, error) {
+	if end < envelopeHeadSize+1 {
+		return nil, ErrEnvelopeTooShort
+	}
+	var protocol [4]byte
+	copy(protocol[:], data[1+8+crypto.TokenSize:envelopeHeadSize])
+	if protocol != synergyProtocol {
+		return nil, ErrNotSynergyProtocol
+	}
+	envelope := Envelope{}
+	position := 1
+	envelope.Epoch, position = util.ParseUint64(data, position)
+	envelope.Author, _ = util.ParseToken(data, position)
+
+	attorneySigned := end + crypto.TokenSize
+	envelope.Attorney, position = util.ParseToken(data, end)
+	var signature crypto.Signature
+	signature, _ = util.ParseSignature(data, position)
+	if !envelope.Attorney.Verify(data[:attorneySigned], signature) {
+		return nil, ErrInvalidAttorneySignature
+	}
+
+	envelope.Action = undressed(data, end)
+	return &envelope, nil
+}
+
+// undressed rebuilds the synergy action from the envelope head and the
+// action bytes up to end.
+func undressed(data []byte, end int) []byte {
+	action := make([]byte, 0, end-envelopeHeadSize+8+crypto.TokenSize)
+	action = append(action, data[1:1+8+crypto.TokenSize]...)
+	return append(action, data[envelopeHeadSize:end]...)
+}
+
+// ActionHash returns the hash of the undressed synergy action of a dressed
+// action without checking its signatures. It is the hash under which the
+// state incorporates or rejects the action.
+func ActionHash(data []byte) (crypto.Hash, bool) {
+	if len(data) < envelopeHeadSize+1+envelopeTailSize {
+		return crypto.ZeroHash, false
+	}
+	return crypto.Hasher(undressed(data, len(data)-envelopeTailSize)), true
+}
+
+// ParseEnvelope checks the attorney and wallet signatures of a dressed action
+// and returns the envelope with the undressed synergy action. It does not
+// check if the attorney is authorized to act on behalf of the author, this
+// is a matter for the state.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if len(data) < envelopeHeadSize+1+envelopeTailSize {
+		return nil, ErrEnvelopeTooShort
+	}
+	envelope, err := verifyAttorney(data, len(data)-envelopeTailSize)
+	if err != nil {
+		return nil, err
+	}
+	position := len(data) - crypto.TokenSize - 8 - crypto.SignatureSize
+	envelope.Wallet, position = util.ParseToken(data, position)
+	envelope.Fee, position = util.ParseUint64(data, position)
+	walletSigned := position
+	signature, _ := util.ParseSignature(data, position)
+	if !envelope.Wallet.Verify(data[:walletSigned], signature) {
+		return nil, ErrInvalidWalletSignature
+	}
+	return envelope, nil
+}
@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+func TestEnvelope(t *testing.T) {
+	author, authorKey := crypto.RandomAsymetricKey()
+	_, walletKey := crypto.RandomAsymetricKey()
+	signin := &Signin{
+		Epoch:   10,
+		Author:  author,
+		Reasons: "envelope test",
+		Handle:  "first_handle",
+	}
+	dressed := Dress(signin.Serialize(), 10, author, authorKey, walletKey, 0)
+	envelope, err := ParseEnvelope(dressed)
+	if err != nil {
+		t.Fatalf("Could not parse envelope: %v", err)
+	}
+	if !envelope.Author.Equal(author) || !envelope.Attorney.Equal(author) || !envelope.Wallet.Equal(walletKey.PublicKey()) {
+		t.Error("Envelope tokens not working")
+	}
+	if !bytes.Equal(envelope.Action, signin.Serialize()) {
+		t.Error("Dress and ParseEnvelope not working for actions Signin")
+	}
+	if hash, ok := ActionHash(dressed); !ok || !hash.Equal(crypto.Hasher(envelope.Action)) {
+		t.Error("ActionHash differs from the hash of the undressed action")
+	}
+	// tamper with the synergy action
+	tampered := append([]byte{}, dressed...)
+	tampered[envelopeHeadSize+2] ^= 1
+	if _, err := ParseEnvelope(tampered); !errors.Is(err, ErrInvalidAttorneySignature) {
+		t.Errorf("Tampered action accepted: %v", err)
+	}
+	// tamper with the fee
+	tampered = append([]byte{}, dressed...)
+	tampered[len(tampered)-crypto.SignatureSize-1] ^= 1
+	if _, err := ParseEnvelope(tampered); !errors.Is(err, ErrInvalidWalletSignature) {
+		t.Errorf("Tampered fee accepted: %v", err)
+	}
+	// forge the author
+	forger, _ := crypto.RandomAsymetricKey()
+	forged := append([]byte{}, dressed...)
+	copy(forged[1+8:], forger[:])
+	if _, err := ParseEnvelope(forged); !errors.Is(err, ErrInvalidAttorneySignature) {
+		t.Errorf("Forged author accepted: %v", err)
+	}
+	if _, err := ParseEnvelope(dressed[:envelopeHeadSize]); !errors.Is(err, ErrEnvelopeTooShort) {
+		t.Errorf("Truncated envelope accepted: %v", err)
+	}
+}
+
+func TestSelfCustody(t *testing.T) {
+	author, authorKey := crypto.RandomAsymetricKey()
+	_, walletKey := crypto.RandomAsymetricKey()
+	react := &React{Epoch: 3, Author: author, Hash: crypto.Hasher([]byte("draft")), Reaction: 1}
+	// signed by the author on the client, paid by the wallet on the server
+	signed := Unsigned(react.Serialize(), 3, author, author)
+	signature := authorKey.Sign(signed)
+	signed = append(signed, signature[:]...)
+	envelope, err := VerifyAttorney(signed)
+	if err != nil {
+		t.Fatalf("Could not verify signed envelope: %v", err)
+	}
+	if !bytes.Equal(envelope.Action, react.Serialize()) || !envelope.Attorney.Equal(author) {
+		t.Error("VerifyAttorney not working for actions React")
+	}
+	dressed := PayFee(signed, walletKey, 0)
+	if !bytes.Equal(dressed, Dress(react.Serialize(), 3, author, authorKey, walletKey, 0)) {
+		t.Error("Unsigned and PayFee differ from Dress")
+	}
+	if _, err := ParseEnvelope(dressed); err != nil {
+		t.Errorf("Could not parse paid envelope: %v", err)
+	}
+	signed[envelopeHeadSize] ^= 1
+	if _, err := VerifyAttorney(signed); !errors.Is(err, ErrInvalidAttorneySignature) {
+		t.Errorf("Tampered signed envelope accepted: %v", err)
+	}
+	if _, err := VerifyAttorney(signed[:envelopeHeadSize]); !errors.Is(err, ErrEnvelopeTooShort) {
+		t.Errorf("Truncated signed envelope accepted: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package actions
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// RotateKey replaces the Author token of a member by NewKey. The action must
+// be signed by the Author key itself. The new key takes over on epoch
+// TimeLock, or immediately if TimeLock is not in the future.
+type RotateKey struct {
+	Epoch    uint64
+	Author   crypto.Token
+	Reasons  string
+	NewKey   crypto.Token
+	TimeLock uint64
+}
+
+func (c *RotateKey) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *RotateKey) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta o membro que troca de chave
+func (c *RotateKey) Affected() []crypto.Hash {
+	return []crypto.Hash{crypto.HashToken(c.Author)}
+}
+
+func (c *RotateKey) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *RotateKey) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(ARotateKey, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutToken(c.NewKey, &bytes)
+	util.PutUint64(c.TimeLock, &bytes)
+	return bytes
+}
+
+func ParseRotateKey(rotate []byte) *RotateKey {
+	action := RotateKey{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(rotate, position)
+	action.Author, position = util.ParseToken(rotate, position)
+	if rotate[position] != ARotateKey {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(rotate, position)
+	action.NewKey, position = util.ParseToken(rotate, position)
+	action.TimeLock, position = util.ParseUint64(rotate, position)
+	if position != len(rotate) {
+		return nil
+	}
+	return &action
+}
+
+// ChangeHandle renames the Author to Handle, which must not be taken by
+// another member.
+type ChangeHandle struct {
+	Epoch   uint64
+	Author  crypto.Token
+	Reasons string
+	Handle  string
+}
+
+func (c *ChangeHandle) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *ChangeHandle) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta o membro que troca de handle
+func (c *ChangeHandle) Affected() []crypto.Hash {
+	return []crypto.Hash{crypto.HashToken(c.Author)}
+}
+
+func (c *ChangeHandle) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *ChangeHandle) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(AChangeHandle, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutString(c.Handle, &bytes)
+	return bytes
+}
+
+func ParseChangeHandle(change []byte) *ChangeHandle {
+	action := ChangeHandle{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(change, position)
+	action.Author, position = util.ParseToken(change, position)
+	if change[position] != AChangeHandle {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(change, position)
+	action.Handle, position = util.ParseString(change, position)
+	if position != len(change) {
+		return nil
+	}
+	return &action
+}
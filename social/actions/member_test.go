@@ -0,0 +1,47 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+var (
+	rotate = &RotateKey{
+		Epoch:    32,
+		Author:   crypto.Token{},
+		Reasons:  "rotate key test",
+		NewKey:   crypto.Token{},
+		TimeLock: 100,
+	}
+
+	changeHandle = &ChangeHandle{
+		Epoch:   33,
+		Author:  crypto.Token{},
+		Reasons: "change handle test",
+		Handle:  "new handle",
+	}
+)
+
+func TestRotateKey(t *testing.T) {
+	r := ParseRotateKey(rotate.Serialize())
+	if r == nil {
+		t.Error("Could not parse actions RotateKey")
+		return
+	}
+	if !reflect.DeepEqual(r, rotate) {
+		t.Error("Parse and Serialize not working for actions RotateKey")
+	}
+}
+
+func TestChangeHandle(t *testing.T) {
+	c := ParseChangeHandle(changeHandle.Serialize())
+	if c == nil {
+		t.Error("Could not parse actions ChangeHandle")
+		return
+	}
+	if !reflect.DeepEqual(c, changeHandle) {
+		t.Error("Parse and Serialize not working for actions ChangeHandle")
+	}
+}
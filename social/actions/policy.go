@@ -1,6 +1,8 @@
 package actions
 
 import (
+	"sort"
+
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/util"
 )
@@ -8,21 +10,157 @@ import (
 // % + 1 Vote...or 100%
 // Supermahority to change policy rule
 // Majority for anything else
+// Quorum is the % of members that must cast a vote (abstentions included) for
+// a proposal to be approved. A proposal that reaches the end of its voting
+// window is rejected, unless AcceptOnExpiry and the quorum was met with more
+// favorable than against votes.
 type Policy struct {
-	Majority      int
-	SuperMajority int
+	Majority       int
+	SuperMajority  int
+	Quorum         int
+	AcceptOnExpiry bool
+	VotingWindow   map[byte]uint64 // epochs por tipo de acao (ex. AUpdateCollective)
+	Strategy       Strategy
+}
+
+// Consensus strategies of a collective
+const (
+	MajorityStrategy  byte = iota // majority of members, one vote each
+	WeightedStrategy              // majority of the weights of the members
+	VetoStrategy                  // majority of members, any of the Roles can veto
+	ReviewersStrategy             // Required of the Roles must approve
+	UnknownStrategy
+)
+
+// Strategy of consensus of a collective. The zero value is the majority of
+// members.
+type Strategy struct {
+	Kind     byte
+	Weights  map[crypto.Token]int // peso de cada membro, 1 se ausente (WeightedStrategy)
+	Roles    []crypto.Token       // membros com veto (VetoStrategy) ou revisores (ReviewersStrategy)
+	Required int                  // numero de revisores necessarios (ReviewersStrategy)
 }
 
+// PutPolicy writes majority and supermajority only. The other rules were
+// added later and go behind a marker, see PutPolicyRules.
 func PutPolicy(policy Policy, bytes *[]byte) {
 	*bytes = append(*bytes, byte(policy.Majority), byte(policy.SuperMajority))
 }
 
+// HasRules checks if the policy has any rule besides majority and
+// supermajority.
+func (p Policy) HasRules() bool {
+	s := p.Strategy
+	return p.Quorum != 0 || p.AcceptOnExpiry || len(p.VotingWindow) > 0 || s.Kind != MajorityStrategy || len(s.Weights) > 0 || len(s.Roles) > 0 || s.Required != 0
+}
+
+// PutPolicyRules writes quorum, expiry, voting windows and strategy.
+func PutPolicyRules(policy Policy, bytes *[]byte) {
+	util.PutByte(byte(policy.Quorum), bytes)
+	util.PutBool(policy.AcceptOnExpiry, bytes)
+	PutVotingWindow(policy.VotingWindow, bytes)
+	PutStrategy(policy.Strategy, bytes)
+}
+
+func ParsePolicyRules(data []byte, position int, policy *Policy) int {
+	var quorum byte
+	quorum, position = util.ParseByte(data, position)
+	policy.Quorum = int(quorum)
+	policy.AcceptOnExpiry, position = util.ParseBool(data, position)
+	policy.VotingWindow, position = ParseVotingWindow(data, position)
+	policy.Strategy, position = ParseStrategy(data, position)
+	return position
+}
+
+// PutStrategy writes the weights sorted by token, so that equal strategies
+// serialize equally.
+func PutStrategy(strategy Strategy, bytes *[]byte) {
+	util.PutByte(strategy.Kind, bytes)
+	tokens := make([]crypto.Token, 0, len(strategy.Weights))
+	for token := range strategy.Weights {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return string(tokens[i][:]) < string(tokens[j][:])
+	})
+	util.PutUint16(uint16(len(tokens)), bytes)
+	for _, token := range tokens {
+		util.PutToken(token, bytes)
+		util.PutUint32(uint32(strategy.Weights[token]), bytes)
+	}
+	util.PutUint16(uint16(len(strategy.Roles)), bytes)
+	for _, token := range strategy.Roles {
+		util.PutToken(token, bytes)
+	}
+	util.PutUint16(uint16(strategy.Required), bytes)
+}
+
+// ParseStrategy returns nil weights and roles when there are none.
+func ParseStrategy(data []byte, position int) (Strategy, int) {
+	strategy := Strategy{}
+	strategy.Kind, position = util.ParseByte(data, position)
+	var count uint16
+	count, position = util.ParseUint16(data, position)
+	if count > 0 {
+		strategy.Weights = make(map[crypto.Token]int, count)
+	}
+	for n := 0; n < int(count) && position < len(data); n++ {
+		var token crypto.Token
+		var weight uint32
+		token, position = util.ParseToken(data, position)
+		weight, position = util.ParseUint32(data, position)
+		strategy.Weights[token] = int(weight)
+	}
+	count, position = util.ParseUint16(data, position)
+	for n := 0; n < int(count) && position < len(data); n++ {
+		var token crypto.Token
+		token, position = util.ParseToken(data, position)
+		strategy.Roles = append(strategy.Roles, token)
+	}
+	var required uint16
+	required, position = util.ParseUint16(data, position)
+	strategy.Required = int(required)
+	return strategy, position
+}
+
 func ParsePolicy(data []byte, position int) (Policy, int) {
-	policy := Policy{
-		int(data[position]),
-		int(data[position+1]),
+	policy := Policy{}
+	var value byte
+	value, position = util.ParseByte(data, position)
+	policy.Majority = int(value)
+	value, position = util.ParseByte(data, position)
+	policy.SuperMajority = int(value)
+	return policy, position
+}
+
+// PutVotingWindow writes the windows sorted by kind, so that equal policies
+// serialize equally.
+func PutVotingWindow(window map[byte]uint64, bytes *[]byte) {
+	kinds := make([]int, 0, len(window))
+	for kind := range window {
+		kinds = append(kinds, int(kind))
+	}
+	sort.Ints(kinds)
+	util.PutByte(byte(len(kinds)), bytes)
+	for _, kind := range kinds {
+		util.PutByte(byte(kind), bytes)
+		util.PutUint64(window[byte(kind)], bytes)
+	}
+}
+
+// ParseVotingWindow returns nil for an empty window.
+func ParseVotingWindow(data []byte, position int) (map[byte]uint64, int) {
+	count, position := util.ParseByte(data, position)
+	if count == 0 {
+		return nil, position
 	}
-	return policy, position + 2
+	window := make(map[byte]uint64, count)
+	for n := 0; n < int(count); n++ {
+		var kind byte
+		kind, position = util.ParseByte(data, position)
+		window[kind], position = util.ParseUint64(data, position)
+	}
+	return window, position
 }
 
 type Vote struct {
@@ -31,6 +169,7 @@ type Vote struct {
 	Reasons string
 	Hash    crypto.Hash
 	Approve bool
+	Abstain bool // conta para o quorum mas nao para a maioria
 }
 
 func (c *Vote) Reasoning() string {
@@ -53,28 +192,52 @@ func (v *Vote) Serialize() []byte {
 	util.PutString(v.Reasons, &bytes)
 	util.PutHash(v.Hash, &bytes)
 	util.PutBool(v.Approve, &bytes)
+	// abstain is a trailing byte present only on abstentions, votes without
+	// it serialize as before it existed
+	if v.Abstain {
+		util.PutBool(true, &bytes)
+	}
 	return bytes
 }
 
+// markers of an optional policy
+const (
+	noPolicy        byte = iota
+	basePolicy           // majority and supermajority
+	policyWithRules      // followed by the rules of PutPolicyRules
+)
+
 func PutOptionalPolicy(policy *Policy, bytes *[]byte) {
 	if policy == nil {
-		*bytes = append(*bytes, 0)
+		*bytes = append(*bytes, noPolicy)
 		return
 	}
-	*bytes = append(*bytes, 1, byte(policy.Majority), byte(policy.SuperMajority))
+	if !policy.HasRules() {
+		*bytes = append(*bytes, basePolicy)
+		PutPolicy(*policy, bytes)
+		return
+	}
+	*bytes = append(*bytes, policyWithRules)
+	PutPolicy(*policy, bytes)
+	PutPolicyRules(*policy, bytes)
 }
 
 func ParseOptionalPolicy(data []byte, position int) (*Policy, int) {
-	if data[position] == 0 {
+	if position >= len(data) || data[position] == noPolicy {
 		return nil, position + 1
 	}
-	if data[position] != 1 || data[position+1] > 100 || data[position+2] > 100 {
-		return nil, position + 3
+	marker := data[position]
+	if marker != basePolicy && marker != policyWithRules {
+		return nil, len(data) + 1
+	}
+	policy, position := ParsePolicy(data, position+1)
+	if marker == policyWithRules {
+		position = ParsePolicyRules(data, position, &policy)
 	}
-	return &Policy{
-		Majority:      int(data[position+1]),
-		SuperMajority: int(data[position+2]),
-	}, position + 3
+	if policy.Majority > 100 || policy.SuperMajority > 100 || policy.Quorum > 100 {
+		return nil, position
+	}
+	return &policy, position
 }
 
 func ParseVote(vote []byte) *Vote {
@@ -89,6 +252,12 @@ func ParseVote(vote []byte) *Vote {
 	action.Reasons, position = util.ParseString(vote, position)
 	action.Hash, position = util.ParseHash(vote, position)
 	action.Approve, position = util.ParseBool(vote, position)
+	if position < len(vote) {
+		if vote[position] != 1 {
+			return nil
+		}
+		action.Abstain, position = true, position+1
+	}
 	if position != len(vote) {
 		return nil
 	}
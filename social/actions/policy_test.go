@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+var vote = &Vote{
+	Epoch:   36,
+	Author:  crypto.Token{},
+	Reasons: "vote test",
+	Hash:    crypto.Hasher([]byte("proposal")),
+	Abstain: true,
+}
+
+func TestVote(t *testing.T) {
+	v := ParseVote(vote.Serialize())
+	if v == nil {
+		t.Error("Could not parse actions Vote")
+		return
+	}
+	if !reflect.DeepEqual(v, vote) {
+		t.Error("Parse and Serialize not working for actions Vote")
+	}
+}
+
+func TestVoteWithoutAbstain(t *testing.T) {
+	approve := &Vote{Epoch: 36, Author: crypto.Token{}, Reasons: "vote test", Hash: crypto.Hasher([]byte("proposal")), Approve: true}
+	// serialized as before abstentions existed
+	bytes := make([]byte, 0)
+	util.PutUint64(approve.Epoch, &bytes)
+	util.PutToken(approve.Author, &bytes)
+	util.PutByte(AVote, &bytes)
+	util.PutString(approve.Reasons, &bytes)
+	util.PutHash(approve.Hash, &bytes)
+	util.PutBool(approve.Approve, &bytes)
+	if !reflect.DeepEqual(ParseVote(bytes), approve) {
+		t.Error("Could not parse vote serialized without abstain")
+	}
+	if !approve.Hashed().Equal(crypto.Hasher(bytes)) {
+		t.Error("Hash of vote without abstention changed")
+	}
+	if ParseVote(append(bytes, 0)) != nil {
+		t.Error("Vote with explicit false abstain accepted")
+	}
+}
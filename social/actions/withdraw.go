@@ -0,0 +1,63 @@
+package actions
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// Withdraw cancels the pending proposal with the given Hash. It is issued by
+// the author of the proposal, or OnBehalfOf the collective the proposal
+// belongs to, in which case it goes through the consensus of the collective.
+type Withdraw struct {
+	Epoch      uint64
+	Author     crypto.Token
+	Reasons    string
+	OnBehalfOf string
+	Hash       crypto.Hash
+}
+
+func (c *Withdraw) Reasoning() string {
+	return c.Reasons
+}
+
+func (c *Withdraw) Hashed() crypto.Hash {
+	return crypto.Hasher(c.Serialize())
+}
+
+// Afeta a proposta retirada
+func (c *Withdraw) Affected() []crypto.Hash {
+	return []crypto.Hash{c.Hash}
+}
+
+func (c *Withdraw) Authored() crypto.Token {
+	return c.Author
+}
+
+func (c *Withdraw) Serialize() []byte {
+	bytes := make([]byte, 0)
+	util.PutUint64(c.Epoch, &bytes)
+	util.PutToken(c.Author, &bytes)
+	util.PutByte(AWithdraw, &bytes)
+	util.PutString(c.Reasons, &bytes)
+	util.PutString(c.OnBehalfOf, &bytes)
+	util.PutHash(c.Hash, &bytes)
+	return bytes
+}
+
+func ParseWithdraw(withdraw []byte) *Withdraw {
+	action := Withdraw{}
+	position := 0
+	action.Epoch, position = util.ParseUint64(withdraw, position)
+	action.Author, position = util.ParseToken(withdraw, position)
+	if withdraw[position] != AWithdraw {
+		return nil
+	}
+	position += 1
+	action.Reasons, position = util.ParseString(withdraw, position)
+	action.OnBehalfOf, position = util.ParseString(withdraw, position)
+	action.Hash, position = util.ParseHash(withdraw, position)
+	if position != len(withdraw) {
+		return nil
+	}
+	return &action
+}
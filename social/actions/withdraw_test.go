@@ -0,0 +1,27 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+var withdraw = &Withdraw{
+	Epoch:      35,
+	Author:     crypto.Token{},
+	Reasons:    "withdraw test",
+	OnBehalfOf: "collective",
+	Hash:       crypto.Hasher([]byte("proposal")),
+}
+
+func TestWithdraw(t *testing.T) {
+	w := ParseWithdraw(withdraw.Serialize())
+	if w == nil {
+		t.Error("Could not parse actions Withdraw")
+		return
+	}
+	if !reflect.DeepEqual(w, withdraw) {
+		t.Error("Parse and Serialize not working for actions Withdraw")
+	}
+}
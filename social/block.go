@@ -0,0 +1,158 @@
+package social
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+)
+
+// Blocks are sealed by the gateway with a header
+//
+//	epoch | parent hash | actions hash | state root | gateway signature
+//
+// The parent is the hash of the previous header (ZeroHash for the genesis
+// block). The state root is the commitment to the state after the block is
+// incorporated. The gateway signs the hash of the header.
+
+const BlockHeaderSize = 8 + 3*crypto.Size + crypto.SignatureSize
+
+var (
+	ErrInvalidBlockHeader    = errors.New("invalid block header")
+	ErrBlockOutOfOrder       = errors.New("block out of order")
+	ErrBlockParentMismatch   = errors.New("block does not extend the chain")
+	ErrBlockActionsMismatch  = errors.New("block actions hash mismatch")
+	ErrInvalidBlockSignature = errors.New("invalid block signature")
+	ErrStateRootMismatch     = errors.New("state root diverges from gateway")
+)
+
+type BlockHeader struct {
+	Epoch     uint64
+	Parent    crypto.Hash
+	Actions   crypto.Hash
+	StateRoot crypto.Hash
+	Signature crypto.Signature
+}
+
+// ActionsHash is the hash of the concatenation of the hashes of the actions
+// of the block in order.
+func ActionsHash(actions [][]byte) crypto.Hash {
+	hashes := make([]byte, 0, len(actions)*crypto.Size)
+	for _, action := range actions {
+		hash := crypto.Hasher(action)
+		hashes = append(hashes, hash[:]...)
+	}
+	return crypto.Hasher(hashes)
+}
+
+// SealBlock returns the header of the block with the given actions and state
+// root signed by the gateway credentials.
+func SealBlock(epoch uint64, parent crypto.Hash, actions [][]byte, root crypto.Hash, credentials crypto.PrivateKey) *BlockHeader {
+	header := &BlockHeader{
+		Epoch:     epoch,
+		Parent:    parent,
+		Actions:   ActionsHash(actions),
+		StateRoot: root,
+	}
+	hash := header.Hash()
+	header.Signature = credentials.Sign(hash[:])
+	return header
+}
+
+func (b *BlockHeader) Hash() crypto.Hash {
+	bytes := make([]byte, 0)
+	util.PutUint64(b.Epoch, &bytes)
+	util.PutHash(b.Parent, &bytes)
+	util.PutHash(b.Actions, &bytes)
+	util.PutHash(b.StateRoot, &bytes)
+	return crypto.Hasher(bytes)
+}
+
+// Verify checks if the header seals the given actions as the block following
+// parent at the expected epoch under the gateway token.
+func (b *BlockHeader) Verify(epoch uint64, parent crypto.Hash, actions [][]byte, gateway crypto.Token) error {
+	if b.Epoch != epoch {
+		return ErrBlockOutOfOrder
+	}
+	if !b.Parent.Equal(parent) {
+		return ErrBlockParentMismatch
+	}
+	if !b.Actions.Equal(ActionsHash(actions)) {
+		return ErrBlockActionsMismatch
+	}
+	hash := b.Hash()
+	if !gateway.Verify(hash[:], b.Signature) {
+		return ErrInvalidBlockSignature
+	}
+	return nil
+}
+
+func (b *BlockHeader) Serialize() []byte {
+	bytes := make([]byte, 0, BlockHeaderSize)
+	util.PutUint64(b.Epoch, &bytes)
+	util.PutHash(b.Parent, &bytes)
+	util.PutHash(b.Actions, &bytes)
+	util.PutHash(b.StateRoot, &bytes)
+	util.PutSignature(b.Signature, &bytes)
+	return bytes
+}
+
+func ParseBlockHeader(data []byte, position int) (*BlockHeader, int) {
+	if len(data)-position < BlockHeaderSize {
+		return nil, len(data) + 1
+	}
+	header := BlockHeader{}
+	header.Epoch, position = util.ParseUint64(data, position)
+	header.Parent, position = util.ParseHash(data, position)
+	header.Actions, position = util.ParseHash(data, position)
+	header.StateRoot, position = util.ParseHash(data, position)
+	header.Signature, position = util.ParseSignature(data, position)
+	return &header, position
+}
+
+// A connection to the gateway starts with a sync request
+//
+//	epoch | hash of the header of block epoch-1 (ZeroHash for epoch 0)
+//
+// and the gateway streams the blocks from epoch on. If the hash does not
+// match the gateway chain, the gateway streams the chain from genesis.
+
+func SyncRequest(epoch uint64, parent crypto.Hash) []byte {
+	bytes := make([]byte, 0, 8+crypto.Size)
+	util.PutUint64(epoch, &bytes)
+	util.PutHash(parent, &bytes)
+	return bytes
+}
+
+func ParseSyncRequest(data []byte) (uint64, crypto.Hash, bool) {
+	if len(data) != 8+crypto.Size {
+		return 0, crypto.ZeroHash, false
+	}
+	epoch, position := util.ParseUint64(data, 0)
+	parent, _ := util.ParseHash(data, position)
+	return epoch, parent, true
+}
+
+// The gateway tells the proxy that sent an action why the action was
+// rejected
+//
+//	hash of the undressed action | reason
+
+func RejectionMessage(hash crypto.Hash, reason string) []byte {
+	bytes := make([]byte, 0, crypto.Size+len(reason)+4)
+	util.PutHash(hash, &bytes)
+	util.PutString(reason, &bytes)
+	return bytes
+}
+
+func ParseRejection(data []byte) (crypto.Hash, string, bool) {
+	if len(data) < crypto.Size {
+		return crypto.ZeroHash, "", false
+	}
+	hash, position := util.ParseHash(data, 0)
+	reason, position := util.ParseString(data, position)
+	if position != len(data) {
+		return crypto.ZeroHash, "", false
+	}
+	return hash, reason, true
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
 	"github.com/lienkolabs/synergy/social/index"
 	"github.com/lienkolabs/synergy/social/state"
 )
@@ -81,7 +82,7 @@ func SelfGateway(engine *state.State) *Gateway {
 			case <-ticker.C:
 				gateway.mu.Lock()
 				// nesse tempo só eu posso alterar
-				engine.Epoch += 1
+				engine.NextBlock()
 				// atualiza a epoch e avisa todos que foi atualizado
 				for _, emit := range gateway.newBlock {
 					emit <- engine.Epoch
@@ -91,10 +92,9 @@ func SelfGateway(engine *state.State) *Gateway {
 
 			// chegou uma acao pra processar
 			case action := <-gateway.incoming:
-				// tira tudo que é do breeze e axé e fica só com o que diz respeito ao synergy
-				undressed := Undress(action)
-				// engine (que é o estado) incorpora acao, se nao conseguir devolve o erro
-				if err := engine.Action(undressed); err != nil {
+				// engine (que é o estado) confere o envelope e a procuracao e
+				// incorpora acao, se nao conseguir devolve o erro
+				if err := engine.Action(action); err != nil {
 					fmt.Println(err)
 				} else {
 					fmt.Println("Action performed")
@@ -115,12 +115,12 @@ func SelfGateway(engine *state.State) *Gateway {
 	return gateway
 }
 
-// removendo tudo que nao é do synergy
-func Undress(data []byte) []byte {
-	// ignore first byte (breeze version)
-	head := data[1 : 8+crypto.TokenSize+1]
-	// ignore protocol id and tail
-	// tail = wallet signature + fee + wallet + attorney signature + attorney
-	tailSize := 2*crypto.SignatureSize + 2*crypto.TokenSize + 8
-	return append(head, data[8+crypto.TokenSize+1+4:len(data)-tailSize]...)
+// Undress checks the attorney and wallet signatures of the envelope and
+// returns the synergy action within it.
+func Undress(data []byte) ([]byte, error) {
+	envelope, err := actions.ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Action, nil
 }
@@ -61,6 +61,31 @@ func (i *Index) ActionToObjects(action actions.Action) []crypto.Hash {
 		return []crypto.Hash{crypto.Hasher([]byte(v.OnBehalfOf))}
 	case *actions.Signin:
 		return []crypto.Hash{crypto.ZeroHash}
+	case *actions.Comment:
+		if v.OnBehalfOf != "" {
+			return []crypto.Hash{crypto.Hasher([]byte(v.OnBehalfOf)), v.Object}
+		}
+		return []crypto.Hash{v.Object}
+	case *actions.Withdraw:
+		if v.OnBehalfOf != "" {
+			return []crypto.Hash{crypto.Hasher([]byte(v.OnBehalfOf)), v.Hash}
+		}
+		return []crypto.Hash{v.Hash}
+	}
+	return nil
+}
+
+// commentedDraft returns the draft commented directly or through one of its
+// edits or its release
+func (i *Index) commentedDraft(object crypto.Hash) *state.Draft {
+	if draft, ok := i.state.Drafts[object]; ok {
+		return draft
+	}
+	if edit, ok := i.state.Edits[object]; ok {
+		return edit.Draft
+	}
+	if release, ok := i.state.Release(object); ok {
+		return release.Draft
 	}
 	return nil
 }
@@ -497,6 +522,29 @@ func (i *Index) ActionToString(action actions.Action, status bool) (string, stri
 			}
 		}
 		return "", "", v.Author, 0, ""
+	case *actions.Comment:
+		if draft := i.commentedDraft(v.Object); draft != nil {
+			if status {
+				handle := i.state.Members[crypto.HashToken(v.Author)]
+				if v.OnBehalfOf != "" {
+					handle = v.OnBehalfOf
+				}
+				return fmt.Sprintf("%v commented on %v", handle, draft.Title), crypto.EncodeHash(draft.DraftHash), v.Author, v.Epoch, "comment"
+			} else {
+				handle := i.state.Members[crypto.HashToken(v.Author)]
+				return fmt.Sprintf("%v proposed %v comment on %v", handle, v.OnBehalfOf, draft.Title), crypto.EncodeHash(draft.DraftHash), v.Author, v.Epoch, "comment"
+			}
+		}
+		return "", "", v.Author, 0, ""
+	case *actions.Withdraw:
+		handle := i.state.Members[crypto.HashToken(v.Author)]
+		if v.OnBehalfOf == "" {
+			return fmt.Sprintf("%v withdrew a proposal", handle), "", v.Author, v.Epoch, "withdraw"
+		}
+		if status {
+			return fmt.Sprintf("%v withdrew a proposal", v.OnBehalfOf), "", v.Author, v.Epoch, "withdraw"
+		}
+		return fmt.Sprintf("%v proposed %v to withdraw a proposal", handle, v.OnBehalfOf), "", v.Author, v.Epoch, "withdraw"
 	}
 	return "", "", crypto.ZeroToken, 0, ""
 }
@@ -709,6 +757,27 @@ func (i *Index) ActionToStringWithLinks(action actions.Action, status bool) (str
 			}
 		}
 		fmt.Println("sign in not return")
+	case *actions.Comment:
+		if draft := i.commentedDraft(v.Object); draft != nil {
+			handle := i.state.Members[crypto.HashToken(v.Author)]
+			if status {
+				if v.OnBehalfOf != "" {
+					return fmt.Sprintf("%v commented on %v", fmtCollective(v.OnBehalfOf), fmtDraft(draft.Title, draft.DraftHash)), v.Epoch, v.Reasons
+				}
+				return fmt.Sprintf("%v commented on %v", fmtHandle(handle), fmtDraft(draft.Title, draft.DraftHash)), v.Epoch, v.Reasons
+			} else {
+				return fmt.Sprintf("%v proposed %v comment on %v", fmtHandle(handle), fmtCollective(v.OnBehalfOf), fmtDraft(draft.Title, draft.DraftHash)), v.Epoch, v.Reasons
+			}
+		}
+	case *actions.Withdraw:
+		handle := i.state.Members[crypto.HashToken(v.Author)]
+		if v.OnBehalfOf == "" {
+			return fmt.Sprintf("%v withdrew a proposal", fmtHandle(handle)), v.Epoch, v.Reasons
+		}
+		if status {
+			return fmt.Sprintf("%v withdrew a proposal", fmtCollective(v.OnBehalfOf)), v.Epoch, v.Reasons
+		}
+		return fmt.Sprintf("%v proposed %v to withdraw a proposal", fmtHandle(handle), fmtCollective(v.OnBehalfOf)), v.Epoch, v.Reasons
 	}
 	return "", 0, ""
 }
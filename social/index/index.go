@@ -78,6 +78,7 @@ type Index struct {
 	MemberToEdit       map[crypto.Token][]*state.Edit
 
 	MemberToCheckin map[crypto.Token][]*state.Event
+	memberToComment map[crypto.Token][]*state.Comment
 	//memberToEdit
 	//memberToDraft
 
@@ -88,6 +89,9 @@ type Index struct {
 	collectiveToStamps map[*state.Collective][]*state.Stamp
 	collectiveToEvents map[*state.Collective][]*state.Event
 
+	// comments on a draft and on its edits
+	draftToComment map[crypto.Hash][]*state.Comment
+
 	RecentActions []*IndexedAction
 
 	// collectiveLastAction map[*state.Collective][]lastaction
@@ -262,6 +266,7 @@ func NewIndex() *Index {
 		memberToBoard:      make(map[crypto.Token][]string),
 		memberToEvent:      make(map[crypto.Token][]crypto.Hash),
 		MemberToCheckin:    make(map[crypto.Token][]*state.Event),
+		memberToComment:    make(map[crypto.Token][]*state.Comment),
 
 		MemberToDraft: make(map[crypto.Token][]*state.Draft),
 		MemberToEdit:  make(map[crypto.Token][]*state.Edit),
@@ -270,6 +275,7 @@ func NewIndex() *Index {
 		collectiveToBoards: make(map[*state.Collective][]*state.Board),
 		collectiveToStamps: make(map[*state.Collective][]*state.Stamp),
 		collectiveToEvents: make(map[*state.Collective][]*state.Event),
+		draftToComment:     make(map[crypto.Hash][]*state.Comment),
 		// collectiveLastAction: make(map[*state.Collective][]lastaction),
 		//editToDrafts: make(map[*state.Edit][]*state.Draft),
 
@@ -327,10 +333,64 @@ func (i *Index) EventsOnMember(member crypto.Token) []crypto.Hash {
 	return i.memberToEvent[member]
 }
 
+func (i *Index) CommentsOnMember(member crypto.Token) []*state.Comment {
+	return i.memberToComment[member]
+}
+
+// Comments related to a given draft, including the ones on its edits and its
+// release
+
+func (i *Index) CommentsOnDraft(draft crypto.Hash) []*state.Comment {
+	return i.draftToComment[draft]
+}
+
+// Reset discards everything indexed from the state keeping the members added
+// with AddMemberToIndex.
+func (i *Index) Reset(s *state.State) {
+	members := i.indexedMembers
+	*i = *NewIndex()
+	i.indexedMembers = members
+	i.SetState(s)
+}
+
 func (i *Index) AddMemberToIndex(token crypto.Token, handle string) {
 	i.indexedMembers[token] = handle
 }
 
+func moveMember[T any](members map[crypto.Token]T, old, new crypto.Token) {
+	if value, ok := members[old]; ok {
+		delete(members, old)
+		members[new] = value
+	}
+}
+
+// RotateMember moves everything indexed for the old key of a member to its
+// new key.
+func (i *Index) RotateMember(old, new crypto.Token) {
+	moveMember(i.allUsers, old, new)
+	moveMember(i.indexedMembers, old, new)
+	moveMember(i.memberToAction, old, new)
+	moveMember(i.indexVotes, old, new)
+	moveMember(i.memberToCollective, old, new)
+	moveMember(i.memberToBoard, old, new)
+	moveMember(i.memberToEvent, old, new)
+	moveMember(i.MemberToDraft, old, new)
+	moveMember(i.MemberToEdit, old, new)
+	moveMember(i.MemberToCheckin, old, new)
+	moveMember(i.memberToComment, old, new)
+	for hash, token := range i.pendingIndexActions {
+		if token.Equal(old) {
+			i.pendingIndexActions[hash] = new
+		}
+	}
+}
+
+func (i *Index) ChangeHandle(token crypto.Token, handle string) {
+	if _, ok := i.indexedMembers[token]; ok {
+		i.indexedMembers[token] = handle
+	}
+}
+
 func (i Index) GetLastAction(objectHash crypto.Hash) *ActionDetails {
 	recent := i.objectHashToActionHash[objectHash]
 	if recent == nil || len(recent.actions) == 0 {
@@ -422,6 +482,14 @@ func (i *Index) IndexAction(action actions.Action) {
 			newAction.Approved = 1
 		case *actions.Vote:
 			newAction.Approved = 1
+		case *actions.Comment:
+			if v.OnBehalfOf == "" {
+				newAction.Approved = 1
+			}
+		case *actions.Withdraw:
+			if v.OnBehalfOf == "" {
+				newAction.Approved = 1
+			}
 		case *actions.RequestMembership:
 			if !v.Include {
 				i.IndexConsensusAction(action)
@@ -811,6 +879,15 @@ func (i *Index) AddEditToIndex(edit *state.Edit) {
 	}
 }
 
+func (i *Index) AddCommentToIndex(comment *state.Comment) {
+	if i.isIndexedMember(comment.Author) {
+		i.memberToComment[comment.Author] = appendOrCreate[*state.Comment](i.memberToComment[comment.Author], comment)
+	}
+	if draft := i.commentedDraft(comment.Object); draft != nil {
+		i.draftToComment[draft.DraftHash] = appendOrCreate[*state.Comment](i.draftToComment[draft.DraftHash], comment)
+	}
+}
+
 /*
 func (i *Index) RemoveMemberFromCollective(collective *state.Collective, member crypto.Token) {
 	delete(i.memberToCollective, member)
@@ -0,0 +1,126 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+// Rebuild indexes the objects of a state restored from a snapshot. Only what
+// can be derived from the state is rebuilt: memberships, boards, events,
+// drafts, edits, comments and the proposals open to the vote of the indexed
+// members. The history of actions (recent actions, the actions of each
+// member and the votes of closed proposals) is not part of the state and
+// starts empty.
+func (i *Index) Rebuild(s *state.State) {
+	i.Reset(s)
+	for _, collective := range s.Collectives {
+		for token := range collective.Members {
+			i.Personal(token).AddCollective(collective.Name)
+			if i.isIndexedMember(token) {
+				i.memberToCollective[token] = appendOrCreate[string](i.memberToCollective[token], collective.Name)
+			}
+		}
+	}
+	for _, board := range s.Boards {
+		if board.Collective != nil {
+			i.AddBoardToCollective(board, board.Collective)
+		}
+		if board.Editors == nil {
+			continue
+		}
+		for token := range board.Editors.Members {
+			i.Personal(token).AddBoard(board.Name)
+			if i.isIndexedMember(token) {
+				i.memberToBoard[token] = appendOrCreate[string](i.memberToBoard[token], board.Name)
+			}
+		}
+	}
+	for _, event := range sortedEvents(s.Events) {
+		if event.Collective != nil {
+			i.AddEventToCollective(event, event.Collective)
+		}
+		if event.Managers != nil {
+			for token := range event.Managers.Members {
+				i.Personal(token).AddEvent(event.Hash)
+				if i.isIndexedMember(token) {
+					i.memberToEvent[token] = appendOrCreate[crypto.Hash](i.memberToEvent[token], event.Hash)
+				}
+			}
+		}
+		for token := range event.Checkin {
+			i.AddCheckin(token, event)
+		}
+	}
+	for _, release := range s.Releases {
+		for _, stamp := range release.Stamps {
+			if stamp.Imprinted && stamp.Reputation != nil {
+				i.AddStampToCollective(stamp, stamp.Reputation)
+			}
+		}
+	}
+	drafts := make([]*state.Draft, 0, len(s.Drafts)+len(s.Proposals.Draft))
+	for _, draft := range s.Drafts {
+		drafts = append(drafts, draft)
+	}
+	for hash, draft := range s.Proposals.Draft {
+		if _, ok := s.Drafts[hash]; !ok {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.Slice(drafts, func(a, b int) bool { return drafts[a].Date < drafts[b].Date })
+	for _, draft := range drafts {
+		i.AddDraftToIndex(draft)
+		if draft.Authors != nil && draft.Authors.CollectiveName() == "" {
+			for token := range draft.Authors.ListOfMembers() {
+				i.Personal(token).AddDraft(draft.DraftHash)
+			}
+		}
+	}
+	edits := make([]*state.Edit, 0, len(s.Edits)+len(s.Proposals.Edit))
+	for _, edit := range s.Edits {
+		edits = append(edits, edit)
+	}
+	for hash, edit := range s.Proposals.Edit {
+		if _, ok := s.Edits[hash]; !ok {
+			edits = append(edits, edit)
+		}
+	}
+	sort.Slice(edits, func(a, b int) bool { return edits[a].Date < edits[b].Date })
+	for _, edit := range edits {
+		i.AddEditToIndex(edit)
+	}
+	comments := make([]*state.Comment, 0, len(s.Comments))
+	for _, comment := range s.Comments {
+		comments = append(comments, comment)
+	}
+	sort.Slice(comments, func(a, b int) bool { return comments[a].Epoch < comments[b].Epoch })
+	for _, comment := range comments {
+		i.AddCommentToIndex(comment)
+	}
+	for _, hash := range s.Proposals.Open() {
+		pool := s.Proposals.Pooling(hash)
+		if pool == nil {
+			continue
+		}
+		for token := range pool.Voters {
+			if !i.isIndexedMember(token) {
+				continue
+			}
+			if _, ok := i.indexVotes[token]; !ok {
+				i.indexVotes[token] = NewSetOfHashes()
+			}
+			i.indexVotes[token].Add(hash)
+		}
+	}
+}
+
+func sortedEvents(events map[crypto.Hash]*state.Event) []*state.Event {
+	sorted := make([]*state.Event, 0, len(events))
+	for _, event := range events {
+		sorted = append(sorted, event)
+	}
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].StartAt.Before(sorted[b].StartAt) })
+	return sorted
+}
@@ -0,0 +1,57 @@
+package social
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+// A key file holds the hex encoded private key of a node on a single line.
+
+var (
+	ErrInvalidKeyFile = errors.New("invalid key file")
+	ErrInvalidToken   = errors.New("invalid hex encoded token")
+)
+
+func ReadKeyFile(path string) (crypto.PrivateKey, error) {
+	var key crypto.PrivateKey
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return key, err
+	}
+	bytes, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(bytes) != len(key) {
+		return key, ErrInvalidKeyFile
+	}
+	copy(key[:], bytes)
+	return key, nil
+}
+
+// OpenKeyFile reads the key file, creating it with a new random key if it
+// does not exist. Returns true if the key was created.
+func OpenKeyFile(path string) (crypto.PrivateKey, bool, error) {
+	key, err := ReadKeyFile(path)
+	if !os.IsNotExist(err) {
+		return key, false, err
+	}
+	_, key = crypto.RandomAsymetricKey()
+	data := hex.EncodeToString(key[:]) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return key, false, err
+	}
+	return key, true, nil
+}
+
+// ParseToken parses the hex encoded token.
+func ParseToken(text string) (crypto.Token, error) {
+	var token crypto.Token
+	bytes, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(bytes) != crypto.TokenSize {
+		return token, ErrInvalidToken
+	}
+	copy(token[:], bytes)
+	return token, nil
+}
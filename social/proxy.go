@@ -1,9 +1,9 @@
 package social
 
 import (
-	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/breeze/network/trusted"
@@ -11,16 +11,30 @@ import (
 	"github.com/lienkolabs/synergy/social/state"
 )
 
+// longest wait between attempts to reconnect to the gateway
+const maxBackoff = time.Minute
+
 type Proxy struct {
-	mu      sync.Mutex
-	state   *state.State
-	conn    *trusted.SignedConnection
-	viewers []chan uint64
-	epoch   uint64
+	mu         sync.Mutex
+	state      *state.State
+	conn       *trusted.SignedConnection
+	viewers    []chan uint64
+	epoch      uint64
+	host       string
+	credential crypto.PrivateKey
+	gateway    crypto.Token
+	parent     crypto.Hash // hash of the last sealed block header
+	pending    [][]byte    // actions of the current block not yet sealed
+	stopped    bool
 }
 
 func (p *Proxy) Stop() {
-	p.conn.Shutdown()
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+	if conn := p.connection(); conn != nil {
+		conn.Shutdown()
+	}
 }
 
 func (p *Proxy) State() *state.State {
@@ -28,12 +42,36 @@ func (p *Proxy) State() *state.State {
 }
 
 func (p *Proxy) Epoch() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.epoch
 }
 
+func (p *Proxy) connection() *trusted.SignedConnection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.conn
+}
+
+func (p *Proxy) isStopped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopped
+}
+
+// Action forwards the dressed action to the gateway. Actions with invalid
+// signatures are not forwarded.
 func (p *Proxy) Action(data []byte) {
-	undressed := Undress(data)
-	if err := p.conn.Send(undressed); err != nil {
+	if _, err := Undress(data); err != nil {
+		log.Printf("invalid action: %v", err)
+		return
+	}
+	conn := p.connection()
+	if conn == nil {
+		log.Print("error sending action: not connected to host")
+		return
+	}
+	if err := conn.Send(data); err != nil {
 		log.Printf("error sending action: %v", err)
 	}
 }
@@ -46,92 +84,215 @@ func (p *Proxy) Register() chan uint64 {
 	return viewer
 }
 
+// Snapshot returns the epoch, the parent hash and the serialized state of the
+// proxy at the last sealed block, as expected by WriteSnapshot, so that the
+// proxy can be resumed from it with ResumeProxyState.
+func (p *Proxy) Snapshot() (uint64, crypto.Hash, []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.epoch, p.parent, p.state.Snapshot()
+}
+
+// SelfProxyState connects to the gateway and syncs the genesis state with the
+// entire chain.
 func SelfProxyState(host string, hostToken crypto.Token, credential crypto.PrivateKey, genesis *state.State) *Proxy {
-	conn, err := trusted.Dial(host, credential, hostToken)
-	if err != nil {
-		log.Fatalf("could not connect to host: %v", err)
-	}
+	return ResumeProxyState(host, hostToken, credential, genesis, crypto.ZeroHash)
+}
+
+// ResumeProxyState connects to the gateway and syncs only the blocks after
+// the state epoch. parent is the hash of the header of the last block
+// incorporated into the state (as recorded by WriteSnapshot). The indexer of
+// the resumed state must be rebuilt from it (see index.Rebuild) before. If
+// the gateway cannot be reached the proxy keeps trying with backoff.
+func ResumeProxyState(host string, hostToken crypto.Token, credential crypto.PrivateKey, resumed *state.State, parent crypto.Hash) *Proxy {
 	proxy := &Proxy{
-		mu:      sync.Mutex{},
-		state:   genesis,
-		conn:    conn,
-		viewers: make([]chan uint64, 0),
-		epoch:   0,
+		mu:         sync.Mutex{},
+		state:      resumed,
+		viewers:    make([]chan uint64, 0),
+		epoch:      resumed.Epoch,
+		host:       host,
+		credential: credential,
+		gateway:    hostToken,
+		parent:     parent,
+		pending:    make([][]byte, 0),
 	}
-
 	go func() {
+		if !proxy.reconnect() {
+			return
+		}
 		for {
+			conn := proxy.connection()
 			data, err := conn.Read()
 			if err != nil {
-				log.Printf("error reading from host: %v", err)
+				if proxy.isStopped() {
+					return
+				}
+				log.Printf("connection to host lost: %v", err)
+				conn.Shutdown()
+				if !proxy.reconnect() {
+					return
+				}
+				continue
+			}
+			if len(data) == 0 {
 				continue
 			}
+			var blocks []*blockdata
 			if data[0] == 0 {
-				if len(data) == 9 {
-					proxy.epoch, _ = util.ParseUint64(data, 1)
-					proxy.mu.Lock()
-					for _, v := range proxy.viewers {
-						v <- proxy.epoch
-					}
-					proxy.mu.Unlock()
-				} else {
-					log.Print("invalid epoch message")
+				header, position := ParseBlockHeader(data, 1)
+				if header == nil || position != len(data) {
+					log.Print("invalid block message")
+					continue
 				}
+				blocks = []*blockdata{{header: header, actions: proxy.pending}}
+				proxy.pending = make([][]byte, 0)
 			} else if data[0] == 1 {
 				if len(data) > 1 {
-					action := data[1:]
-					if err := proxy.state.Action(action); err != nil {
-						log.Printf("invalid action: %v", err)
-					} else {
-						fmt.Println("action received")
-					}
+					// actions are incorporated only after the block is sealed
+					proxy.pending = append(proxy.pending, data[1:])
 				}
-			} else if data[0] == 2 {
-				blocks := ParseMultiBlocks(data)
-				if len(blocks) == 0 {
-					log.Printf("invalid multiblocv: %v", err)
-				} else {
-					log.Printf("multiple blocks: %v", len(blocks))
+			} else if data[0] == 3 {
+				// gateway resyncs the connection from the last sealed block
+				proxy.pending = make([][]byte, 0)
+			} else if data[0] == 4 {
+				if hash, reason, ok := ParseRejection(data[1:]); ok {
+					proxy.state.Rejected(hash, reason)
 				}
-
-				for _, block := range blocks {
-					proxy.mu.Lock()
-					proxy.epoch = block.epoch
-					for _, v := range proxy.viewers {
-						v <- proxy.epoch
-					}
-					proxy.mu.Unlock()
-					for _, action := range block.actions {
-						if err := proxy.state.Action(action); err != nil {
-							log.Printf("invalid action: %v", err)
-						}
-					}
+			} else if data[0] == 2 {
+				if blocks = ParseMultiBlocks(data); len(blocks) == 0 {
+					log.Print("invalid multiblock message")
+					continue
 				}
+				log.Printf("multiple blocks: %v", len(blocks))
 			} else {
 				log.Printf("invalid message type: %v", data[0])
 			}
+			for _, block := range blocks {
+				if block.header.Epoch == 0 && proxy.epoch != 0 {
+					// gateway did not recognize the chain of the proxy
+					log.Print("gateway syncing from genesis: resetting state")
+					proxy.reset()
+				}
+				err := proxy.seal(block.header, block.actions)
+				if err == ErrStateRootMismatch {
+					log.Printf("state diverged from gateway at block %v: resyncing", block.header.Epoch)
+					proxy.connection().Shutdown()
+					proxy.reset()
+					if !proxy.reconnect() {
+						return
+					}
+					break
+				} else if err != nil {
+					log.Printf("refusing block %v: %v", block.header.Epoch, err)
+					conn.Shutdown()
+					return
+				}
+			}
 		}
 	}()
 	return proxy
 }
 
+// dial connects to the gateway asking for the blocks after the last one
+// incorporated into the state.
+func (p *Proxy) dial() error {
+	conn, err := trusted.Dial(p.host, p.credential, p.gateway)
+	if err != nil {
+		return err
+	}
+	if err := conn.Send(SyncRequest(p.epoch, p.parent)); err != nil {
+		conn.Shutdown()
+		return err
+	}
+	// gateway sends again the actions of the open block
+	p.pending = make([][]byte, 0)
+	p.mu.Lock()
+	p.conn = conn
+	p.mu.Unlock()
+	return nil
+}
+
+// reconnect dials the gateway with exponential backoff until it succeeds or
+// the proxy is stopped.
+func (p *Proxy) reconnect() bool {
+	backoff := time.Second
+	for !p.isStopped() {
+		err := p.dial()
+		if err == nil {
+			return true
+		}
+		log.Printf("could not connect to host: %v (retry in %v)", err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+	return false
+}
+
+// seal checks that the header extends the chain known to the proxy and seals
+// the given actions under the gateway token. Only then are the actions
+// incorporated to the state. The state root after the block must match the
+// one signed by the gateway.
+func (p *Proxy) seal(header *BlockHeader, actions [][]byte) error {
+	if err := header.Verify(p.epoch, p.parent, actions, p.gateway); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	for _, action := range actions {
+		if err := p.state.Action(action); err != nil {
+			log.Printf("invalid action: %v", err)
+		}
+	}
+	if root := p.state.NextBlock(); !root.Equal(header.StateRoot) {
+		p.mu.Unlock()
+		return ErrStateRootMismatch
+	}
+	p.parent = header.Hash()
+	p.epoch = header.Epoch + 1
+	epoch, viewers := p.epoch, p.viewers
+	p.mu.Unlock()
+	for _, v := range viewers {
+		v <- epoch
+	}
+	return nil
+}
+
+// reset discards the state so that the chain is incorporated from genesis.
+func (p *Proxy) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Reset()
+	p.epoch = 0
+	p.parent = crypto.ZeroHash
+	p.pending = make([][]byte, 0)
+}
+
 type blockdata struct {
-	epoch   uint64
+	header  *BlockHeader
 	actions [][]byte
 }
 
+// ParseMultiBlocks parses a sequence of sealed blocks (header followed by the
+// actions array). Returns nil if the message is malformed.
 func ParseMultiBlocks(data []byte) []*blockdata {
-	if len(data) < 9 {
+	if len(data) < 1+BlockHeaderSize {
 		return nil
 	}
 	blocks := make([]*blockdata, 0)
 	position := 1
 	for {
 		block := blockdata{}
-		block.epoch, position = util.ParseUint64(data, position)
+		block.header, position = ParseBlockHeader(data, position)
+		if block.header == nil {
+			return nil
+		}
 		block.actions, position = util.ParseActionsArray(data, position)
+		if position > len(data) {
+			return nil
+		}
 		blocks = append(blocks, &block)
-		if position >= len(data) {
+		if position == len(data) {
 			return blocks
 		}
 	}
@@ -0,0 +1,71 @@
+package social
+
+import (
+	"errors"
+	"hash/crc32"
+	"os"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+	"github.com/lienkolabs/synergy/social/state"
+)
+
+// The snapshot file is
+//
+//	epoch | hash of the header of block epoch-1 | crc32 of state | state
+//
+// where epoch is the number of sealed blocks incorporated into the state. The
+// hash lets a node resume the sync with the gateway right after the snapshot.
+
+var ErrInvalidSnapshotFile = errors.New("invalid snapshot file")
+
+// WriteSnapshot replaces the snapshot file atomically.
+func WriteSnapshot(path string, epoch uint64, parent crypto.Hash, snapshot []byte) error {
+	data := make([]byte, 0, 8+crypto.Size+4+len(snapshot))
+	util.PutUint64(epoch, &data)
+	util.PutHash(parent, &data)
+	util.PutUint32(crc32.ChecksumIEEE(snapshot), &data)
+	data = append(data, snapshot...)
+	file, err := os.OpenFile(path+".tmp", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// ReadSnapshot returns the epoch, the parent hash and the restored state of
+// the snapshot file.
+func ReadSnapshot(path string, indexer state.Indexer) (uint64, crypto.Hash, *state.State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, crypto.ZeroHash, nil, err
+	}
+	if len(data) < 8+crypto.Size+4 {
+		return 0, crypto.ZeroHash, nil, ErrInvalidSnapshotFile
+	}
+	epoch, position := util.ParseUint64(data, 0)
+	parent, position := util.ParseHash(data, position)
+	checksum, position := util.ParseUint32(data, position)
+	if crc32.ChecksumIEEE(data[position:]) != checksum {
+		return 0, crypto.ZeroHash, nil, ErrInvalidSnapshotFile
+	}
+	restored, err := state.RestoreSnapshot(data[position:], indexer)
+	if err != nil {
+		return 0, crypto.ZeroHash, nil, err
+	}
+	if restored.Epoch != epoch {
+		return 0, crypto.ZeroHash, nil, ErrInvalidSnapshotFile
+	}
+	return epoch, parent, restored, nil
+}
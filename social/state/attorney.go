@@ -0,0 +1,64 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+var ErrUnauthorizedAttorney = errors.New("attorney not authorized by author")
+var ErrAttorneyByAttorney = errors.New("power of attorney must be granted or revoked by the member key")
+
+// IsAttorney checks if attorney can sign actions on behalf of author. Every
+// member is its own attorney.
+func (s *State) IsAttorney(author, attorney crypto.Token) bool {
+	if author.Equal(attorney) {
+		return true
+	}
+	granted, ok := s.Attorneys[author]
+	if !ok {
+		return false
+	}
+	_, ok = granted[attorney]
+	return ok
+}
+
+func (s *State) grantAttorney(author, attorney crypto.Token) {
+	if author.Equal(attorney) {
+		return
+	}
+	s.touchToken(attorneyLeaf, author)
+	if granted, ok := s.Attorneys[author]; ok {
+		granted[attorney] = struct{}{}
+	} else {
+		s.Attorneys[author] = map[crypto.Token]struct{}{attorney: {}}
+	}
+}
+
+func (s *State) GrantPowerOfAttorney(grant *actions.GrantPowerOfAttorney) error {
+	if !s.IsMember(grant.Author) {
+		return errors.New("not a member of synergy")
+	}
+	if s.IsAttorney(grant.Author, grant.Attorney) {
+		return errors.New("attorney already granted")
+	}
+	s.grantAttorney(grant.Author, grant.Attorney)
+	return nil
+}
+
+func (s *State) RevokePowerOfAttorney(revoke *actions.RevokePowerOfAttorney) error {
+	granted, ok := s.Attorneys[revoke.Author]
+	if !ok {
+		return errors.New("attorney not granted")
+	}
+	if _, ok := granted[revoke.Attorney]; !ok {
+		return errors.New("attorney not granted")
+	}
+	delete(granted, revoke.Attorney)
+	s.touchToken(attorneyLeaf, revoke.Author)
+	if len(granted) == 0 {
+		delete(s.Attorneys, revoke.Author)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestPowerOfAttorneyAction(t *testing.T) {
+	s := GenesisState(nil)
+	author, key := crypto.RandomAsymetricKey()
+	attorneyToken, attorney := crypto.RandomAsymetricKey()
+	signin := &actions.Signin{Epoch: 0, Author: author, Handle: "author"}
+	if err := s.Action(actions.Dress(signin.Serialize(), 0, author, attorney, attorney, 0)); err != nil {
+		t.Fatalf("could not sign in: %v", err)
+	}
+	device, _ := crypto.RandomAsymetricKey()
+	grant := &actions.GrantPowerOfAttorney{Epoch: 0, Author: author, Attorney: device}
+	if err := s.Action(actions.Dress(grant.Serialize(), 0, author, attorney, attorney, 0)); err != ErrAttorneyByAttorney {
+		t.Fatalf("Grant signed by attorney: %v", err)
+	}
+	if err := s.Action(actions.Dress(grant.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("Could not grant attorney: %v", err)
+	}
+	revoke := &actions.RevokePowerOfAttorney{Epoch: 0, Author: author, Attorney: device}
+	if err := s.Action(actions.Dress(revoke.Serialize(), 0, author, attorney, attorney, 0)); err != ErrAttorneyByAttorney {
+		t.Fatalf("Revoke signed by attorney: %v", err)
+	}
+	if !s.IsAttorney(author, device) {
+		t.Error("Attorney revoked by another attorney")
+	}
+	revoke = &actions.RevokePowerOfAttorney{Epoch: 0, Author: author, Attorney: attorneyToken}
+	if err := s.Action(actions.Dress(revoke.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("Could not revoke attorney: %v", err)
+	}
+	if s.IsAttorney(author, attorneyToken) {
+		t.Error("Attorney not revoked by the member key")
+	}
+}
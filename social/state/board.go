@@ -68,16 +68,21 @@ type PendingUpdateBoard struct {
 
 func (b *PendingUpdateBoard) IncorporateVote(vote actions.Vote, state *State) error {
 	IsNewValidVote(vote, b.Votes, b.Hash)
-	b.Votes = append(b.Votes, vote)
+	b.Votes = castVote(b.Votes, vote)
 	consensus := b.Board.Collective.Consensus(vote.Hash, b.Votes)
 	if consensus == Undecided {
 		return nil
 	}
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
+	return b.decide(consensus, state)
+}
+
+func (b *PendingUpdateBoard) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(b.Hash, consensus == Favorable)
 	state.Proposals.Delete(b.Hash)
 	if consensus == Against {
 		return nil
 	}
+	state.touchBoard(b.Board.Name)
 	if b.PinMajority != nil {
 		b.Board.Editors.ChangeMajority(int(*b.PinMajority))
 	}
@@ -102,12 +107,16 @@ func (b *PendingBoard) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, b.Votes, b.Hash); err != nil {
 		fmt.Println(err)
 	}
-	b.Votes = append(b.Votes, vote)
+	b.Votes = castVote(b.Votes, vote)
 	consensus := b.Board.Collective.Consensus(vote.Hash, b.Votes)
 	if consensus == Undecided {
 		return nil
 	}
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
+	return b.decide(consensus, state)
+}
+
+func (b *PendingBoard) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(b.Hash, consensus == Favorable)
 	state.Proposals.Delete(b.Hash)
 	if consensus == Against {
 		return nil
@@ -136,7 +145,7 @@ func (p *Pin) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	consensus := p.Board.Editors.Consensus(vote.Hash, p.Votes)
 	if consensus == Undecided {
 		return nil
@@ -146,6 +155,8 @@ func (p *Pin) IncorporateVote(vote actions.Vote, state *State) error {
 	if consensus == Against {
 		return nil
 	}
+	state.touchBoard(p.Board.Name)
+	state.touch(draftLeaf, p.Draft.DraftHash)
 	if p.Pin {
 		// coloca o pin no draft
 		p.Draft.Pinned = append(p.Draft.Pinned, p.Board)
@@ -177,16 +188,21 @@ type BoardEditor struct {
 
 func (e *BoardEditor) IncorporateVote(vote actions.Vote, state *State) error {
 	IsNewValidVote(vote, e.Votes, e.Hash)
-	e.Votes = append(e.Votes, vote)
+	e.Votes = castVote(e.Votes, vote)
 	consensus := e.Board.Collective.Consensus(vote.Hash, e.Votes)
 	if consensus == Undecided {
 		return nil
 	}
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
-	state.Proposals.Delete(vote.Hash)
+	return e.decide(consensus, state)
+}
+
+func (e *BoardEditor) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(e.Hash, consensus == Favorable)
+	state.Proposals.Delete(e.Hash)
 	if consensus == Against {
 		return nil
 	}
+	state.touchBoard(e.Board.Name)
 	if e.Insert {
 		e.Board.Editors.IncludeMember(e.Editor)
 	} else {
@@ -44,10 +44,7 @@ func (c *Collective) Photo() *Collective {
 	cloned := Collective{
 		Name:    c.Name,
 		Members: make(map[crypto.Token]struct{}),
-		Policy: actions.Policy{
-			Majority:      c.Policy.Majority,
-			SuperMajority: c.Policy.SuperMajority,
-		},
+		Policy:  clonePolicy(c.Policy),
 	}
 	for member, _ := range c.Members {
 		cloned.Members[member] = struct{}{}
@@ -68,32 +65,28 @@ func (c *Collective) ChangeMajority(majority int) {
 }
 
 func (c *Collective) Consensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	required := len(c.Members)*c.Policy.Majority/100 + 1
-	if required > len(c.Members) {
-		required = len(c.Members)
+	if strategy := c.strategy(); strategy != nil {
+		return strategy.Consensus(hash, votes)
 	}
-	return consensus(c.Members, required, len(c.Members), hash, votes)
+	return consensus(c.Members, c.Policy.Majority, c.Policy.Quorum, hash, votes)
 }
 
 func (c *Collective) ConsensusEpoch(votes []actions.Vote) uint64 {
-	required := len(c.Members)*c.Policy.Majority/100 + 1
-	if required > len(c.Members) {
-		required = len(c.Members)
+	if strategy := c.strategy(); strategy != nil {
+		return strategy.ConsensusEpoch(votes)
 	}
-	return consensusEpoch(c.Members, required, votes)
+	return consensusEpoch(c.Members, c.Policy.Majority, c.Policy.Quorum, votes)
 }
 
 func (c *Collective) Unanimous(hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	required := len(c.Members)
-	return consensus(c.Members, required, len(c.Members), hash, votes)
+	return consensus(c.Members, 100, 0, hash, votes)
 }
 
 func (c *Collective) SuperConsensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	required := len(c.Members)*c.Policy.SuperMajority/100 + 1
-	if required > len(c.Members) {
-		required = len(c.Members)
+	if strategy := c.strategy(); strategy != nil {
+		return strategy.SuperConsensus(hash, votes)
 	}
-	return consensus(c.Members, required, len(c.Members), hash, votes)
+	return consensus(c.Members, c.Policy.SuperMajority, c.Policy.Quorum, hash, votes)
 }
 
 func (c *Collective) IsMember(token crypto.Token) bool {
@@ -101,6 +94,106 @@ func (c *Collective) IsMember(token crypto.Token) bool {
 	return ok
 }
 
+// VotingWindow is the number of epochs a proposal originated by an action of
+// the given kind remains open for votes.
+func (c *Collective) VotingWindow(kind byte) uint64 {
+	if window, ok := c.Policy.VotingWindow[kind]; ok && window > 0 {
+		return window
+	}
+	return ProposalDeadline
+}
+
+// Expiry is the consensus of the collective when the voting window closes.
+func (c *Collective) Expiry(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	if !c.Policy.AcceptOnExpiry {
+		return Against
+	}
+	if strategy := c.strategy(); strategy != nil {
+		return strategy.Expiry(hash, votes)
+	}
+	return expiry(c.Members, c.Policy.Quorum, hash, votes)
+}
+
+func clonePolicy(policy actions.Policy) actions.Policy {
+	cloned := policy
+	if policy.VotingWindow != nil {
+		cloned.VotingWindow = make(map[byte]uint64, len(policy.VotingWindow))
+		for kind, window := range policy.VotingWindow {
+			cloned.VotingWindow[kind] = window
+		}
+	}
+	cloned.Strategy = cloneStrategy(policy.Strategy)
+	return cloned
+}
+
+func cloneStrategy(strategy actions.Strategy) actions.Strategy {
+	cloned := strategy
+	if strategy.Weights != nil {
+		cloned.Weights = make(map[crypto.Token]int, len(strategy.Weights))
+		for token, weight := range strategy.Weights {
+			cloned.Weights[token] = weight
+		}
+	}
+	if strategy.Roles != nil {
+		cloned.Roles = append([]crypto.Token{}, strategy.Roles...)
+	}
+	return cloned
+}
+
+func isValidStrategy(strategy actions.Strategy) bool {
+	if strategy.Kind >= actions.UnknownStrategy {
+		return false
+	}
+	for _, weight := range strategy.Weights {
+		if weight < 0 {
+			return false
+		}
+	}
+	switch strategy.Kind {
+	case actions.VetoStrategy:
+		return len(strategy.Roles) > 0
+	case actions.ReviewersStrategy:
+		return strategy.Required > 0 && strategy.Required <= len(strategy.Roles)
+	}
+	return true
+}
+
+func isValidPolicyUpdate(update *actions.UpdateCollective) bool {
+	if update.Majority != nil && *update.Majority > 100 {
+		return false
+	}
+	if update.SuperMajority != nil && *update.SuperMajority > 100 {
+		return false
+	}
+	if update.Quorum != nil && *update.Quorum > 100 {
+		return false
+	}
+	for kind := range update.VotingWindow {
+		if kind >= actions.AUnknown {
+			return false
+		}
+	}
+	if update.Strategy != nil && !isValidStrategy(*update.Strategy) {
+		return false
+	}
+	return true
+}
+
+func isValidPolicy(policy actions.Policy) bool {
+	if policy.Majority < 0 || policy.Majority > 100 || policy.SuperMajority < 0 || policy.SuperMajority > 100 {
+		return false
+	}
+	if policy.Quorum < 0 || policy.Quorum > 100 {
+		return false
+	}
+	for kind, window := range policy.VotingWindow {
+		if kind >= actions.AUnknown || window == 0 {
+			return false
+		}
+	}
+	return isValidStrategy(policy.Strategy)
+}
+
 type UnamedCollective struct {
 	Members  map[crypto.Token]struct{}
 	Majority int
@@ -119,24 +212,15 @@ func (c *UnamedCollective) ListOfTokens() map[crypto.Token]struct{} {
 }
 
 func (c *UnamedCollective) Consensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	required := len(c.Members)*c.Majority/100 + 1
-	if required > len(c.Members) {
-		required = len(c.Members)
-	}
-	return consensus(c.Members, required, len(c.Members), hash, votes)
+	return consensus(c.Members, c.Majority, 0, hash, votes)
 }
 
 func (c *UnamedCollective) ConsensusEpoch(votes []actions.Vote) uint64 {
-	required := len(c.Members)*c.Majority/100 + 1
-	if required > len(c.Members) {
-		required = len(c.Members)
-	}
-	return consensusEpoch(c.Members, required, votes)
+	return consensusEpoch(c.Members, c.Majority, 0, votes)
 }
 
 func (c *UnamedCollective) Unanimous(hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	required := len(c.Members)
-	return consensus(c.Members, required, len(c.Members), hash, votes)
+	return consensus(c.Members, 100, 0, hash, votes)
 }
 
 func (c *UnamedCollective) IsMember(token crypto.Token) bool {
@@ -171,10 +255,10 @@ type PendingUpdate struct {
 }
 
 func (p *PendingUpdate) IncorporateVote(vote actions.Vote, state *State) error {
-	if err := isValidVote(p.Hash, vote, p.Votes); err != nil {
+	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if p.ChangePolicy {
 		if p.Collective.SuperConsensus(p.Hash, p.Votes) != Favorable {
 			return nil
@@ -184,8 +268,16 @@ func (p *PendingUpdate) IncorporateVote(vote actions.Vote, state *State) error {
 			return nil
 		}
 	}
+	return p.decide(Favorable, state)
+}
+
+func (p *PendingUpdate) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(p.Hash, consensus == Favorable)
 	// exclude pending update from live proposals because of consensus
 	state.Proposals.Delete(p.Hash)
+	if consensus == Against {
+		return nil
+	}
 	// update collective
 
 	// p.Collective is a photo, we need the original to update
@@ -193,15 +285,12 @@ func (p *PendingUpdate) IncorporateVote(vote actions.Vote, state *State) error {
 	if !ok {
 		return nil
 	}
-
+	state.touchCollective(collective.Name)
 	if p.Update.Description != nil {
 		collective.Description = *p.Update.Description
 	}
 	if p.ChangePolicy {
-		newPolicy := actions.Policy{
-			Majority:      p.Collective.Policy.Majority,
-			SuperMajority: p.Collective.Policy.SuperMajority,
-		}
+		newPolicy := clonePolicy(p.Collective.Policy)
 
 		if p.Update.Majority != nil {
 			newPolicy.Majority = int(*p.Update.Majority)
@@ -209,6 +298,28 @@ func (p *PendingUpdate) IncorporateVote(vote actions.Vote, state *State) error {
 		if p.Update.SuperMajority != nil {
 			newPolicy.SuperMajority = int(*p.Update.SuperMajority)
 		}
+		if p.Update.Quorum != nil {
+			newPolicy.Quorum = int(*p.Update.Quorum)
+		}
+		if p.Update.AcceptOnExpiry != nil {
+			newPolicy.AcceptOnExpiry = *p.Update.AcceptOnExpiry
+		}
+		for kind, window := range p.Update.VotingWindow {
+			if window == 0 {
+				delete(newPolicy.VotingWindow, kind)
+				continue
+			}
+			if newPolicy.VotingWindow == nil {
+				newPolicy.VotingWindow = make(map[byte]uint64)
+			}
+			newPolicy.VotingWindow[kind] = window
+		}
+		if len(newPolicy.VotingWindow) == 0 {
+			newPolicy.VotingWindow = nil
+		}
+		if p.Update.Strategy != nil {
+			newPolicy.Strategy = cloneStrategy(*p.Update.Strategy)
+		}
 		collective.Policy = newPolicy
 	}
 	return nil
@@ -222,21 +333,29 @@ type PendingRequestMembership struct {
 }
 
 func (p *PendingRequestMembership) IncorporateVote(vote actions.Vote, state *State) error {
-	if err := isValidVote(p.Hash, vote, p.Votes); err != nil {
+	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	consensus := p.Collective.Consensus(p.Hash, p.Votes)
 	if consensus == Undecided {
 		return nil
 	}
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
+	return p.decide(consensus, state)
+}
+
+func (p *PendingRequestMembership) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(p.Hash, consensus == Favorable)
 	state.Proposals.Delete(p.Hash)
+	if consensus == Against {
+		return nil
+	}
 	collective, ok := state.Collective(p.Collective.Name)
 	if !ok {
 		return errors.New("collective not found")
 	}
-	collective.Members[p.Request.Author] = struct{}{}
+	state.touchCollective(collective.Name)
+	collective.Members[state.Current(p.Request.Author)] = struct{}{}
 	return nil
 }
 
@@ -248,20 +367,28 @@ type PendingRemoveMember struct {
 }
 
 func (p *PendingRemoveMember) IncorporateVote(vote actions.Vote, state *State) error {
-	if err := isValidVote(p.Hash, vote, p.Votes); err != nil {
+	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	consensus := p.Collective.Consensus(p.Hash, p.Votes)
 	if consensus == Undecided {
 		return nil
 	}
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
+	return p.decide(consensus, state)
+}
+
+func (p *PendingRemoveMember) decide(consensus ConsensusState, state *State) error {
+	state.IndexConsensus(p.Hash, consensus == Favorable)
 	state.Proposals.Delete(p.Hash)
+	if consensus == Against {
+		return nil
+	}
 	collective, ok := state.Collective(p.Collective.Name)
 	if !ok {
 		return errors.New("collective not found")
 	}
-	delete(collective.Members, p.Remove.Member)
+	state.touchCollective(collective.Name)
+	delete(collective.Members, state.Current(p.Remove.Member))
 	return nil
 }
@@ -0,0 +1,133 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// Comment on a draft, an edit or a release. Comments on behalf of a collective remain
+// pending until the collective consents.
+type Comment struct {
+	Hash       crypto.Hash
+	Epoch      uint64
+	Author     crypto.Token
+	Collective *Collective // nil se o comentario eh do proprio autor
+	Object     crypto.Hash // hash do draft, do edit ou do release comentado
+	Parent     *Comment    // nil se o comentario abre uma thread
+	Content    string
+	Replies    []*Comment
+	Votes      []actions.Vote
+	Published  bool
+}
+
+func (c *Comment) IncorporateVote(vote actions.Vote, state *State) error {
+	if err := IsNewValidVote(vote, c.Votes, c.Hash); err != nil {
+		return err
+	}
+	if !c.Collective.IsMember(vote.Author) {
+		return errors.New("author is not a recognized member of the collective")
+	}
+	c.Votes = castVote(c.Votes, vote)
+	if c.Published {
+		return nil
+	}
+	consensus := c.Collective.Consensus(vote.Hash, c.Votes)
+	if consensus == Undecided {
+		return nil
+	}
+	return c.decide(consensus, state)
+}
+
+func (c *Comment) decide(consensus ConsensusState, state *State) error {
+	// new consensus
+	state.IndexConsensus(c.Hash, consensus == Favorable)
+	state.Proposals.Delete(c.Hash)
+	if consensus == Favorable {
+		state.publishComment(c)
+	}
+	return nil
+}
+
+// Thread returns the comments opening threads on the draft, edit or release
+// with the given hash, in the order they were published.
+func (s *State) Thread(object crypto.Hash) []*Comment {
+	return s.Threads[object]
+}
+
+func (s *State) publishComment(c *Comment) {
+	c.Published = true
+	s.Comments[c.Hash] = c
+	s.touch(commentLeaf, c.Hash)
+	if c.Parent != nil {
+		c.Parent.Replies = append(c.Parent.Replies, c)
+		s.touch(commentLeaf, c.Parent.Hash)
+	} else {
+		s.Threads[c.Object] = append(s.Threads[c.Object], c)
+		s.touch(threadLeaf, c.Object)
+	}
+	if s.index != nil {
+		s.index.AddCommentToIndex(c)
+	}
+}
+
+func (s *State) Comment(comment *actions.Comment) error {
+	if !s.IsMember(comment.Author) {
+		return errors.New("not a member")
+	}
+	if comment.Content == "" {
+		return errors.New("empty comment")
+	}
+	_, isDraft := s.Drafts[comment.Object]
+	_, isEdit := s.Edits[comment.Object]
+	if !isDraft && !isEdit {
+		if _, isRelease := s.Release(comment.Object); !isRelease {
+			return errors.New("draft, edit or release not found")
+		}
+	}
+	hash := comment.Hashed()
+	if _, ok := s.Comments[hash]; ok || s.Proposals.Has(hash) {
+		return errors.New("comment already incorporated")
+	}
+	newComment := Comment{
+		Hash:    hash,
+		Epoch:   comment.Epoch,
+		Author:  comment.Author,
+		Object:  comment.Object,
+		Content: comment.Content,
+		Votes:   []actions.Vote{},
+	}
+	if !comment.Parent.Equal(crypto.ZeroValueHash) {
+		parent, ok := s.Comments[comment.Parent]
+		if !ok {
+			return errors.New("parent comment not found")
+		}
+		if !parent.Object.Equal(comment.Object) {
+			return errors.New("parent comment on another object")
+		}
+		newComment.Parent = parent
+	}
+	if comment.OnBehalfOf == "" {
+		s.publishComment(&newComment)
+		return nil
+	}
+	collective, ok := s.Collective(comment.OnBehalfOf)
+	if !ok {
+		return errors.New("collective not found")
+	}
+	if !collective.IsMember(comment.Author) {
+		return errors.New("not a member of the collective")
+	}
+	newComment.Collective = collective
+	vote := actions.Vote{
+		Epoch:   comment.Epoch,
+		Author:  comment.Author,
+		Reasons: "commit",
+		Hash:    hash,
+		Approve: true,
+	}
+	s.Proposals.AddComment(&newComment, comment)
+	s.setDeadline(comment.Epoch+collective.VotingWindow(actions.AComment), hash)
+	return newComment.IncorporateVote(vote, s)
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestComment(t *testing.T) {
+	s := snapshotTestState(t)
+	author, other := s.MembersIndex["author"], s.MembersIndex["other"]
+	first, edit := crypto.Hasher([]byte("first")), crypto.Hasher([]byte("edit"))
+
+	if err := s.Comment(&actions.Comment{Epoch: 42, Author: author, Object: crypto.Hasher([]byte("nothing")), Content: "lost"}); err == nil {
+		t.Error("Comment on unknown object accepted")
+	}
+	if err := s.Comment(&actions.Comment{Epoch: 42, Author: author, Object: first}); err == nil {
+		t.Error("Empty comment accepted")
+	}
+	opening := &actions.Comment{Epoch: 42, Author: author, Object: first, Content: "opening"}
+	if err := s.Comment(opening); err != nil {
+		t.Fatalf("Could not comment: %v", err)
+	}
+	if err := s.Comment(opening); err == nil {
+		t.Error("Repeated comment accepted")
+	}
+	reply := &actions.Comment{Epoch: 42, Author: other, Object: first, Parent: opening.Hashed(), Content: "reply"}
+	if err := s.Comment(reply); err != nil {
+		t.Fatalf("Could not reply: %v", err)
+	}
+	if err := s.Comment(&actions.Comment{Epoch: 42, Author: other, Object: edit, Parent: opening.Hashed(), Content: "elsewhere"}); err == nil {
+		t.Error("Reply on another object accepted")
+	}
+	if err := s.Comment(&actions.Comment{Epoch: 42, Author: other, Object: first, Parent: crypto.Hasher([]byte("unknown")), Content: "orphan"}); err == nil {
+		t.Error("Reply to unknown comment accepted")
+	}
+	thread := s.Thread(first)
+	if len(thread) != 1 || thread[0].Content != "opening" || len(thread[0].Replies) != 1 || thread[0].Replies[0].Parent != thread[0] {
+		t.Fatal("Thread not built")
+	}
+
+	collective := &actions.Comment{Epoch: 42, Author: author, OnBehalfOf: "collective", Object: edit, Content: "collective"}
+	if err := s.Comment(collective); err != nil {
+		t.Fatalf("Could not comment on behalf of collective: %v", err)
+	}
+	if len(s.Thread(edit)) != 0 || s.Proposals.Kind(collective.Hashed()) != CommentProposal {
+		t.Fatal("Comment on behalf of collective published without consensus")
+	}
+	restored, err := RestoreSnapshot(s.Snapshot(), nil)
+	if err != nil || !restored.StateRoot().Equal(s.StateRoot()) {
+		t.Fatalf("Comments not restored: %v", err)
+	}
+	if thread := restored.Thread(first); len(thread) != 1 || len(thread[0].Replies) != 1 || thread[0].Replies[0] != restored.Comments[reply.Hashed()] {
+		t.Error("Thread not restored")
+	}
+
+	vote := &actions.Vote{Epoch: 42, Author: other, Hash: collective.Hashed(), Approve: true}
+	if err := s.Vote(vote); err != nil {
+		t.Fatalf("Could not vote: %v", err)
+	}
+	if thread := s.Thread(edit); len(thread) != 1 || thread[0].Collective == nil || s.Proposals.Has(collective.Hashed()) {
+		t.Error("Comment on behalf of collective not published on consensus")
+	}
+}
+
+func TestCommentOnRelease(t *testing.T) {
+	s := snapshotTestState(t)
+	author := s.MembersIndex["author"]
+	release := crypto.Hasher([]byte("release"))
+	comment := &actions.Comment{Epoch: 42, Author: author, Object: release, Content: "on the release"}
+	if err := s.Comment(comment); err != nil {
+		t.Fatalf("Could not comment on release: %v", err)
+	}
+	thread := s.Thread(release)
+	if len(thread) != 1 || thread[0].Content != "on the release" {
+		t.Fatal("Thread on release not built")
+	}
+	if object, _, _ := s.objectOf(release); object != DraftObject {
+		t.Error("Comment on release not scoped to its draft")
+	}
+}
@@ -1,8 +1,6 @@
 package state
 
 import (
-	"errors"
-
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/synergy/social/actions"
 )
@@ -21,52 +19,110 @@ type Consensual interface {
 	Unanimous(hash crypto.Hash, votes []actions.Vote) ConsensusState
 }
 
-func consensus(members map[crypto.Token]struct{}, votesRequired int, votesCount int, hash crypto.Hash, votes []actions.Vote) ConsensusState {
-	favor := 0
-	against := 0
+// votesRequired is the number of favorable votes for a majority (in %) of
+// voters. Members that abstain are not voters.
+func votesRequired(voters int, majority int) int {
+	required := voters*majority/100 + 1
+	if required > voters {
+		required = voters
+	}
+	return required
+}
+
+// quorumMet checks if the members that cast a vote, abstentions included, are
+// at least quorum % of all members.
+func quorumMet(members int, quorum int, cast int) bool {
+	return cast*100 >= quorum*members
+}
+
+// lastVotes considers only the last vote of each member: a member may change
+// the vote while the proposal is undecided.
+func lastVotes(members map[crypto.Token]struct{}, hash crypto.Hash, votes []actions.Vote) map[crypto.Token]actions.Vote {
+	cast := make(map[crypto.Token]actions.Vote)
 	for _, vote := range votes {
-		_, isMember := members[vote.Author]
-		if isMember && hash == vote.Hash {
-			if vote.Approve {
-				favor += 1
-				if favor >= votesRequired {
-					return Favorable
-				}
-			} else {
-				against += 1
-				if against > (votesCount - votesRequired) {
-					return Against
-				}
-			}
+		if _, isMember := members[vote.Author]; isMember && hash == vote.Hash {
+			cast[vote.Author] = vote
 		}
 	}
+	return cast
+}
+
+func tally(members map[crypto.Token]struct{}, hash crypto.Hash, votes []actions.Vote) (favor, against, abstain int) {
+	for _, vote := range lastVotes(members, hash, votes) {
+		if vote.Abstain {
+			abstain += 1
+		} else if vote.Approve {
+			favor += 1
+		} else {
+			against += 1
+		}
+	}
+	return
+}
+
+// majorityOf decides by a majority (in %) of the total that did not abstain,
+// once the quorum (in %) of the total has voted.
+func majorityOf(favor, against, abstain, total int, majority int, quorum int) ConsensusState {
+	voters := total - abstain
+	if voters <= 0 {
+		return Undecided
+	}
+	required := votesRequired(voters, majority)
+	if against > voters-required {
+		return Against
+	}
+	if favor >= required && quorumMet(total, quorum, favor+against+abstain) {
+		return Favorable
+	}
 	return Undecided
 }
 
-func consensusEpoch(members map[crypto.Token]struct{}, votesRequired int, votes []actions.Vote) uint64 {
-	count := 0
+func consensus(members map[crypto.Token]struct{}, majority int, quorum int, hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	favor, against, abstain := tally(members, hash, votes)
+	return majorityOf(favor, against, abstain, len(members), majority, quorum)
+}
+
+// expiry is the consensus when the voting window closes: favorable if the
+// quorum was met with more favorable than against votes.
+func expiry(members map[crypto.Token]struct{}, quorum int, hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	favor, against, abstain := tally(members, hash, votes)
+	if favor > against && quorumMet(len(members), quorum, favor+against+abstain) {
+		return Favorable
+	}
+	return Against
+}
+
+func consensusEpoch(members map[crypto.Token]struct{}, majority int, quorum int, votes []actions.Vote) uint64 {
+	cast := make(map[crypto.Token]actions.Vote)
 	for _, vote := range votes {
-		_, isMember := members[vote.Author]
-		if isMember && vote.Approve {
-			count += 1
-			if count >= votesRequired {
-				return vote.Epoch
+		if _, isMember := members[vote.Author]; !isMember {
+			continue
+		}
+		cast[vote.Author] = vote
+		favor, abstain := 0, 0
+		for _, vote := range cast {
+			if vote.Abstain {
+				abstain += 1
+			} else if vote.Approve {
+				favor += 1
 			}
 		}
+		voters := len(members) - abstain
+		if voters > 0 && favor >= votesRequired(voters, majority) && quorumMet(len(members), quorum, len(cast)) {
+			return vote.Epoch
+		}
 	}
 	return 0
 }
 
-func isValidVote(hash crypto.Hash, vote actions.Vote, signatures []actions.Vote) error {
-	if vote.Hash != hash {
-		return errors.New("invalid hash")
-	}
-	for _, cast := range signatures {
-		if cast.Author == vote.Author {
-			return errors.New("vote already cast")
+// epochOf is the epoch of the vote that formed a favorable consensus.
+func epochOf(c Consensual, votes []actions.Vote) uint64 {
+	for n, vote := range votes {
+		if c.Consensus(vote.Hash, votes[:n+1]) == Favorable {
+			return vote.Epoch
 		}
 	}
-	return nil
+	return 0
 }
 
 func Authors(majority int, tokens ...crypto.Token) Consensual {
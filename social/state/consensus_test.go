@@ -0,0 +1,124 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestConsensus(t *testing.T) {
+	hash := crypto.Hasher([]byte("proposal"))
+	tokens := make([]crypto.Token, 4)
+	for n := range tokens {
+		tokens[n], _ = crypto.RandomAsymetricKey()
+	}
+	collective := Authors(50, tokens...)
+	votes := make([]actions.Vote, 0)
+	epoch := uint64(0)
+	cast := func(n int, approve, abstain bool) error {
+		epoch += 1
+		vote := actions.Vote{Epoch: epoch, Author: tokens[n], Hash: hash, Approve: approve, Abstain: abstain}
+		if err := IsNewValidVote(vote, votes, hash); err != nil {
+			return err
+		}
+		votes = castVote(votes, vote)
+		return nil
+	}
+
+	cast(0, false, false)
+	if err := cast(0, false, false); err == nil {
+		t.Error("Repeated vote accepted")
+	}
+	if err := cast(0, true, false); err != nil {
+		t.Fatalf("Could not change vote: %v", err)
+	}
+	cast(1, true, false)
+	if len(votes) != 2 || collective.Consensus(hash, votes) != Undecided {
+		t.Fatal("Changed vote counted twice")
+	}
+	// with an abstention 2 of the 3 remaining voters are a majority
+	cast(2, true, true)
+	if collective.Consensus(hash, votes) != Favorable || collective.ConsensusEpoch(votes) != 5 {
+		t.Error("Abstention counted toward majority")
+	}
+	if err := cast(2, false, true); err == nil {
+		t.Error("Repeated abstention accepted")
+	}
+
+	abstained := make([]actions.Vote, 0)
+	for _, token := range tokens {
+		abstained = castVote(abstained, actions.Vote{Author: token, Hash: hash, Abstain: true})
+	}
+	if collective.Consensus(hash, abstained) != Undecided {
+		t.Error("Consensus without voters")
+	}
+}
+
+func TestAbstainOnProposal(t *testing.T) {
+	s := snapshotTestState(t)
+	author, other := s.MembersIndex["author"], s.MembersIndex["other"]
+	comment := &actions.Comment{Epoch: 42, Author: author, OnBehalfOf: "collective", Object: crypto.Hasher([]byte("first")), Content: "pending"}
+	if err := s.Comment(comment); err != nil {
+		t.Fatalf("Could not comment: %v", err)
+	}
+	if err := s.Vote(&actions.Vote{Epoch: 43, Author: other, Hash: comment.Hashed(), Abstain: true}); err != nil {
+		t.Fatalf("Could not abstain: %v", err)
+	}
+	if s.Proposals.Has(comment.Hashed()) || len(s.Thread(comment.Object)) != 1 {
+		t.Error("Abstention blocked consensus of the remaining voters")
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	hash := crypto.Hasher([]byte("proposal"))
+	collective := &Collective{Name: "quorum", Members: make(map[crypto.Token]struct{}), Policy: actions.Policy{Majority: 50, Quorum: 100}}
+	tokens := make([]crypto.Token, 4)
+	for n := range tokens {
+		tokens[n], _ = crypto.RandomAsymetricKey()
+		collective.Members[tokens[n]] = struct{}{}
+	}
+	votes := []actions.Vote{
+		{Author: tokens[0], Hash: hash, Approve: true},
+		{Author: tokens[1], Hash: hash, Approve: true},
+		{Author: tokens[2], Hash: hash, Abstain: true},
+	}
+	if collective.Consensus(hash, votes) != Undecided {
+		t.Error("Consensus without quorum")
+	}
+	votes = append(votes, actions.Vote{Author: tokens[3], Hash: hash, Abstain: true})
+	if collective.Consensus(hash, votes) != Favorable {
+		t.Error("Abstentions not counted toward quorum")
+	}
+}
+
+func TestProposalExpiry(t *testing.T) {
+	for _, quorum := range []int{100, 50} {
+		s := snapshotTestState(t)
+		author := s.MembersIndex["author"]
+		collective, _ := s.Collective("collective")
+		collective.Policy.Quorum = quorum
+		collective.Policy.AcceptOnExpiry = true
+		collective.Policy.VotingWindow = map[byte]uint64{actions.AComment: 5}
+		comment := &actions.Comment{Epoch: s.Epoch, Author: author, OnBehalfOf: "collective", Object: crypto.Hasher([]byte("first")), Content: "expiring"}
+		if err := s.Comment(comment); err != nil {
+			t.Fatalf("Could not comment: %v", err)
+		}
+		for n := 0; n <= 5; n++ {
+			if !s.Proposals.Has(comment.Hashed()) {
+				t.Fatal("Proposal expired before the voting window")
+			}
+			s.NextBlock()
+		}
+		if s.Proposals.Has(comment.Hashed()) {
+			t.Fatal("Proposal not expired after the voting window")
+		}
+		published := len(s.Thread(comment.Object)) == 1
+		if quorum == 100 && published {
+			t.Error("Proposal without quorum accepted on expiry")
+		}
+		if quorum == 50 && !published {
+			t.Error("Proposal with quorum not accepted on expiry")
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package state
 
 import (
+	"errors"
+
 	"github.com/lienkolabs/breeze/crypto"
 	"github.com/lienkolabs/synergy/social/actions"
 )
@@ -90,7 +92,10 @@ func (d *Draft) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, d.Votes, d.DraftHash); err != nil {
 		return err
 	}
-	d.Votes = append(d.Votes, vote)
+	if d.Aproved && hasVoted(d.Votes, vote.Author) {
+		return errors.New("vote cannot change after consensus")
+	}
+	d.Votes = castVote(d.Votes, vote)
 	if d.Aproved {
 		return nil
 	}
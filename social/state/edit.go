@@ -19,7 +19,7 @@ func (e *Edit) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, e.Votes, e.Edit); err != nil {
 		return err
 	}
-	e.Votes = append(e.Votes, vote)
+	e.Votes = castVote(e.Votes, vote)
 	consensus := e.Authors.Consensus(e.Edit, e.Votes)
 	if consensus == Undecided {
 		return nil
@@ -27,6 +27,7 @@ func (e *Edit) IncorporateVote(vote actions.Vote, state *State) error {
 	// new consensus
 	if consensus == Favorable {
 		e.Draft.Edits = append(e.Draft.Edits, e)
+		state.touch(draftLeaf, e.Draft.DraftHash)
 	}
 	state.Edits[e.Edit] = e
 	state.Proposals.Delete(e.Edit)
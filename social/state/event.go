@@ -34,7 +34,7 @@ func (p *Event) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if p.Live {
 		return nil
 	}
@@ -42,6 +42,10 @@ func (p *Event) IncorporateVote(vote actions.Vote, state *State) error {
 	if consensus == Undecided {
 		return nil
 	}
+	return p.decide(consensus, state)
+}
+
+func (p *Event) decide(consensus ConsensusState, state *State) error {
 	// new consensus
 	state.IndexConsensus(p.Hash, consensus == Favorable)
 	state.Proposals.Delete(p.Hash)
@@ -79,7 +83,7 @@ func (p *EventUpdate) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if p.Updated {
 		return nil
 	}
@@ -95,6 +99,7 @@ func (p *EventUpdate) IncorporateVote(vote actions.Vote, state *State) error {
 	}
 	p.Updated = true
 	if event := p.Event; event != nil {
+		state.touch(eventLeaf, event.Hash)
 		if p.StartAt != nil {
 			event.StartAt = *p.StartAt
 		}
@@ -132,7 +137,7 @@ func (p *CancelEvent) IncorporateVote(vote actions.Vote, state *State) error {
 	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if !p.Event.Live {
 		return nil
 	}
@@ -144,6 +149,7 @@ func (p *CancelEvent) IncorporateVote(vote actions.Vote, state *State) error {
 	state.IndexConsensus(vote.Hash, consensus == Favorable)
 	if consensus == Favorable {
 		p.Event.Live = false
+		state.touch(eventLeaf, p.Event.Hash)
 		if state.index != nil {
 			state.index.RemoveEventFromCollective(p.Event, p.Event.Collective)
 		}
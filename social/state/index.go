@@ -18,4 +18,8 @@ type Indexer interface {
 	AddDraftToIndex(*Draft)
 	AddEditToIndex(*Edit)
 	AddCheckin(crypto.Token, *Event)
+	RotateMember(old, new crypto.Token)
+	ChangeHandle(crypto.Token, string)
+	AddCommentToIndex(*Comment)
+	Reset(*State)
 }
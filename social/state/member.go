@@ -0,0 +1,207 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+var ErrRotationByAttorney = errors.New("key rotation must be signed by the member key")
+
+// Current returns the token that holds the identity of token after every
+// rotation since.
+func (s *State) Current(token crypto.Token) crypto.Token {
+	for {
+		next, ok := s.Rotated[token]
+		if !ok {
+			return token
+		}
+		token = next
+	}
+}
+
+// isFreeKey checks if token was never used by a member and is not about to be
+func (s *State) isFreeKey(token crypto.Token) bool {
+	if s.IsMember(token) {
+		return false
+	}
+	if _, ok := s.Rotated[token]; ok {
+		return false
+	}
+	for _, pending := range s.Rotations {
+		if pending.NewKey.Equal(token) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *State) RotateKey(rotate *actions.RotateKey) error {
+	if !s.IsMember(rotate.Author) {
+		return errors.New("not a member of synergy")
+	}
+	_, pending := s.Rotations[rotate.Author]
+	if rotate.NewKey.Equal(rotate.Author) {
+		// rotation to the key itself cancels the pending one
+		if !pending {
+			return errors.New("no pending rotation")
+		}
+		delete(s.Rotations, rotate.Author)
+		s.touchToken(rotationLeaf, rotate.Author)
+		return nil
+	}
+	if pending {
+		return errors.New("rotation already pending")
+	}
+	if !s.isFreeKey(rotate.NewKey) {
+		return errors.New("new key already in use")
+	}
+	if rotate.TimeLock > s.Epoch {
+		s.Rotations[rotate.Author] = rotate
+		s.touchToken(rotationLeaf, rotate.Author)
+		return nil
+	}
+	s.rotateMember(rotate.Author, rotate.NewKey)
+	return nil
+}
+
+// applyRotations rotates the keys whose time-lock is over on the current
+// epoch.
+func (s *State) applyRotations() {
+	due := make([]crypto.Token, 0)
+	for token, rotate := range s.Rotations {
+		if rotate.TimeLock <= s.Epoch {
+			due = append(due, token)
+		}
+	}
+	for _, token := range sortTokens(due) {
+		rotate := s.Rotations[token]
+		delete(s.Rotations, token)
+		if !s.IsMember(token) || s.IsMember(rotate.NewKey) {
+			continue
+		}
+		s.rotateMember(token, rotate.NewKey)
+		s.Notify(MemberAction, crypto.HashToken(rotate.NewKey))
+	}
+}
+
+func rotateSet(set map[crypto.Token]struct{}, old, new crypto.Token) {
+	if _, ok := set[old]; ok {
+		delete(set, old)
+		set[new] = struct{}{}
+	}
+}
+
+func rotateVotes(votes []actions.Vote, old, new crypto.Token) {
+	for n := range votes {
+		if votes[n].Author.Equal(old) {
+			votes[n].Author = new
+		}
+	}
+}
+
+// rotateMember moves every reference to old on the state to new
+func (s *State) rotateMember(old, new crypto.Token) {
+	// the old token may be anywhere in the state
+	s.touchAll()
+	hash := crypto.HashToken(old)
+	handle := s.Members[hash]
+	delete(s.Members, hash)
+	s.Members[crypto.HashToken(new)] = handle
+	s.MembersIndex[handle] = new
+	s.Rotated[old] = new
+
+	if granted, ok := s.Attorneys[old]; ok {
+		delete(s.Attorneys, old)
+		s.Attorneys[new] = granted
+	}
+	for _, granted := range s.Attorneys {
+		rotateSet(granted, old, new)
+	}
+
+	w := &snapshotWriter{ids: make(map[interface{}]uint32)}
+	w.visitState(s)
+	for _, c := range w.collectives {
+		rotateSet(c.Members, old, new)
+		if weight, ok := c.Policy.Strategy.Weights[old]; ok {
+			delete(c.Policy.Strategy.Weights, old)
+			c.Policy.Strategy.Weights[new] = weight
+		}
+		for n, token := range c.Policy.Strategy.Roles {
+			if token.Equal(old) {
+				c.Policy.Strategy.Roles[n] = new
+			}
+		}
+	}
+	for _, c := range w.unamed {
+		rotateSet(c.Members, old, new)
+	}
+	for _, d := range w.drafts {
+		rotateVotes(d.Votes, old, new)
+	}
+	for _, e := range w.edits {
+		rotateVotes(e.Votes, old, new)
+	}
+	for _, r := range w.releases {
+		rotateVotes(r.Votes, old, new)
+	}
+	for _, stamp := range w.stamps {
+		rotateVotes(stamp.Votes, old, new)
+	}
+	for _, e := range w.events {
+		rotateVotes(e.Votes, old, new)
+		if greeting, ok := e.Checkin[old]; ok {
+			delete(e.Checkin, old)
+			e.Checkin[new] = greeting
+		}
+		if reasons, ok := e.CheckinReasons[old]; ok {
+			delete(e.CheckinReasons, old)
+			e.CheckinReasons[new] = reasons
+		}
+	}
+	for _, comment := range s.Comments {
+		if comment.Author.Equal(old) {
+			comment.Author = new
+		}
+	}
+	for hash, author := range s.Proposals.authors {
+		if author.Equal(old) {
+			s.Proposals.authors[hash] = new
+		}
+	}
+	for hash := range s.Proposals.all {
+		rotateVotes(s.Proposals.Votes(hash), old, new)
+		if editor, ok := s.Proposals.BoardEditor[hash]; ok && editor.Editor.Equal(old) {
+			editor.Editor = new
+		}
+		if comment, ok := s.Proposals.Comment[hash]; ok && comment.Author.Equal(old) {
+			comment.Author = new
+		}
+	}
+	if s.index != nil {
+		s.index.RotateMember(old, new)
+	}
+}
+
+func (s *State) ChangeHandle(change *actions.ChangeHandle) error {
+	hash := crypto.HashToken(change.Author)
+	previous, ok := s.Members[hash]
+	if !ok {
+		return errors.New("not a member of synergy")
+	}
+	if change.Handle == "" {
+		return errors.New("invalid handle")
+	}
+	if _, ok := s.MembersIndex[change.Handle]; ok {
+		return errors.New("handle already taken")
+	}
+	delete(s.MembersIndex, previous)
+	s.MembersIndex[change.Handle] = change.Author
+	s.Members[hash] = change.Handle
+	s.touch(memberLeaf, hash)
+	if s.index != nil {
+		s.index.ChangeHandle(change.Author, change.Handle)
+	}
+	return nil
+}
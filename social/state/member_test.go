@@ -0,0 +1,132 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestRotateKey(t *testing.T) {
+	s := snapshotTestState(t)
+	author, other := s.MembersIndex["author"], s.MembersIndex["other"]
+	newKey, _ := crypto.RandomAsymetricKey()
+	if err := s.RotateKey(&actions.RotateKey{Epoch: 42, Author: author, NewKey: other}); err == nil {
+		t.Error("Rotation to the key of another member accepted")
+	}
+	if err := s.RotateKey(&actions.RotateKey{Epoch: 42, Author: author, NewKey: newKey, TimeLock: 44}); err != nil {
+		t.Fatalf("Could not rotate key: %v", err)
+	}
+	if err := s.RotateKey(&actions.RotateKey{Epoch: 42, Author: author, NewKey: other, TimeLock: 44}); err == nil {
+		t.Error("Second pending rotation accepted")
+	}
+	if err := s.SignIn(&actions.Signin{Epoch: 42, Author: newKey, Handle: "newcomer"}); err == nil {
+		t.Error("Sign in with the key of a pending rotation accepted")
+	}
+	restored, err := RestoreSnapshot(s.Snapshot(), nil)
+	if err != nil || restored.Rotations[author] == nil || !restored.StateRoot().Equal(s.StateRoot()) {
+		t.Fatalf("Pending rotation not restored: %v", err)
+	}
+
+	s.NextBlock()
+	if !s.IsMember(author) || s.IsMember(newKey) {
+		t.Fatal("Key rotated before the time-lock")
+	}
+	s.NextBlock()
+	if s.IsMember(author) || !s.IsMember(newKey) || !s.MembersIndex["author"].Equal(newKey) {
+		t.Fatal("Key not rotated after the time-lock")
+	}
+	if !s.Current(author).Equal(newKey) {
+		t.Error("Rotated key not followed")
+	}
+
+	collective, _ := s.Collective("collective")
+	board, _ := s.Board("board")
+	first := s.Drafts[crypto.Hasher([]byte("first"))]
+	event := s.Events[crypto.Hasher([]byte("event"))]
+	update := s.Proposals.UpdateCollective[crypto.Hasher([]byte("update"))]
+	for name, members := range map[string]Consensual{
+		"collective": collective,
+		"photo":      update.Collective,
+		"editors":    board.Editors,
+		"authors":    first.Authors,
+		"managers":   event.Managers,
+	} {
+		if members.IsMember(author) || !members.IsMember(newKey) {
+			t.Errorf("Rotation not followed by %v", name)
+		}
+	}
+	if !first.Votes[0].Author.Equal(newKey) {
+		t.Error("Rotation not followed by votes")
+	}
+	if _, ok := s.Attorneys[newKey]; !ok {
+		t.Error("Rotation not followed by attorneys")
+	}
+	if err := s.SignIn(&actions.Signin{Epoch: 44, Author: author, Handle: "thief"}); err == nil {
+		t.Error("Sign in with a retired key accepted")
+	}
+	restored, err = RestoreSnapshot(s.Snapshot(), nil)
+	if err != nil || !restored.Rotated[author].Equal(newKey) {
+		t.Fatalf("Rotated keys not restored: %v", err)
+	}
+}
+
+func TestRotateKeyAction(t *testing.T) {
+	s := GenesisState(nil)
+	author, key := crypto.RandomAsymetricKey()
+	_, attorney := crypto.RandomAsymetricKey()
+	signin := &actions.Signin{Epoch: 0, Author: author, Handle: "author"}
+	if err := s.Action(actions.Dress(signin.Serialize(), 0, author, attorney, attorney, 0)); err != nil {
+		t.Fatalf("could not sign in: %v", err)
+	}
+	newKey, _ := crypto.RandomAsymetricKey()
+	rotate := &actions.RotateKey{Epoch: 0, Author: author, NewKey: newKey, TimeLock: 2}
+	if err := s.Action(actions.Dress(rotate.Serialize(), 0, author, attorney, attorney, 0)); err != ErrRotationByAttorney {
+		t.Fatalf("Rotation signed by attorney: %v", err)
+	}
+	if err := s.Action(actions.Dress(rotate.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("Could not rotate key: %v", err)
+	}
+	cancel := &actions.RotateKey{Epoch: 0, Author: author, NewKey: author}
+	if err := s.Action(actions.Dress(cancel.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("Could not cancel rotation: %v", err)
+	}
+	s.NextBlock()
+	s.NextBlock()
+	if !s.IsMember(author) || s.IsMember(newKey) {
+		t.Error("Canceled rotation applied")
+	}
+	immediate := &actions.RotateKey{Epoch: 2, Author: author, NewKey: newKey}
+	if err := s.Action(actions.Dress(immediate.Serialize(), 2, author, key, key, 0)); err != nil {
+		t.Fatalf("Could not rotate key: %v", err)
+	}
+	if s.IsMember(author) || !s.IsMember(newKey) {
+		t.Error("Rotation without time-lock not immediate")
+	}
+	if !s.IsAttorney(newKey, attorney.PublicKey()) {
+		t.Error("Power of attorney lost on rotation")
+	}
+}
+
+func TestChangeHandle(t *testing.T) {
+	s := GenesisState(nil)
+	author, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	s.SignIn(&actions.Signin{Epoch: 0, Author: author, Handle: "author"})
+	if err := s.SignIn(&actions.Signin{Epoch: 0, Author: other, Handle: "author"}); err == nil {
+		t.Fatal("Sign in with a handle in use accepted")
+	}
+	s.SignIn(&actions.Signin{Epoch: 0, Author: other, Handle: "other"})
+	if err := s.ChangeHandle(&actions.ChangeHandle{Epoch: 0, Author: author, Handle: "other"}); err == nil {
+		t.Error("Change to a handle in use accepted")
+	}
+	if err := s.ChangeHandle(&actions.ChangeHandle{Epoch: 0, Author: author, Handle: "renamed"}); err != nil {
+		t.Fatalf("Could not change handle: %v", err)
+	}
+	if _, ok := s.MembersIndex["author"]; ok || !s.MembersIndex["renamed"].Equal(author) || s.Members[crypto.HashToken(author)] != "renamed" {
+		t.Error("Handle not changed")
+	}
+	if err := s.ChangeHandle(&actions.ChangeHandle{Epoch: 0, Author: other, Handle: "author"}); err != nil {
+		t.Errorf("Previous handle not released: %v", err)
+	}
+}
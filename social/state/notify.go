@@ -1,12 +1,21 @@
 package state
 
-import "github.com/lienkolabs/breeze/crypto"
+import (
+	"sync"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
 
 /*
-Notify implements an interface to send notification messages through a
-channel about modifications on objects within the state of the synergy
-protocol. This is useful for example, for the implementation of user
-interfaces related to the protocol.
+Notify publishes messages on a bus about modifications on objects within the
+state of the synergy protocol. This is useful for example, for the
+implementation of user interfaces related to the protocol.
+
+Every subscriber has its own buffered channel and a topic filter. Delivery
+never blocks the state: a subscriber with a full channel misses the message,
+and the number of missed messages is kept on the subscription. Consumers that
+cannot miss a message register a listener instead, called synchronously on
+every matching update.
 */
 
 // Action is action that is trigering the notification. For example a
@@ -28,6 +37,15 @@ const (
 	AcceptProposal
 	SigninAction
 	MediaUpload
+	EventAction
+	AttorneyAction
+	GreetAction
+	RejectProposal
+	MemberAction
+	CommentAction
+	WithdrawAction
+	WithdrawnProposal // the proposal was taken back before consensus
+	RejectAction      // the action was not incorporated, see Updated.Reason
 )
 
 type Object byte
@@ -38,7 +56,7 @@ const (
 	DraftObject
 	EditObject
 	BoardObject
-	JournalObject
+	JournalObject // release stamped by a collective
 	EventObject
 	CollectiveObject
 	MemberObject
@@ -46,13 +64,187 @@ const (
 )
 
 type Updated struct {
-	Action Action
-	Object Object
-	Hash   crypto.Hash
+	Action     Action
+	Object     Object
+	Hash       crypto.Hash
+	Origin     crypto.Hash // hash of the action incorporated, zero for proposals closed
+	Epoch      uint64
+	Author     crypto.Token   // author of the action, zero for expirations
+	Members    []crypto.Token // other members concerned by the action
+	Collective string         // collective concerned, if any
+	Board      string         // board concerned, if any
+	Reason     string         // why the action was rejected, RejectAction only
+}
+
+// Topic filters notifications. Empty fields match everything.
+type Topic struct {
+	Objects    []Object
+	Collective string
+	Board      string
+	Member     crypto.Token
+	Following  *Following // widens Member to what the member follows
+}
+
+// Following are the collectives, boards and proposals followed by a member.
+// It must not be modified once the topic is subscribed.
+type Following struct {
+	Collectives map[string]struct{}
+	Boards      map[string]struct{}
+	Hashes      map[crypto.Hash]struct{}
+}
+
+// Has checks if the update is on something followed
+func (f *Following) Has(u Updated) bool {
+	if f == nil {
+		return false
+	}
+	if _, ok := f.Collectives[u.Collective]; ok && u.Collective != "" {
+		return true
+	}
+	if _, ok := f.Boards[u.Board]; ok && u.Board != "" {
+		return true
+	}
+	_, ok := f.Hashes[u.Hash]
+	return ok
+}
+
+func (t Topic) Match(u Updated) bool {
+	if len(t.Objects) > 0 {
+		match := false
+		for _, object := range t.Objects {
+			if object == u.Object {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	if t.Collective != "" && t.Collective != u.Collective {
+		return false
+	}
+	if t.Board != "" && t.Board != u.Board {
+		return false
+	}
+	if t.Member != crypto.ZeroToken && !u.Concerns(t.Member) && !t.Following.Has(u) {
+		return false
+	}
+	return true
+}
+
+// Concerns checks if the member is the author of the update, one of the
+// members concerned or the member object itself.
+func (u Updated) Concerns(member crypto.Token) bool {
+	if u.Author.Equal(member) {
+		return true
+	}
+	if u.Object == MemberObject && u.Hash.Equal(crypto.HashToken(member)) {
+		return true
+	}
+	for _, token := range u.Members {
+		if token.Equal(member) {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscriptionBuffer is the number of messages a subscriber can hold before
+// it starts missing messages.
+const SubscriptionBuffer = 256
+
+type Subscription struct {
+	C      <-chan Updated
+	bus    *Bus
+	id     uint64
+	topic  Topic
+	send   chan Updated
+	missed uint64
 }
 
-type Notifier chan Updated
+// Missed returns the number of messages not delivered because the channel
+// was full.
+func (s *Subscription) Missed() uint64 {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.missed
+}
+
+// SetTopic replaces the topic of the subscription for the next updates
+func (s *Subscription) SetTopic(topic Topic) {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.topic = topic
+}
+
+// Close removes the subscription from the bus and closes its channel.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subscribers[s.id]; ok {
+		delete(s.bus.subscribers, s.id)
+		close(s.send)
+	}
+}
+
+// Listener is called on the goroutine that publishes the update, while the
+// state is locked: it must neither block nor lock the state, but it may read
+// it.
+type Listener func(Updated)
+
+type listening struct {
+	topic    Topic
+	listener Listener
+}
+
+type Bus struct {
+	mu          sync.Mutex
+	next        uint64
+	subscribers map[uint64]*Subscription
+	listeners   []listening
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[uint64]*Subscription)}
+}
+
+func (b *Bus) Subscribe(topic Topic) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next += 1
+	send := make(chan Updated, SubscriptionBuffer)
+	subscription := &Subscription{C: send, bus: b, id: b.next, topic: topic, send: send}
+	b.subscribers[b.next] = subscription
+	return subscription
+}
+
+// Listen registers a listener for every update matching topic. Listeners
+// receive every update, in order, and cannot be removed.
+func (b *Bus) Listen(topic Topic, listener Listener) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, listening{topic: topic, listener: listener})
+}
 
-func (n Notifier) Notify(origin Action, affects Object, id crypto.Hash) {
-	n <- Updated{Action: origin, Object: affects, Hash: id}
+// Publish delivers the update to every subscriber whose topic matches it
+// without blocking, and calls the listeners whose topic matches it.
+func (b *Bus) Publish(u Updated) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, listening := range b.listeners {
+		if listening.topic.Match(u) {
+			listening.listener(u)
+		}
+	}
+	for _, subscriber := range b.subscribers {
+		if !subscriber.topic.Match(u) {
+			continue
+		}
+		select {
+		case subscriber.send <- u:
+		default:
+			subscriber.missed += 1
+		}
+	}
 }
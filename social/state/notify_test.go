@@ -0,0 +1,115 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestBusTopics(t *testing.T) {
+	s := GenesisState(nil)
+	author, key := crypto.RandomAsymetricKey()
+	all := s.Subscribe(Topic{})
+	members := s.Subscribe(Topic{Objects: []Object{MemberObject}})
+	mine := s.Subscribe(Topic{Member: author})
+	collective := s.Subscribe(Topic{Collective: "synergy"})
+	defer all.Close()
+	defer members.Close()
+	defer mine.Close()
+	defer collective.Close()
+
+	signin := &actions.Signin{Epoch: 0, Author: author, Handle: "author"}
+	if err := s.Action(actions.Dress(signin.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("could not sign in: %v", err)
+	}
+	create := &actions.CreateCollective{Epoch: 0, Author: author, Name: "synergy", Policy: actions.Policy{Majority: 1, SuperMajority: 1}}
+	if err := s.Action(actions.Dress(create.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("could not create collective: %v", err)
+	}
+	if len(all.C) != 2 || len(mine.C) != 2 {
+		t.Errorf("expected two updates, got %v and %v", len(all.C), len(mine.C))
+	}
+	if len(members.C) != 1 {
+		t.Fatalf("expected one member update, got %v", len(members.C))
+	}
+	if u := <-members.C; u.Action != SigninAction || !u.Hash.Equal(crypto.HashToken(author)) {
+		t.Errorf("unexpected member update: %+v", u)
+	}
+	if len(collective.C) != 1 {
+		t.Fatalf("expected one collective update, got %v", len(collective.C))
+	}
+	if u := <-collective.C; u.Action != CollectiveAction || u.Object != CollectiveObject {
+		t.Errorf("unexpected collective update: %+v", u)
+	}
+}
+
+func TestBusSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe(Topic{})
+	for n := 0; n < SubscriptionBuffer+10; n++ {
+		bus.Publish(Updated{Action: ReactAction})
+	}
+	if missed := slow.Missed(); missed != 10 {
+		t.Errorf("expected 10 missed updates, got %v", missed)
+	}
+	slow.Close()
+	slow.Close()
+	count := 0
+	for range slow.C {
+		count += 1
+	}
+	if count != SubscriptionBuffer {
+		t.Errorf("expected %v buffered updates, got %v", SubscriptionBuffer, count)
+	}
+	// publishing after close must not panic
+	bus.Publish(Updated{Action: ReactAction})
+}
+
+func TestRejectedAction(t *testing.T) {
+	s := GenesisState(nil)
+	author, key := crypto.RandomAsymetricKey()
+	updates := s.Subscribe(Topic{Member: author})
+	defer updates.Close()
+	react := &actions.React{Epoch: 5, Author: author, Hash: crypto.Hasher([]byte("draft")), Reaction: 1}
+	dressed := actions.Dress(react.Serialize(), 5, author, key, key, 0)
+	err := s.Action(dressed)
+	if err == nil {
+		t.Fatal("reaction from a future epoch accepted")
+	}
+	if len(updates.C) != 1 {
+		t.Fatalf("expected one rejection, got %v updates", len(updates.C))
+	}
+	hash, _ := actions.ActionHash(dressed)
+	if u := <-updates.C; u.Action != RejectAction || !u.Origin.Equal(hash) || u.Reason != err.Error() {
+		t.Errorf("unexpected rejection: %+v", u)
+	}
+}
+
+func TestFollowingTopic(t *testing.T) {
+	bus := NewBus()
+	member, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	proposal := crypto.Hasher([]byte("proposal"))
+	following := &Following{
+		Collectives: map[string]struct{}{"synergy": {}},
+		Boards:      map[string]struct{}{"news": {}},
+		Hashes:      map[crypto.Hash]struct{}{proposal: {}},
+	}
+	topic := bus.Subscribe(Topic{Member: member, Following: following})
+	defer topic.Close()
+	bus.Publish(Updated{Action: ReactAction, Author: other})
+	bus.Publish(Updated{Action: CollectiveAction, Author: other, Collective: "other"})
+	bus.Publish(Updated{Action: ReactAction, Author: member})
+	bus.Publish(Updated{Action: CollectiveAction, Author: other, Collective: "synergy"})
+	bus.Publish(Updated{Action: PinAction, Author: other, Board: "news"})
+	bus.Publish(Updated{Action: ExpireProposal, Hash: proposal})
+	if len(topic.C) != 4 {
+		t.Errorf("expected four updates, got %v", len(topic.C))
+	}
+	topic.SetTopic(Topic{Member: member})
+	bus.Publish(Updated{Action: PinAction, Author: other, Board: "news"})
+	if len(topic.C) != 4 {
+		t.Errorf("update on a board no longer followed delivered")
+	}
+}
@@ -26,6 +26,8 @@ const (
 	CancelEventProposal
 	UpdateEventProposal
 	EventCheckinGreetProposal
+	CommentProposal
+	WithdrawProposal
 	UnkownProposal
 )
 
@@ -45,6 +47,9 @@ var proposalNames = []string{
 	"Create Event",
 	"Cancel Event",
 	"Update Event",
+	"Event Checkin Greet",
+	"Comment",
+	"Withdraw",
 	"Unkown",
 }
 
@@ -54,10 +59,18 @@ type Proposal interface {
 	IncorporateVote(vote actions.Vote, state *State) error
 }
 
+// decider is a proposal of a collective that the policy of the collective can
+// decide when its voting window closes.
+type decider interface {
+	Proposal
+	decide(consensus ConsensusState, state *State) error
+}
+
 func NewProposals(i Indexer) *Proposals {
 	return &Proposals{
 		mu:         &sync.Mutex{},
 		all:        make(map[crypto.Hash]byte),
+		authors:    make(map[crypto.Hash]crypto.Token),
 		stateIndex: i,
 		//index:             make(map[crypto.Token]*SetOfHashes),
 		UpdateCollective:  make(map[crypto.Hash]*PendingUpdate),
@@ -76,6 +89,8 @@ func NewProposals(i Indexer) *Proposals {
 		CancelEvent:  make(map[crypto.Hash]*CancelEvent),
 		UpdateEvent:  make(map[crypto.Hash]*EventUpdate),
 		GreetCheckin: make(map[crypto.Hash]*EventCheckinGreet),
+		Comment:      make(map[crypto.Hash]*Comment),
+		Withdraw:     make(map[crypto.Hash]*PendingWithdraw),
 	}
 }
 
@@ -111,7 +126,8 @@ func (s *SetOfHashes) All() map[crypto.Hash]struct{} {
 
 type Proposals struct {
 	mu         *sync.Mutex
-	all        map[crypto.Hash]byte // hash do que ta pendente pro tipo de proposal
+	all        map[crypto.Hash]byte         // hash do que ta pendente pro tipo de proposal
+	authors    map[crypto.Hash]crypto.Token // hash do que ta pendente pro autor da acao que originou a proposal
 	stateIndex Indexer
 	//index             map[crypto.Token]*SetOfHashes // token do membro pra um conjunto de hashs dos votos que ele precisa dar
 	UpdateCollective  map[crypto.Hash]*PendingUpdate
@@ -130,6 +146,8 @@ type Proposals struct {
 	CancelEvent  map[crypto.Hash]*CancelEvent
 	UpdateEvent  map[crypto.Hash]*EventUpdate
 	GreetCheckin map[crypto.Hash]*EventCheckinGreet
+	Comment      map[crypto.Hash]*Comment
+	Withdraw     map[crypto.Hash]*PendingWithdraw
 }
 
 func (p *Proposals) GetEvent(hash crypto.Hash) *Event {
@@ -144,6 +162,7 @@ func (p *Proposals) Delete(hash crypto.Hash) {
 		p.stateIndex.RemoveVoteHash(hash)
 	}
 	delete(p.all, hash)
+	delete(p.authors, hash)
 	delete(p.UpdateCollective, hash)
 	delete(p.RequestMembership, hash)
 	delete(p.RemoveMember, hash)
@@ -163,6 +182,8 @@ func (p *Proposals) Delete(hash crypto.Hash) {
 		hashes.Remove(hash)
 	}*/
 	delete(p.GreetCheckin, hash)
+	delete(p.Comment, hash)
+	delete(p.Withdraw, hash)
 }
 
 func (p *Proposals) Kind(hash crypto.Hash) byte {
@@ -173,6 +194,20 @@ func (p *Proposals) Kind(hash crypto.Hash) byte {
 	return kind
 }
 
+// Author returns the author of the action that originated the proposal
+func (p *Proposals) Author(hash crypto.Hash) (crypto.Token, bool) {
+	author, ok := p.authors[hash]
+	return author, ok
+}
+
+// register records a new pending proposal of the given kind
+func (p *Proposals) register(hash crypto.Hash, kind byte, reason actions.Action) {
+	p.all[hash] = kind
+	if reason != nil {
+		p.authors[hash] = reason.Authored()
+	}
+}
+
 func (p *Proposals) KindText(hash crypto.Hash) string {
 	return proposalNames[p.Kind(hash)]
 }
@@ -221,19 +256,19 @@ func casted(votes []actions.Vote, token crypto.Token) bool {
 
 func (p *Proposals) AddUpdateCollective(update *PendingUpdate, reason actions.Action) {
 	p.indexHash(update.Collective, update.Hash)
-	p.all[update.Hash] = UpdateCollectiveProposal
+	p.register(update.Hash, UpdateCollectiveProposal, reason)
 	p.UpdateCollective[update.Hash] = update
 }
 
 func (p *Proposals) AddRequestMembership(update *PendingRequestMembership, reason actions.Action) {
 	p.indexHash(update.Collective, update.Hash)
-	p.all[update.Hash] = RequestMembershipProposal
+	p.register(update.Hash, RequestMembershipProposal, reason)
 	p.RequestMembership[update.Hash] = update
 }
 
 func (p *Proposals) AddPendingRemoveMember(update *PendingRemoveMember, reason actions.Action) {
 	p.indexHash(update.Collective, update.Hash)
-	p.all[update.Hash] = RemoveMemberProposal
+	p.register(update.Hash, RemoveMemberProposal, reason)
 	p.RemoveMember[update.Hash] = update
 }
 
@@ -242,26 +277,26 @@ func (p *Proposals) AddDraft(update *Draft, reason actions.Action) {
 	if update.PreviousVersion != nil {
 		p.indexHash(update.PreviousVersion.Authors, update.DraftHash)
 	}
-	p.all[update.DraftHash] = DraftProposal
+	p.register(update.DraftHash, DraftProposal, reason)
 	p.Draft[update.DraftHash] = update
 }
 
 func (p *Proposals) AddEdit(update *Edit, reason actions.Action) {
 	p.indexHash(update.Draft.Authors, update.Edit)
 	p.indexHash(update.Authors, update.Edit)
-	p.all[update.Edit] = EditProposal
+	p.register(update.Edit, EditProposal, reason)
 	p.Edit[update.Edit] = update
 }
 
 func (p *Proposals) AddPendingBoard(update *PendingBoard, reason actions.Action) {
 	p.indexHash(update.Board.Collective, update.Hash)
-	p.all[update.Hash] = CreateBoardProposal
+	p.register(update.Hash, CreateBoardProposal, reason)
 	p.CreateBoard[update.Hash] = update
 }
 
 func (p *Proposals) AddPendingUpdateBoard(update *PendingUpdateBoard, reason actions.Action) {
 	p.indexHash(update.Board.Editors, update.Hash)
-	p.all[update.Hash] = UpdateBoardProposal
+	p.register(update.Hash, UpdateBoardProposal, reason)
 	p.UpdateBoard[update.Hash] = update
 }
 
@@ -269,57 +304,78 @@ func (p *Proposals) AddPendingUpdateBoard(update *PendingUpdateBoard, reason act
 func (p *Proposals) AddPin(update *Pin, reason actions.Action) {
 	// quem vai receber o pedido de voto
 	p.indexHash(update.Board.Editors, update.Hash)
-	p.all[update.Hash] = PinProposal // adiciona a
+	p.register(update.Hash, PinProposal, reason)
 	p.Pin[update.Hash] = update
 }
 
 func (p *Proposals) AddBoardEditor(update *BoardEditor, reason actions.Action) {
 	p.indexHash(update.Board.Collective, update.Hash)
-	p.all[update.Hash] = BoardEditorProposal
+	p.register(update.Hash, BoardEditorProposal, reason)
 	p.BoardEditor[update.Hash] = update
 }
 
 func (p *Proposals) AddRelease(update *Release, reason actions.Action) {
 	p.indexHash(update.Draft.Authors, update.Hash)
-	p.all[update.Hash] = ReleaseDraftProposal
+	p.register(update.Hash, ReleaseDraftProposal, reason)
 	p.ReleaseDraft[update.Hash] = update
 }
 
 func (p *Proposals) AddStamp(update *Stamp, reason actions.Action) {
 	p.indexHash(update.Reputation, update.Hash) // reputation aqui é = um membro ou coletivo que vai dar o stamp ??
-	p.all[update.Hash] = ImprintStampProposal
+	p.register(update.Hash, ImprintStampProposal, reason)
 	p.ImprintStamp[update.Hash] = update
 }
 
 func (p *Proposals) AddEvent(update *Event, reason actions.Action) {
 	p.indexHash(update.Collective, update.Hash)
-	p.all[update.Hash] = CreateEventProposal
+	p.register(update.Hash, CreateEventProposal, reason)
 	p.CreateEvent[update.Hash] = update
 }
 
 func (p *Proposals) AddCancelEvent(update *CancelEvent, reason actions.Action) {
 	p.indexHash(update.Event.Collective, update.Hash)
-	p.all[update.Hash] = CancelEventProposal
+	p.register(update.Hash, CancelEventProposal, reason)
 	p.CancelEvent[update.Hash] = update
 }
 
 func (p *Proposals) AddEventUpdate(update *EventUpdate, reason actions.Action) {
 	p.indexHash(update.Event.Managers, update.Hash)
-	p.all[update.Hash] = UpdateEventProposal
+	p.register(update.Hash, UpdateEventProposal, reason)
 	p.UpdateEvent[update.Hash] = update
 }
 
 func (p *Proposals) AddEventCheckinGreet(update *EventCheckinGreet, reason actions.Action) {
 	// p.indexHash(update.Event.Greets, update.Hash)
-	p.all[update.Hash] = EventCheckinGreetProposal
+	p.register(update.Hash, EventCheckinGreetProposal, reason)
 	p.GreetCheckin[update.Hash] = update
 }
 
+func (p *Proposals) AddComment(update *Comment, reason actions.Action) {
+	p.indexHash(update.Collective, update.Hash)
+	p.register(update.Hash, CommentProposal, reason)
+	p.Comment[update.Hash] = update
+}
+
+func (p *Proposals) AddWithdraw(update *PendingWithdraw, reason actions.Action) {
+	p.indexHash(update.Collective, update.Hash)
+	p.register(update.Hash, WithdrawProposal, reason)
+	p.Withdraw[update.Hash] = update
+}
+
 func (p *Proposals) Has(hash crypto.Hash) bool {
 	_, ok := p.all[hash]
 	return ok
 }
 
+// Open returns the hashes of the proposals waiting for consensus
+func (p *Proposals) Open() []crypto.Hash {
+	hashes := make([]crypto.Hash, 0, len(p.all))
+	for hash := range p.all {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
 func (p *Proposals) IncorporateVote(vote actions.Vote, state *State) error {
 	hash := vote.Hash
 	var proposal Proposal
@@ -359,6 +415,10 @@ func (p *Proposals) IncorporateVote(vote actions.Vote, state *State) error {
 		proposal = p.CancelEvent[hash]
 	case UpdateEventProposal:
 		proposal = p.UpdateEvent[hash]
+	case CommentProposal:
+		proposal = p.Comment[hash]
+	case WithdrawProposal:
+		proposal = p.Withdraw[hash]
 	}
 	if proposal == nil {
 		return ErrProposalNotFound
@@ -481,6 +541,20 @@ func (p *Proposals) Pooling(hash crypto.Hash) *Pool {
 			Majority: proposal.Event.Collective.Policy.Majority,
 			Votes:    proposal.Votes,
 		}
+	case CommentProposal:
+		proposal := p.Comment[hash]
+		return &Pool{
+			Voters:   proposal.Collective.ListOfMembers(),
+			Majority: proposal.Collective.Policy.Majority,
+			Votes:    proposal.Votes,
+		}
+	case WithdrawProposal:
+		proposal := p.Withdraw[hash]
+		return &Pool{
+			Voters:   proposal.Collective.ListOfMembers(),
+			Majority: proposal.Collective.Policy.Majority,
+			Votes:    proposal.Votes,
+		}
 	}
 	return nil
 }
@@ -494,6 +568,9 @@ func (p *Proposals) Votes(hash crypto.Hash) []actions.Vote {
 	case UpdateCollectiveProposal:
 		proposal := p.UpdateCollective[hash]
 		return proposal.Votes
+	case RequestMembershipProposal:
+		proposal := p.RequestMembership[hash]
+		return proposal.Votes
 	case RemoveMemberProposal:
 		proposal := p.RemoveMember[hash]
 		return proposal.Votes
@@ -532,10 +609,62 @@ func (p *Proposals) Votes(hash crypto.Hash) []actions.Vote {
 	case UpdateEventProposal:
 		proposal := p.UpdateEvent[hash]
 		return proposal.Votes
+	case CommentProposal:
+		proposal := p.Comment[hash]
+		return proposal.Votes
+	case WithdrawProposal:
+		proposal := p.Withdraw[hash]
+		return proposal.Votes
 	}
 	return nil
 }
 
+// closing returns the proposal and the collective whose policy decides it at
+// the end of the voting window, nil for proposals that simply expire.
+func (p *Proposals) closing(hash crypto.Hash) (decider, *Collective) {
+	kind, ok := p.all[hash]
+	if !ok {
+		return nil, nil
+	}
+	switch kind {
+	case UpdateCollectiveProposal:
+		proposal := p.UpdateCollective[hash]
+		// changes of policy require a supermajority
+		if proposal.ChangePolicy {
+			return nil, nil
+		}
+		return proposal, proposal.Collective
+	case RequestMembershipProposal:
+		proposal := p.RequestMembership[hash]
+		return proposal, proposal.Collective
+	case RemoveMemberProposal:
+		proposal := p.RemoveMember[hash]
+		return proposal, proposal.Collective
+	case CreateBoardProposal:
+		proposal := p.CreateBoard[hash]
+		return proposal, proposal.Board.Collective
+	case UpdateBoardProposal:
+		proposal := p.UpdateBoard[hash]
+		return proposal, proposal.Board.Collective
+	case BoardEditorProposal:
+		proposal := p.BoardEditor[hash]
+		return proposal, proposal.Board.Collective
+	case ImprintStampProposal:
+		proposal := p.ImprintStamp[hash]
+		return proposal, proposal.Reputation
+	case CreateEventProposal:
+		proposal := p.CreateEvent[hash]
+		return proposal, proposal.Collective
+	case CommentProposal:
+		proposal := p.Comment[hash]
+		return proposal, proposal.Collective
+	case WithdrawProposal:
+		proposal := p.Withdraw[hash]
+		return proposal, proposal.Collective
+	}
+	return nil, nil
+}
+
 func (p *Proposals) OnBehalfOf(hash crypto.Hash) string {
 	kind, ok := p.all[hash]
 	if !ok {
@@ -583,6 +712,12 @@ func (p *Proposals) OnBehalfOf(hash crypto.Hash) string {
 	case UpdateEventProposal:
 		proposal := p.UpdateEvent[hash]
 		return proposal.Event.Collective.Name
+	case CommentProposal:
+		proposal := p.Comment[hash]
+		return proposal.Collective.Name
+	case WithdrawProposal:
+		proposal := p.Withdraw[hash]
+		return proposal.Collective.Name
 	}
 	return ""
 }
@@ -0,0 +1,251 @@
+package state
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// Subscribe to the updates of the state matching topic.
+func (s *State) Subscribe(topic Topic) *Subscription {
+	return s.bus.Subscribe(topic)
+}
+
+// Listen calls listener with every update matching topic, see Listener.
+func (s *State) Listen(topic Topic, listener Listener) {
+	s.bus.Listen(topic, listener)
+}
+
+// Notify publishes an update about the object with the given hash.
+func (s *State) Notify(origin Action, hash crypto.Hash) {
+	update := Updated{Action: origin, Hash: hash, Epoch: s.Epoch}
+	update.Object, update.Collective, update.Board = s.objectOf(hash)
+	s.bus.Publish(update)
+}
+
+func collectiveName(c Consensual) string {
+	if collective, ok := c.(*Collective); ok && collective != nil {
+		return collective.Name
+	}
+	return ""
+}
+
+func boardScope(b *Board) (Object, string, string) {
+	if b == nil {
+		return BoardObject, "", ""
+	}
+	return BoardObject, collectiveName(b.Collective), b.Name
+}
+
+func eventScope(e *Event) (Object, string, string) {
+	if e == nil {
+		return EventObject, "", ""
+	}
+	return EventObject, collectiveName(e.Collective), ""
+}
+
+func draftScope(d *Draft) (Object, string, string) {
+	if d == nil {
+		return DraftObject, "", ""
+	}
+	return DraftObject, collectiveName(d.Authors), ""
+}
+
+// objectOf returns the kind of the object with the given hash together with
+// the collective and the board it belongs to. Pending proposals are taken as
+// the object they propose to change.
+func (s *State) objectOf(hash crypto.Hash) (Object, string, string) {
+	if _, ok := s.Members[hash]; ok {
+		return MemberObject, "", ""
+	}
+	if draft, ok := s.Drafts[hash]; ok {
+		return draftScope(draft)
+	}
+	if edit, ok := s.Edits[hash]; ok {
+		return EditObject, collectiveName(edit.Authors), ""
+	}
+	if board, ok := s.Boards[hash]; ok {
+		return boardScope(board)
+	}
+	if event, ok := s.Events[hash]; ok {
+		return eventScope(event)
+	}
+	if collective, ok := s.Collectives[hash]; ok {
+		return CollectiveObject, collective.Name, ""
+	}
+	if _, ok := s.Media[hash]; ok {
+		return MediaObject, "", ""
+	}
+	if _, ok := s.PendingMedia[hash]; ok {
+		return MediaObject, "", ""
+	}
+	if comment, ok := s.Comments[hash]; ok {
+		return s.objectOf(comment.Object)
+	}
+	p := s.Proposals
+	switch p.Kind(hash) {
+	case UpdateCollectiveProposal:
+		if update := p.UpdateCollective[hash]; update != nil && update.Collective != nil {
+			return CollectiveObject, update.Collective.Name, ""
+		}
+	case RequestMembershipProposal:
+		if request := p.RequestMembership[hash]; request != nil {
+			return CollectiveObject, collectiveName(request.Collective), ""
+		}
+	case RemoveMemberProposal:
+		if remove := p.RemoveMember[hash]; remove != nil {
+			return CollectiveObject, collectiveName(remove.Collective), ""
+		}
+	case DraftProposal:
+		return draftScope(p.Draft[hash])
+	case EditProposal:
+		if edit := p.Edit[hash]; edit != nil {
+			return EditObject, collectiveName(edit.Authors), ""
+		}
+		return EditObject, "", ""
+	case CreateBoardProposal:
+		if pending := p.CreateBoard[hash]; pending != nil {
+			return boardScope(pending.Board)
+		}
+	case UpdateBoardProposal:
+		if pending := p.UpdateBoard[hash]; pending != nil {
+			return boardScope(pending.Board)
+		}
+	case PinProposal:
+		if pin := p.Pin[hash]; pin != nil {
+			return boardScope(pin.Board)
+		}
+	case BoardEditorProposal:
+		if editor := p.BoardEditor[hash]; editor != nil {
+			return boardScope(editor.Board)
+		}
+	case ReleaseDraftProposal:
+		if release := p.ReleaseDraft[hash]; release != nil {
+			return draftScope(release.Draft)
+		}
+	case ImprintStampProposal:
+		if stamp := p.ImprintStamp[hash]; stamp != nil {
+			return JournalObject, collectiveName(stamp.Reputation), ""
+		}
+	case CreateEventProposal:
+		return eventScope(p.CreateEvent[hash])
+	case CancelEventProposal:
+		if cancel := p.CancelEvent[hash]; cancel != nil {
+			return eventScope(cancel.Event)
+		}
+	case UpdateEventProposal:
+		if update := p.UpdateEvent[hash]; update != nil {
+			return eventScope(update.Event)
+		}
+	case EventCheckinGreetProposal:
+		if greet := p.GreetCheckin[hash]; greet != nil {
+			return eventScope(greet.Event)
+		}
+	case CommentProposal:
+		if comment := p.Comment[hash]; comment != nil {
+			return s.objectOf(comment.Object)
+		}
+	case WithdrawProposal:
+		if withdraw := p.Withdraw[hash]; withdraw != nil {
+			return s.objectOf(withdraw.Target)
+		}
+	}
+	// comments on a release are scoped to its draft
+	if release, ok := s.Release(hash); ok {
+		return draftScope(release.Draft)
+	}
+	return NoObject, "", ""
+}
+
+// updateOf describes the incorporated action as an update.
+func (s *State) updateOf(action actions.Action) Updated {
+	update := Updated{Epoch: s.Epoch, Author: action.Authored(), Hash: action.Hashed()}
+	named := func(name string) crypto.Hash {
+		return crypto.Hasher([]byte(name))
+	}
+	switch v := action.(type) {
+	case *actions.Vote:
+		update.Action, update.Hash = VoteAction, v.Hash
+		update.Object, update.Collective, update.Board = s.objectOf(v.Hash)
+	case *actions.CreateCollective:
+		update.Action, update.Object, update.Hash = CollectiveAction, CollectiveObject, named(v.Name)
+		update.Collective = v.Name
+	case *actions.UpdateCollective:
+		update.Action, update.Object, update.Hash = CollectiveAction, CollectiveObject, named(v.OnBehalfOf)
+		update.Collective = v.OnBehalfOf
+	case *actions.RequestMembership:
+		update.Action, update.Object, update.Hash = CollectiveAction, CollectiveObject, named(v.Collective)
+		update.Collective = v.Collective
+	case *actions.RemoveMember:
+		update.Action, update.Object, update.Hash = CollectiveAction, CollectiveObject, named(v.OnBehalfOf)
+		update.Collective = v.OnBehalfOf
+		update.Members = []crypto.Token{v.Member}
+	case *actions.Draft:
+		update.Action, update.Object, update.Hash = DraftAction, DraftObject, v.ContentHash
+		update.Collective = v.OnBehalfOf
+		update.Members = v.CoAuthors
+	case *actions.Edit:
+		update.Action, update.Object, update.Hash = EditAction, EditObject, v.ContentHash
+		update.Collective = v.OnBehalfOf
+		update.Members = v.CoAuthors
+	case *actions.MultipartMedia:
+		update.Action, update.Object, update.Hash = MediaUpload, MediaObject, v.Hash
+	case *actions.CreateBoard:
+		update.Action, update.Object, update.Hash = BoardAction, BoardObject, named(v.Name)
+		update.Collective, update.Board = v.OnBehalfOf, v.Name
+	case *actions.UpdateBoard:
+		update.Action, update.Hash = BoardAction, named(v.Board)
+		update.Object, update.Collective, update.Board = boardScope(s.Boards[update.Hash])
+	case *actions.Pin:
+		update.Action, update.Hash = PinAction, named(v.Board)
+		update.Object, update.Collective, update.Board = boardScope(s.Boards[update.Hash])
+	case *actions.BoardEditor:
+		update.Action, update.Hash = BoardAction, named(v.Board)
+		update.Object, update.Collective, update.Board = boardScope(s.Boards[update.Hash])
+		update.Members = []crypto.Token{v.Editor}
+	case *actions.ReleaseDraft:
+		update.Action, update.Hash = PublishAction, v.ContentHash
+		update.Object, update.Collective, update.Board = s.objectOf(v.ContentHash)
+	case *actions.ImprintStamp:
+		update.Action, update.Object, update.Hash = PublishAction, JournalObject, v.Hash
+		update.Collective = v.OnBehalfOf
+	case *actions.React:
+		update.Action, update.Hash = ReactAction, v.Hash
+		update.Object, update.Collective, update.Board = s.objectOf(v.Hash)
+	case *actions.Signin:
+		update.Action, update.Object, update.Hash = SigninAction, MemberObject, crypto.HashToken(v.Author)
+	case *actions.CreateEvent:
+		// the hash of the event is the hash of the action
+		update.Action, update.Object = EventAction, EventObject
+		update.Collective = v.OnBehalfOf
+		update.Members = v.Managers
+	case *actions.CancelEvent:
+		update.Action, update.Hash = EventAction, v.Hash
+		update.Object, update.Collective, update.Board = eventScope(s.Events[v.Hash])
+	case *actions.UpdateEvent:
+		update.Action, update.Hash = EventAction, v.EventHash
+		update.Object, update.Collective, update.Board = eventScope(s.Events[v.EventHash])
+	case *actions.CheckinEvent:
+		update.Action, update.Hash = EventAction, v.EventHash
+		update.Object, update.Collective, update.Board = eventScope(s.Events[v.EventHash])
+	case *actions.GreetCheckinEvent:
+		update.Action, update.Hash = GreetAction, v.EventHash
+		update.Object, update.Collective, update.Board = eventScope(s.Events[v.EventHash])
+		update.Members = []crypto.Token{v.CheckedIn}
+	case *actions.GrantPowerOfAttorney:
+		update.Action, update.Object, update.Hash = AttorneyAction, MemberObject, crypto.HashToken(v.Author)
+	case *actions.RevokePowerOfAttorney:
+		update.Action, update.Object, update.Hash = AttorneyAction, MemberObject, crypto.HashToken(v.Author)
+	case *actions.RotateKey:
+		update.Action, update.Object, update.Hash = MemberAction, MemberObject, crypto.HashToken(v.Author)
+		update.Members = []crypto.Token{v.NewKey}
+	case *actions.ChangeHandle:
+		update.Action, update.Object, update.Hash = MemberAction, MemberObject, crypto.HashToken(v.Author)
+	case *actions.Comment:
+		update.Action, update.Hash = CommentAction, v.Object
+		update.Object, update.Collective, update.Board = s.objectOf(v.Object)
+	case *actions.Withdraw:
+		update.Action, update.Hash = WithdrawAction, v.Hash
+		update.Object, update.Collective, update.Board = s.objectOf(v.Hash)
+	}
+	return update
+}
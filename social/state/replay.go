@@ -0,0 +1,50 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+)
+
+// ActionWindow is the number of epochs an action remains valid after the
+// epoch it was signed for. Applied actions are remembered while valid so
+// that the same signed action is never incorporated twice.
+const ActionWindow = 15 * 60
+
+var (
+	ErrStaleAction     = errors.New("action epoch is older than the validity window")
+	ErrFutureAction    = errors.New("action epoch is in the future")
+	ErrDuplicateAction = errors.New("action already applied")
+)
+
+// checkWindow checks that an action signed for epoch with the given hash can
+// be incorporated on the current epoch.
+func (s *State) checkWindow(epoch uint64, hash crypto.Hash) error {
+	if epoch > s.Epoch {
+		return ErrFutureAction
+	}
+	if epoch+ActionWindow < s.Epoch {
+		return ErrStaleAction
+	}
+	if _, ok := s.Applied[epoch][hash]; ok {
+		return ErrDuplicateAction
+	}
+	return nil
+}
+
+func (s *State) markApplied(epoch uint64, hash crypto.Hash) {
+	s.touchEpoch(appliedLeaf, epoch)
+	if applied, ok := s.Applied[epoch]; ok {
+		applied[hash] = struct{}{}
+	} else {
+		s.Applied[epoch] = map[crypto.Hash]struct{}{hash: {}}
+	}
+}
+
+// expireApplied forgets the actions that are no longer valid from the next
+// epoch on.
+func (s *State) expireApplied() {
+	if s.Epoch >= ActionWindow {
+		delete(s.Applied, s.Epoch-ActionWindow)
+	}
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestActionWindow(t *testing.T) {
+	s := GenesisState(nil)
+	author, key := crypto.RandomAsymetricKey()
+	signin := &actions.Signin{Epoch: 0, Author: author, Handle: "author"}
+	if err := s.Action(actions.Dress(signin.Serialize(), 0, author, key, key, 0)); err != nil {
+		t.Fatalf("could not sign in: %v", err)
+	}
+	s.NextBlock()
+	react := &actions.React{Epoch: 1, Author: author, Hash: crypto.Hasher([]byte("draft")), Reaction: 1}
+	data := actions.Dress(react.Serialize(), 1, author, key, key, 0)
+	if err := s.Action(data); err != nil {
+		t.Fatalf("could not react: %v", err)
+	}
+	if err := s.Action(data); err != ErrDuplicateAction {
+		t.Errorf("replayed action: expected %v, got %v", ErrDuplicateAction, err)
+	}
+	// same signed action paid by another wallet
+	_, wallet := crypto.RandomAsymetricKey()
+	if err := s.Action(actions.Dress(react.Serialize(), 1, author, key, wallet, 0)); err != ErrDuplicateAction {
+		t.Errorf("replayed action on another wallet: expected %v, got %v", ErrDuplicateAction, err)
+	}
+	if count := s.Reactions[1][react.Hash]; count != 1 {
+		t.Errorf("expected one reaction, got %v", count)
+	}
+	future := &actions.React{Epoch: 2, Author: author, Hash: react.Hash, Reaction: 2}
+	if err := s.Action(actions.Dress(future.Serialize(), 2, author, key, key, 0)); err != ErrFutureAction {
+		t.Errorf("future action: expected %v, got %v", ErrFutureAction, err)
+	}
+	for s.Epoch <= ActionWindow+1 {
+		s.NextBlock()
+	}
+	if len(s.Applied) != 0 {
+		t.Errorf("applied actions not forgotten after the validity window: %v", len(s.Applied))
+	}
+	if err := s.Action(data); err != ErrStaleAction {
+		t.Errorf("stale action: expected %v, got %v", ErrStaleAction, err)
+	}
+}
@@ -0,0 +1,459 @@
+package state
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// leaf types of the state root
+const (
+	memberLeaf byte = iota
+	collectiveLeaf
+	boardLeaf
+	draftLeaf
+	editLeaf
+	releaseLeaf
+	eventLeaf
+	mediaLeaf
+	pendingMediaLeaf
+	attorneyLeaf
+	reactionLeaf
+	deadlineLeaf
+	proposalLeaf
+	appliedLeaf
+	rotationLeaf
+	rotatedLeaf
+	commentLeaf
+	threadLeaf
+)
+
+func putConsensual(c Consensual, data *[]byte) {
+	switch v := c.(type) {
+	case *Collective:
+		util.PutByte(1, data)
+		util.PutString(v.Name, data)
+	case *UnamedCollective:
+		util.PutByte(2, data)
+		putTokenSet(v.Members, data)
+		util.PutUint32(uint32(v.Majority), data)
+	default:
+		util.PutByte(0, data)
+	}
+}
+
+func putTokenSet(set map[crypto.Token]struct{}, data *[]byte) {
+	tokens := sortedTokenSet(set)
+	util.PutUint32(uint32(len(tokens)), data)
+	for _, token := range tokens {
+		util.PutToken(token, data)
+	}
+}
+
+func putVotes(votes []actions.Vote, data *[]byte) {
+	util.PutUint32(uint32(len(votes)), data)
+	for _, vote := range votes {
+		util.PutByteArray(vote.Serialize(), data)
+	}
+}
+
+func putStrings(words []string, data *[]byte) {
+	util.PutUint32(uint32(len(words)), data)
+	for _, word := range words {
+		util.PutString(word, data)
+	}
+}
+
+func putDraftHash(d *Draft, data *[]byte) {
+	if d == nil {
+		util.PutHash(crypto.ZeroValueHash, data)
+		return
+	}
+	util.PutHash(d.DraftHash, data)
+}
+
+func putComment(c *Comment, data *[]byte) {
+	util.PutHash(c.Hash, data)
+	util.PutUint64(c.Epoch, data)
+	util.PutToken(c.Author, data)
+	putCollectiveName(c.Collective, data)
+	util.PutHash(c.Object, data)
+	if c.Parent != nil {
+		util.PutHash(c.Parent.Hash, data)
+	} else {
+		util.PutHash(crypto.ZeroValueHash, data)
+	}
+	util.PutString(c.Content, data)
+	putCommentHashes(c.Replies, data)
+}
+
+func putCommentHashes(comments []*Comment, data *[]byte) {
+	util.PutUint32(uint32(len(comments)), data)
+	for _, comment := range comments {
+		util.PutHash(comment.Hash, data)
+	}
+}
+
+func putCollectiveName(c *Collective, data *[]byte) {
+	if c == nil {
+		util.PutString("", data)
+		return
+	}
+	util.PutString(c.Name, data)
+}
+
+func draftLeafData(key crypto.Hash, d *Draft) []byte {
+	data := []byte{draftLeaf}
+	util.PutHash(key, &data)
+	util.PutHash(d.DraftHash, &data)
+	util.PutString(d.Title, &data)
+	util.PutUint64(d.Date, &data)
+	util.PutString(d.Description, &data)
+	putConsensual(d.Authors, &data)
+	util.PutString(d.DraftType, &data)
+	putDraftHash(d.PreviousVersion, &data)
+	putStrings(d.Keywords, &data)
+	util.PutUint32(uint32(len(d.References)), &data)
+	for _, hash := range d.References {
+		util.PutHash(hash, &data)
+	}
+	putVotes(d.Votes, &data)
+	util.PutUint32(uint32(len(d.Pinned)), &data)
+	for _, board := range d.Pinned {
+		util.PutHash(board.Hash, &data)
+	}
+	util.PutUint32(uint32(len(d.Edits)), &data)
+	for _, edit := range d.Edits {
+		util.PutHash(edit.Edit, &data)
+	}
+	util.PutBool(d.Aproved, &data)
+	return data
+}
+
+// rootKey identifies the leaf of an object of the state: the type of leaf and
+// the key of the object within its map. Reaction leaves also carry the kind.
+type rootKey struct {
+	leaf byte
+	kind byte
+	key  crypto.Hash
+}
+
+// rootCache keeps the leaf hashes of the last state root. Mutations mark the
+// leaves of the objects they change as stale, StateRoot hashes again only
+// those and the objects created since.
+type rootCache struct {
+	leaves map[rootKey]crypto.Hash
+	stale  map[rootKey]struct{}
+}
+
+func newRootCache() *rootCache {
+	return &rootCache{
+		leaves: make(map[rootKey]crypto.Hash),
+		stale:  make(map[rootKey]struct{}),
+	}
+}
+
+// touch marks the leaf of an object as changed since the last state root.
+func (s *State) touch(leaf byte, key crypto.Hash) {
+	s.root.stale[rootKey{leaf: leaf, key: key}] = struct{}{}
+}
+
+func (s *State) touchToken(leaf byte, token crypto.Token) {
+	s.touch(leaf, crypto.Hash(token))
+}
+
+func (s *State) touchEpoch(leaf byte, epoch uint64) {
+	s.touch(leaf, epochKey(epoch))
+}
+
+func (s *State) touchReaction(kind byte, hash crypto.Hash) {
+	s.root.stale[rootKey{leaf: reactionLeaf, kind: kind, key: hash}] = struct{}{}
+}
+
+// touchCollective marks the collective with the given name. Proposals hold
+// photos of collectives, changes go to the one in the state.
+func (s *State) touchCollective(name string) {
+	s.touch(collectiveLeaf, crypto.Hasher([]byte(name)))
+}
+
+func (s *State) touchBoard(name string) {
+	s.touch(boardLeaf, crypto.Hasher([]byte(name)))
+}
+
+// touchAll drops every cached leaf, for changes that go through the whole
+// state such as key rotations.
+func (s *State) touchAll() {
+	s.root.leaves = make(map[rootKey]crypto.Hash)
+}
+
+func epochKey(epoch uint64) crypto.Hash {
+	var key crypto.Hash
+	data := make([]byte, 0, 8)
+	util.PutUint64(epoch, &data)
+	copy(key[:], data)
+	return key
+}
+
+// StateRoot is a commitment to the state: the merkle root over the sorted
+// hashes of every member, collective, board, draft, edit, release, event,
+// media, attorney grant, reaction count, deadline, pending proposal and
+// applied action within the validity window. Nodes that incorporated the same
+// actions have the same root. Only the leaves of objects created or touched
+// since the last call are hashed again, so code that changes the state must
+// touch the leaves of the objects it changes. Callers other than NextBlock
+// must hold the write lock.
+func (s *State) StateRoot() crypto.Hash {
+	cache := s.root
+	leaves := make(map[rootKey]crypto.Hash, len(cache.leaves))
+	leaf := func(key rootKey, data func() []byte) {
+		if hash, ok := cache.leaves[key]; ok {
+			if _, stale := cache.stale[key]; !stale {
+				leaves[key] = hash
+				return
+			}
+		}
+		leaves[key] = crypto.Hasher(data())
+	}
+	for hash, handle := range s.Members {
+		leaf(rootKey{leaf: memberLeaf, key: hash}, func() []byte {
+			data := []byte{memberLeaf}
+			util.PutHash(hash, &data)
+			util.PutString(handle, &data)
+			return data
+		})
+	}
+	for hash, c := range s.Collectives {
+		leaf(rootKey{leaf: collectiveLeaf, key: hash}, func() []byte {
+			data := []byte{collectiveLeaf}
+			util.PutHash(hash, &data)
+			util.PutString(c.Name, &data)
+			putTokenSet(c.Members, &data)
+			util.PutString(c.Description, &data)
+			util.PutUint32(uint32(c.Policy.Majority), &data)
+			util.PutUint32(uint32(c.Policy.SuperMajority), &data)
+			util.PutUint32(uint32(c.Policy.Quorum), &data)
+			util.PutBool(c.Policy.AcceptOnExpiry, &data)
+			actions.PutVotingWindow(c.Policy.VotingWindow, &data)
+			actions.PutStrategy(c.Policy.Strategy, &data)
+			return data
+		})
+	}
+	for hash, b := range s.Boards {
+		leaf(rootKey{leaf: boardLeaf, key: hash}, func() []byte {
+			data := []byte{boardLeaf}
+			util.PutHash(hash, &data)
+			util.PutString(b.Name, &data)
+			putStrings(b.Keyword, &data)
+			util.PutString(b.Description, &data)
+			putCollectiveName(b.Collective, &data)
+			putConsensual(b.Editors, &data)
+			util.PutUint32(uint32(len(b.Pinned)), &data)
+			for _, draft := range b.Pinned {
+				putDraftHash(draft, &data)
+			}
+			return data
+		})
+	}
+	for hash, d := range s.Drafts {
+		leaf(rootKey{leaf: draftLeaf, key: hash}, func() []byte {
+			return draftLeafData(hash, d)
+		})
+	}
+	for hash, e := range s.Edits {
+		leaf(rootKey{leaf: editLeaf, key: hash}, func() []byte {
+			data := []byte{editLeaf}
+			util.PutHash(hash, &data)
+			putConsensual(e.Authors, &data)
+			util.PutUint64(e.Date, &data)
+			util.PutString(e.Reasons, &data)
+			putDraftHash(e.Draft, &data)
+			util.PutString(e.EditType, &data)
+			putVotes(e.Votes, &data)
+			return data
+		})
+	}
+	for hash, r := range s.Releases {
+		leaf(rootKey{leaf: releaseLeaf, key: hash}, func() []byte {
+			data := []byte{releaseLeaf}
+			util.PutHash(hash, &data)
+			util.PutUint64(r.Epoch, &data)
+			putDraftHash(r.Draft, &data)
+			util.PutHash(r.Hash, &data)
+			putVotes(r.Votes, &data)
+			util.PutBool(r.Released, &data)
+			util.PutUint32(uint32(len(r.Stamps)), &data)
+			for _, stamp := range r.Stamps {
+				util.PutHash(stamp.Hash, &data)
+				putCollectiveName(stamp.Reputation, &data)
+				putVotes(stamp.Votes, &data)
+				util.PutBool(stamp.Imprinted, &data)
+			}
+			return data
+		})
+	}
+	for hash, e := range s.Events {
+		leaf(rootKey{leaf: eventLeaf, key: hash}, func() []byte {
+			data := []byte{eventLeaf}
+			util.PutHash(hash, &data)
+			putCollectiveName(e.Collective, &data)
+			util.PutTime(e.StartAt, &data)
+			util.PutTime(e.EstimatedEnd, &data)
+			util.PutString(e.Description, &data)
+			util.PutString(e.Venue, &data)
+			util.PutBool(e.Open, &data)
+			util.PutBool(e.Public, &data)
+			putConsensual(e.Managers, &data)
+			putVotes(e.Votes, &data)
+			checkins := make([]crypto.Token, 0, len(e.Checkin))
+			for token := range e.Checkin {
+				checkins = append(checkins, token)
+			}
+			util.PutUint32(uint32(len(checkins)), &data)
+			for _, token := range sortTokens(checkins) {
+				util.PutToken(token, &data)
+				if greeting := e.Checkin[token]; greeting != nil {
+					util.PutToken(greeting.EphemeralKey, &data)
+					util.PutBool(greeting.Action != nil, &data)
+				}
+				util.PutString(e.CheckinReasons[token], &data)
+			}
+			util.PutBool(e.Live, &data)
+			return data
+		})
+	}
+	// media is content addressed, the key commits to the content
+	for hash := range s.Media {
+		leaf(rootKey{leaf: mediaLeaf, key: hash}, func() []byte {
+			data := []byte{mediaLeaf}
+			util.PutHash(hash, &data)
+			return data
+		})
+	}
+	for hash, pending := range s.PendingMedia {
+		leaf(rootKey{leaf: pendingMediaLeaf, key: hash}, func() []byte {
+			data := []byte{pendingMediaLeaf}
+			util.PutHash(hash, &data)
+			for _, part := range pending.Parts {
+				util.PutBool(part != nil, &data)
+			}
+			return data
+		})
+	}
+	for author, attorneys := range s.Attorneys {
+		leaf(rootKey{leaf: attorneyLeaf, key: crypto.Hash(author)}, func() []byte {
+			data := []byte{attorneyLeaf}
+			util.PutToken(author, &data)
+			putTokenSet(attorneys, &data)
+			return data
+		})
+	}
+	for kind, reactions := range s.Reactions {
+		for hash, count := range reactions {
+			leaf(rootKey{leaf: reactionLeaf, kind: byte(kind), key: hash}, func() []byte {
+				data := []byte{reactionLeaf, byte(kind)}
+				util.PutHash(hash, &data)
+				util.PutUint64(uint64(count), &data)
+				return data
+			})
+		}
+	}
+	for epoch, hashes := range s.Deadline {
+		leaf(rootKey{leaf: deadlineLeaf, key: epochKey(epoch)}, func() []byte {
+			data := []byte{deadlineLeaf}
+			util.PutUint64(epoch, &data)
+			for _, hash := range hashes {
+				util.PutHash(hash, &data)
+			}
+			return data
+		})
+	}
+	for hash, kind := range s.Proposals.all {
+		leaf(rootKey{leaf: proposalLeaf, key: hash}, func() []byte {
+			data := []byte{proposalLeaf, kind}
+			util.PutHash(hash, &data)
+			if author, ok := s.Proposals.authors[hash]; ok {
+				util.PutToken(author, &data)
+			}
+			putVotes(s.Proposals.Votes(hash), &data)
+			if draft, ok := s.Proposals.Draft[hash]; ok {
+				data = append(data, draftLeafData(hash, draft)...)
+			}
+			if comment, ok := s.Proposals.Comment[hash]; ok {
+				putComment(comment, &data)
+			}
+			if withdraw, ok := s.Proposals.Withdraw[hash]; ok {
+				util.PutHash(withdraw.Target, &data)
+				putCollectiveName(withdraw.Collective, &data)
+			}
+			return data
+		})
+	}
+	for epoch, applied := range s.Applied {
+		leaf(rootKey{leaf: appliedLeaf, key: epochKey(epoch)}, func() []byte {
+			data := []byte{appliedLeaf}
+			util.PutUint64(epoch, &data)
+			for _, hash := range sortedHashSet(applied) {
+				util.PutHash(hash, &data)
+			}
+			return data
+		})
+	}
+	for token, rotate := range s.Rotations {
+		leaf(rootKey{leaf: rotationLeaf, key: crypto.Hash(token)}, func() []byte {
+			data := []byte{rotationLeaf}
+			util.PutToken(token, &data)
+			util.PutByteArray(rotate.Serialize(), &data)
+			return data
+		})
+	}
+	for old, new := range s.Rotated {
+		leaf(rootKey{leaf: rotatedLeaf, key: crypto.Hash(old)}, func() []byte {
+			data := []byte{rotatedLeaf}
+			util.PutToken(old, &data)
+			util.PutToken(new, &data)
+			return data
+		})
+	}
+	for hash, comment := range s.Comments {
+		leaf(rootKey{leaf: commentLeaf, key: hash}, func() []byte {
+			data := []byte{commentLeaf}
+			putComment(comment, &data)
+			return data
+		})
+	}
+	for object, comments := range s.Threads {
+		leaf(rootKey{leaf: threadLeaf, key: object}, func() []byte {
+			data := []byte{threadLeaf}
+			util.PutHash(object, &data)
+			putCommentHashes(comments, &data)
+			return data
+		})
+	}
+	// leaves of objects removed from the state are dropped with the old cache
+	cache.leaves = leaves
+	cache.stale = make(map[rootKey]struct{})
+	hashes := make([]crypto.Hash, 0, len(leaves))
+	for _, hash := range leaves {
+		hashes = append(hashes, hash)
+	}
+	return merkleRoot(sortHashes(hashes))
+}
+
+// merkleRoot of the given leaves. The last node of a level with odd number of
+// nodes is carried to the next level.
+func merkleRoot(level []crypto.Hash) crypto.Hash {
+	if len(level) == 0 {
+		return crypto.ZeroValueHash
+	}
+	for len(level) > 1 {
+		next := make([]crypto.Hash, 0, (len(level)+1)/2)
+		for n := 0; n+1 < len(level); n += 2 {
+			next = append(next, crypto.Hasher(append(append([]byte{}, level[n][:]...), level[n+1][:]...)))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		level = next
+	}
+	return level[0]
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// checkRoot compares the state root out of cached leaves with the one of
+// every leaf hashed again.
+func checkRoot(t *testing.T, s *State, step string) {
+	t.Helper()
+	fresh := *s
+	fresh.root = newRootCache()
+	if !s.StateRoot().Equal(fresh.StateRoot()) {
+		t.Fatalf("cached state root diverges after %v", step)
+	}
+}
+
+func TestCachedStateRoot(t *testing.T) {
+	s := snapshotTestState(t)
+	author, other := s.MembersIndex["author"], s.MembersIndex["other"]
+	first, second := crypto.Hasher([]byte("first")), crypto.Hasher([]byte("second"))
+	event := crypto.Hasher([]byte("event"))
+	checkRoot(t, s, "genesis")
+
+	// leaves not touched are not hashed again
+	collective := s.Collectives[crypto.Hasher([]byte("collective"))]
+	root := s.StateRoot()
+	collective.Description = "untouched"
+	if !s.StateRoot().Equal(root) {
+		t.Fatal("untouched leaf hashed again")
+	}
+	s.touchCollective(collective.Name)
+	if s.StateRoot().Equal(root) {
+		t.Fatal("touched leaf not hashed again")
+	}
+
+	steps := []struct {
+		name  string
+		apply func() error
+	}{
+		{"pin", func() error {
+			return s.Vote(&actions.Vote{Epoch: 42, Author: author, Hash: crypto.Hasher([]byte("pin")), Approve: true})
+		}},
+		{"event update", func() error {
+			return s.Vote(&actions.Vote{Epoch: 42, Author: author, Hash: crypto.Hasher([]byte("event update")), Approve: true})
+		}},
+		{"draft vote", func() error {
+			return s.Vote(&actions.Vote{Epoch: 42, Author: other, Hash: first, Approve: true})
+		}},
+		{"react", func() error {
+			return s.React(&actions.React{Epoch: 42, Author: other, Hash: second, Reaction: 1})
+		}},
+		{"checkin", func() error {
+			return s.CheckinEvent(&actions.CheckinEvent{Epoch: 42, Author: author, EventHash: event, EphemeralToken: other})
+		}},
+		{"greet", func() error {
+			return s.GreetCheckinEvent(&actions.GreetCheckinEvent{Epoch: 42, Author: author, EventHash: event, CheckedIn: other})
+		}},
+		{"comment", func() error {
+			return s.Comment(&actions.Comment{Epoch: 42, Author: author, Object: first, Content: "opening"})
+		}},
+		{"reply", func() error {
+			opening := &actions.Comment{Epoch: 42, Author: author, Object: first, Content: "opening"}
+			return s.Comment(&actions.Comment{Epoch: 42, Author: other, Object: first, Parent: opening.Hashed(), Content: "reply"})
+		}},
+		{"stamp", func() error {
+			return s.ImprintStamp(&actions.ImprintStamp{Epoch: 42, Author: other, OnBehalfOf: "collective", Hash: second})
+		}},
+		{"change handle", func() error {
+			return s.ChangeHandle(&actions.ChangeHandle{Epoch: 42, Author: other, Handle: "renamed"})
+		}},
+		{"grant attorney", func() error {
+			attorney, _ := crypto.RandomAsymetricKey()
+			return s.GrantPowerOfAttorney(&actions.GrantPowerOfAttorney{Epoch: 42, Author: other, Attorney: attorney})
+		}},
+		{"revoke attorney", func() error {
+			for attorney := range s.Attorneys[author] {
+				return s.RevokePowerOfAttorney(&actions.RevokePowerOfAttorney{Epoch: 42, Author: author, Attorney: attorney})
+			}
+			return nil
+		}},
+		{"leave collective", func() error {
+			return s.RequestMembership(&actions.RequestMembership{Epoch: 42, Author: other, Collective: "collective"})
+		}},
+		{"rotate key", func() error {
+			key, _ := crypto.RandomAsymetricKey()
+			return s.RotateKey(&actions.RotateKey{Epoch: 42, Author: author, NewKey: key, TimeLock: 43})
+		}},
+	}
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("could not apply %v: %v", step.name, err)
+		}
+		checkRoot(t, s, step.name)
+	}
+	// the rotation is due on the next block
+	for n := 0; n < 3; n++ {
+		s.NextBlock()
+		checkRoot(t, s, "next block")
+	}
+	if _, ok := s.Rotated[author]; !ok {
+		t.Fatal("key not rotated")
+	}
+}
@@ -0,0 +1,1684 @@
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/breeze/util"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+/*
+Snapshot serializes the entire state so that a node can start from it instead
+of replaying every action since genesis.
+
+Objects referenced from several places (drafts pinned on boards and pointing to
+previous versions, releases and their stamps, collectives and the photos taken
+of them by pending proposals, ...) are written once on a table for their type
+and referenced by their position on that table (0 for nil). The snapshot is
+
+	number of objects on each table | tables | state maps | proposals
+
+Restore allocates every object first and fills them afterwards, so that cycles
+and shared pointers come back as they were. Maps are written in key order, so
+that equal states produce equal snapshots.
+
+The Indexer is not part of the snapshot.
+*/
+
+var ErrInvalidSnapshot = errors.New("invalid state snapshot")
+
+type snapshotWriter struct {
+	data        []byte
+	collectives []*Collective
+	unamed      []*UnamedCollective
+	drafts      []*Draft
+	edits       []*Edit
+	releases    []*Release
+	stamps      []*Stamp
+	boards      []*Board
+	events      []*Event
+	ids         map[interface{}]uint32
+}
+
+func sortHashes(hashes []crypto.Hash) []crypto.Hash {
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	return hashes
+}
+
+func sortTokens(tokens []crypto.Token) []crypto.Token {
+	sort.Slice(tokens, func(i, j int) bool { return bytes.Compare(tokens[i][:], tokens[j][:]) < 0 })
+	return tokens
+}
+
+func sortedTokenSet(set map[crypto.Token]struct{}) []crypto.Token {
+	tokens := make([]crypto.Token, 0, len(set))
+	for token := range set {
+		tokens = append(tokens, token)
+	}
+	return sortTokens(tokens)
+}
+
+func sortedHashSet(set map[crypto.Hash]struct{}) []crypto.Hash {
+	hashes := make([]crypto.Hash, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	return sortHashes(hashes)
+}
+
+// seen checks if the object was already given an id on its table. Ids are
+// given before visiting the references of the object, so cycles terminate.
+func (w *snapshotWriter) seen(object interface{}) bool {
+	_, ok := w.ids[object]
+	return ok
+}
+
+func (w *snapshotWriter) visitCollective(c *Collective) {
+	if c == nil || w.seen(c) {
+		return
+	}
+	w.ids[c] = uint32(len(w.collectives) + 1)
+	w.collectives = append(w.collectives, c)
+}
+
+func (w *snapshotWriter) visitUnamed(c *UnamedCollective) {
+	if c == nil || w.seen(c) {
+		return
+	}
+	w.ids[c] = uint32(len(w.unamed) + 1)
+	w.unamed = append(w.unamed, c)
+}
+
+func (w *snapshotWriter) visitConsensual(c Consensual) {
+	switch v := c.(type) {
+	case *Collective:
+		w.visitCollective(v)
+	case *UnamedCollective:
+		w.visitUnamed(v)
+	}
+}
+
+func (w *snapshotWriter) visitDraft(d *Draft) {
+	if d == nil || w.seen(d) {
+		return
+	}
+	w.ids[d] = uint32(len(w.drafts) + 1)
+	w.drafts = append(w.drafts, d)
+	w.visitConsensual(d.Authors)
+	w.visitDraft(d.PreviousVersion)
+	for _, board := range d.Pinned {
+		w.visitBoard(board)
+	}
+	for _, edit := range d.Edits {
+		w.visitEdit(edit)
+	}
+}
+
+func (w *snapshotWriter) visitEdit(e *Edit) {
+	if e == nil || w.seen(e) {
+		return
+	}
+	w.ids[e] = uint32(len(w.edits) + 1)
+	w.edits = append(w.edits, e)
+	w.visitConsensual(e.Authors)
+	w.visitDraft(e.Draft)
+}
+
+func (w *snapshotWriter) visitRelease(r *Release) {
+	if r == nil || w.seen(r) {
+		return
+	}
+	w.ids[r] = uint32(len(w.releases) + 1)
+	w.releases = append(w.releases, r)
+	w.visitDraft(r.Draft)
+	for _, stamp := range r.Stamps {
+		w.visitStamp(stamp)
+	}
+}
+
+func (w *snapshotWriter) visitStamp(s *Stamp) {
+	if s == nil || w.seen(s) {
+		return
+	}
+	w.ids[s] = uint32(len(w.stamps) + 1)
+	w.stamps = append(w.stamps, s)
+	w.visitCollective(s.Reputation)
+	w.visitRelease(s.Release)
+}
+
+func (w *snapshotWriter) visitBoard(b *Board) {
+	if b == nil || w.seen(b) {
+		return
+	}
+	w.ids[b] = uint32(len(w.boards) + 1)
+	w.boards = append(w.boards, b)
+	w.visitCollective(b.Collective)
+	w.visitUnamed(b.Editors)
+	for _, draft := range b.Pinned {
+		w.visitDraft(draft)
+	}
+}
+
+func (w *snapshotWriter) visitEvent(e *Event) {
+	if e == nil || w.seen(e) {
+		return
+	}
+	w.ids[e] = uint32(len(w.events) + 1)
+	w.events = append(w.events, e)
+	w.visitCollective(e.Collective)
+	w.visitUnamed(e.Managers)
+}
+
+func (w *snapshotWriter) ref(object interface{}, isNil bool) {
+	if isNil {
+		util.PutUint32(0, &w.data)
+		return
+	}
+	util.PutUint32(w.ids[object], &w.data)
+}
+
+func (w *snapshotWriter) consensual(c Consensual) {
+	switch v := c.(type) {
+	case *Collective:
+		util.PutByte(1, &w.data)
+		w.ref(v, v == nil)
+	case *UnamedCollective:
+		util.PutByte(2, &w.data)
+		w.ref(v, v == nil)
+	default:
+		util.PutByte(0, &w.data)
+	}
+}
+
+func (w *snapshotWriter) strings(words []string) {
+	util.PutUint32(uint32(len(words)), &w.data)
+	for _, word := range words {
+		util.PutString(word, &w.data)
+	}
+}
+
+func (w *snapshotWriter) tokens(tokens []crypto.Token) {
+	util.PutUint32(uint32(len(tokens)), &w.data)
+	for _, token := range tokens {
+		util.PutToken(token, &w.data)
+	}
+}
+
+func (w *snapshotWriter) hashes(hashes []crypto.Hash) {
+	util.PutUint32(uint32(len(hashes)), &w.data)
+	for _, hash := range hashes {
+		util.PutHash(hash, &w.data)
+	}
+}
+
+func (w *snapshotWriter) votes(votes []actions.Vote) {
+	util.PutUint32(uint32(len(votes)), &w.data)
+	for _, vote := range votes {
+		util.PutByteArray(vote.Serialize(), &w.data)
+	}
+}
+
+// action writes an optional action
+func (w *snapshotWriter) action(action actions.Action, isNil bool) {
+	if isNil {
+		util.PutBool(false, &w.data)
+		return
+	}
+	util.PutBool(true, &w.data)
+	util.PutByteArray(action.Serialize(), &w.data)
+}
+
+func (w *snapshotWriter) collective(c *Collective) {
+	util.PutString(c.Name, &w.data)
+	w.tokens(sortedTokenSet(c.Members))
+	util.PutString(c.Description, &w.data)
+	util.PutUint32(uint32(c.Policy.Majority), &w.data)
+	util.PutUint32(uint32(c.Policy.SuperMajority), &w.data)
+	util.PutUint32(uint32(c.Policy.Quorum), &w.data)
+	util.PutBool(c.Policy.AcceptOnExpiry, &w.data)
+	actions.PutVotingWindow(c.Policy.VotingWindow, &w.data)
+	actions.PutStrategy(c.Policy.Strategy, &w.data)
+}
+
+func (w *snapshotWriter) unamedCollective(c *UnamedCollective) {
+	w.tokens(sortedTokenSet(c.Members))
+	util.PutUint32(uint32(c.Majority), &w.data)
+}
+
+func (w *snapshotWriter) draft(d *Draft) {
+	util.PutString(d.Title, &w.data)
+	util.PutUint64(d.Date, &w.data)
+	util.PutString(d.Description, &w.data)
+	w.consensual(d.Authors)
+	util.PutString(d.DraftType, &w.data)
+	util.PutHash(d.DraftHash, &w.data)
+	w.ref(d.PreviousVersion, d.PreviousVersion == nil)
+	w.strings(d.Keywords)
+	w.hashes(d.References)
+	w.votes(d.Votes)
+	util.PutUint32(uint32(len(d.Pinned)), &w.data)
+	for _, board := range d.Pinned {
+		w.ref(board, board == nil)
+	}
+	util.PutUint32(uint32(len(d.Edits)), &w.data)
+	for _, edit := range d.Edits {
+		w.ref(edit, edit == nil)
+	}
+	util.PutBool(d.Aproved, &w.data)
+}
+
+func (w *snapshotWriter) edit(e *Edit) {
+	w.consensual(e.Authors)
+	util.PutUint64(e.Date, &w.data)
+	util.PutString(e.Reasons, &w.data)
+	w.ref(e.Draft, e.Draft == nil)
+	util.PutString(e.EditType, &w.data)
+	util.PutHash(e.Edit, &w.data)
+	w.votes(e.Votes)
+}
+
+func (w *snapshotWriter) release(r *Release) {
+	util.PutUint64(r.Epoch, &w.data)
+	w.ref(r.Draft, r.Draft == nil)
+	util.PutHash(r.Hash, &w.data)
+	w.votes(r.Votes)
+	util.PutBool(r.Released, &w.data)
+	util.PutUint32(uint32(len(r.Stamps)), &w.data)
+	for _, stamp := range r.Stamps {
+		w.ref(stamp, stamp == nil)
+	}
+}
+
+func (w *snapshotWriter) stamp(s *Stamp) {
+	w.ref(s.Reputation, s.Reputation == nil)
+	w.ref(s.Release, s.Release == nil)
+	util.PutHash(s.Hash, &w.data)
+	w.votes(s.Votes)
+	util.PutBool(s.Imprinted, &w.data)
+}
+
+func (w *snapshotWriter) board(b *Board) {
+	util.PutString(b.Name, &w.data)
+	w.strings(b.Keyword)
+	util.PutString(b.Description, &w.data)
+	w.ref(b.Collective, b.Collective == nil)
+	w.ref(b.Editors, b.Editors == nil)
+	util.PutUint32(uint32(len(b.Pinned)), &w.data)
+	for _, draft := range b.Pinned {
+		w.ref(draft, draft == nil)
+	}
+	util.PutHash(b.Hash, &w.data)
+}
+
+func (w *snapshotWriter) event(e *Event) {
+	w.ref(e.Collective, e.Collective == nil)
+	util.PutTime(e.StartAt, &w.data)
+	util.PutTime(e.EstimatedEnd, &w.data)
+	util.PutString(e.Description, &w.data)
+	util.PutString(e.Venue, &w.data)
+	util.PutBool(e.Open, &w.data)
+	util.PutBool(e.Public, &w.data)
+	util.PutHash(e.Hash, &w.data)
+	w.ref(e.Managers, e.Managers == nil)
+	w.votes(e.Votes)
+	checkins := make([]crypto.Token, 0, len(e.Checkin))
+	for token := range e.Checkin {
+		checkins = append(checkins, token)
+	}
+	util.PutUint32(uint32(len(checkins)), &w.data)
+	for _, token := range sortTokens(checkins) {
+		util.PutToken(token, &w.data)
+		greeting := e.Checkin[token]
+		util.PutBool(greeting != nil, &w.data)
+		if greeting != nil {
+			w.action(greeting.Action, greeting.Action == nil)
+			util.PutToken(greeting.EphemeralKey, &w.data)
+		}
+	}
+	reasons := make([]crypto.Token, 0, len(e.CheckinReasons))
+	for token := range e.CheckinReasons {
+		reasons = append(reasons, token)
+	}
+	util.PutUint32(uint32(len(reasons)), &w.data)
+	for _, token := range sortTokens(reasons) {
+		util.PutToken(token, &w.data)
+		util.PutString(e.CheckinReasons[token], &w.data)
+	}
+	util.PutBool(e.Live, &w.data)
+	util.PutString(e.EventReasons, &w.data)
+}
+
+func (w *snapshotWriter) comment(c *Comment) {
+	util.PutHash(c.Hash, &w.data)
+	util.PutUint64(c.Epoch, &w.data)
+	util.PutToken(c.Author, &w.data)
+	w.ref(c.Collective, c.Collective == nil)
+	util.PutHash(c.Object, &w.data)
+	util.PutBool(c.Parent != nil, &w.data)
+	if c.Parent != nil {
+		util.PutHash(c.Parent.Hash, &w.data)
+	}
+	util.PutString(c.Content, &w.data)
+	w.commentHashes(c.Replies)
+	w.votes(c.Votes)
+	util.PutBool(c.Published, &w.data)
+}
+
+// commentHashes writes comments by their hash, they are linked back to the
+// published comments on restore
+func (w *snapshotWriter) commentHashes(comments []*Comment) {
+	util.PutUint32(uint32(len(comments)), &w.data)
+	for _, comment := range comments {
+		util.PutHash(comment.Hash, &w.data)
+	}
+}
+
+func (w *snapshotWriter) optionalString(s *string) {
+	util.PutBool(s != nil, &w.data)
+	if s != nil {
+		util.PutString(*s, &w.data)
+	}
+}
+
+func (w *snapshotWriter) optionalBool(b *bool) {
+	util.PutBool(b != nil, &w.data)
+	if b != nil {
+		util.PutBool(*b, &w.data)
+	}
+}
+
+func (w *snapshotWriter) optionalByte(b *byte) {
+	util.PutBool(b != nil, &w.data)
+	if b != nil {
+		util.PutByte(*b, &w.data)
+	}
+}
+
+func (w *snapshotWriter) optionalTime(t *time.Time) {
+	util.PutBool(t != nil, &w.data)
+	if t != nil {
+		util.PutTime(*t, &w.data)
+	}
+}
+
+// visitState gives an id to every shared object reachable from the state and
+// returns the sorted keys of the maps of the state that hold them.
+func (w *snapshotWriter) visitState(s *State) (drafts, edits, releases, events, collectives, boards, pending []crypto.Hash) {
+	p := s.Proposals
+	drafts = make([]crypto.Hash, 0, len(s.Drafts))
+	for hash := range s.Drafts {
+		drafts = append(drafts, hash)
+	}
+	edits = make([]crypto.Hash, 0, len(s.Edits))
+	for hash := range s.Edits {
+		edits = append(edits, hash)
+	}
+	releases = make([]crypto.Hash, 0, len(s.Releases))
+	for hash := range s.Releases {
+		releases = append(releases, hash)
+	}
+	events = make([]crypto.Hash, 0, len(s.Events))
+	for hash := range s.Events {
+		events = append(events, hash)
+	}
+	collectives = make([]crypto.Hash, 0, len(s.Collectives))
+	for hash := range s.Collectives {
+		collectives = append(collectives, hash)
+	}
+	boards = make([]crypto.Hash, 0, len(s.Boards))
+	for hash := range s.Boards {
+		boards = append(boards, hash)
+	}
+	pending = make([]crypto.Hash, 0, len(p.all))
+	for hash := range p.all {
+		pending = append(pending, hash)
+	}
+	sortHashes(drafts)
+	sortHashes(edits)
+	sortHashes(releases)
+	sortHashes(events)
+	sortHashes(collectives)
+	sortHashes(boards)
+	sortHashes(pending)
+
+	for _, hash := range collectives {
+		w.visitCollective(s.Collectives[hash])
+	}
+	for _, hash := range boards {
+		w.visitBoard(s.Boards[hash])
+	}
+	for _, hash := range drafts {
+		w.visitDraft(s.Drafts[hash])
+	}
+	for _, hash := range edits {
+		w.visitEdit(s.Edits[hash])
+	}
+	for _, hash := range releases {
+		w.visitRelease(s.Releases[hash])
+	}
+	for _, hash := range events {
+		w.visitEvent(s.Events[hash])
+	}
+	for _, hash := range pending {
+		switch p.all[hash] {
+		case UpdateCollectiveProposal:
+			if v := p.UpdateCollective[hash]; v != nil {
+				w.visitCollective(v.Collective)
+			}
+		case RequestMembershipProposal:
+			if v := p.RequestMembership[hash]; v != nil {
+				w.visitCollective(v.Collective)
+			}
+		case RemoveMemberProposal:
+			if v := p.RemoveMember[hash]; v != nil {
+				w.visitCollective(v.Collective)
+			}
+		case DraftProposal:
+			w.visitDraft(p.Draft[hash])
+		case EditProposal:
+			w.visitEdit(p.Edit[hash])
+		case CreateBoardProposal:
+			if v := p.CreateBoard[hash]; v != nil {
+				w.visitBoard(v.Board)
+			}
+		case UpdateBoardProposal:
+			if v := p.UpdateBoard[hash]; v != nil {
+				w.visitBoard(v.Board)
+			}
+		case PinProposal:
+			if v := p.Pin[hash]; v != nil {
+				w.visitBoard(v.Board)
+				w.visitDraft(v.Draft)
+			}
+		case BoardEditorProposal:
+			if v := p.BoardEditor[hash]; v != nil {
+				w.visitBoard(v.Board)
+			}
+		case ReleaseDraftProposal:
+			w.visitRelease(p.ReleaseDraft[hash])
+		case ImprintStampProposal:
+			w.visitStamp(p.ImprintStamp[hash])
+		case CreateEventProposal:
+			w.visitEvent(p.CreateEvent[hash])
+		case CancelEventProposal:
+			if v := p.CancelEvent[hash]; v != nil {
+				w.visitEvent(v.Event)
+			}
+		case UpdateEventProposal:
+			if v := p.UpdateEvent[hash]; v != nil {
+				w.visitEvent(v.Event)
+			}
+		case EventCheckinGreetProposal:
+			if v := p.GreetCheckin[hash]; v != nil {
+				w.visitEvent(v.Event)
+			}
+		case CommentProposal:
+			if v := p.Comment[hash]; v != nil {
+				w.visitCollective(v.Collective)
+			}
+		case WithdrawProposal:
+			if v := p.Withdraw[hash]; v != nil {
+				w.visitCollective(v.Collective)
+			}
+		}
+	}
+	return
+}
+
+// Snapshot serializes the entire state.
+func (s *State) Snapshot() []byte {
+	s.RLock()
+	defer s.RUnlock()
+	w := &snapshotWriter{data: make([]byte, 0), ids: make(map[interface{}]uint32)}
+	p := s.Proposals
+
+	drafts, edits, releases, events, collectives, boards, pending := w.visitState(s)
+
+	// tables
+	for _, count := range []int{len(w.collectives), len(w.unamed), len(w.drafts), len(w.edits), len(w.releases), len(w.stamps), len(w.boards), len(w.events)} {
+		util.PutUint32(uint32(count), &w.data)
+	}
+	for _, c := range w.collectives {
+		w.collective(c)
+	}
+	for _, c := range w.unamed {
+		w.unamedCollective(c)
+	}
+	for _, d := range w.drafts {
+		w.draft(d)
+	}
+	for _, e := range w.edits {
+		w.edit(e)
+	}
+	for _, r := range w.releases {
+		w.release(r)
+	}
+	for _, stamp := range w.stamps {
+		w.stamp(stamp)
+	}
+	for _, b := range w.boards {
+		w.board(b)
+	}
+	for _, e := range w.events {
+		w.event(e)
+	}
+
+	// state
+	util.PutUint64(s.Epoch, &w.data)
+	util.PutTime(s.GenesisTime, &w.data)
+	handles := make([]string, 0, len(s.MembersIndex))
+	for handle := range s.MembersIndex {
+		handles = append(handles, handle)
+	}
+	sort.Strings(handles)
+	util.PutUint32(uint32(len(handles)), &w.data)
+	for _, handle := range handles {
+		util.PutString(handle, &w.data)
+		util.PutToken(s.MembersIndex[handle], &w.data)
+	}
+	members := make([]crypto.Hash, 0, len(s.Members))
+	for hash := range s.Members {
+		members = append(members, hash)
+	}
+	util.PutUint32(uint32(len(members)), &w.data)
+	for _, hash := range sortHashes(members) {
+		util.PutHash(hash, &w.data)
+		util.PutString(s.Members[hash], &w.data)
+	}
+	media := make([]crypto.Hash, 0, len(s.PendingMedia))
+	for hash := range s.PendingMedia {
+		media = append(media, hash)
+	}
+	util.PutUint32(uint32(len(media)), &w.data)
+	for _, hash := range sortHashes(media) {
+		pending := s.PendingMedia[hash]
+		util.PutHash(hash, &w.data)
+		util.PutHash(pending.Hash, &w.data)
+		util.PutByte(pending.NumberOfParts, &w.data)
+		util.PutUint32(uint32(len(pending.Parts)), &w.data)
+		for _, part := range pending.Parts {
+			w.action(part, part == nil)
+		}
+	}
+	media = make([]crypto.Hash, 0, len(s.Media))
+	for hash := range s.Media {
+		media = append(media, hash)
+	}
+	util.PutUint32(uint32(len(media)), &w.data)
+	for _, hash := range sortHashes(media) {
+		util.PutHash(hash, &w.data)
+		util.PutByteArray(s.Media[hash], &w.data)
+	}
+	util.PutUint32(uint32(len(drafts)), &w.data)
+	for _, hash := range drafts {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Drafts[hash], s.Drafts[hash] == nil)
+	}
+	util.PutUint32(uint32(len(edits)), &w.data)
+	for _, hash := range edits {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Edits[hash], s.Edits[hash] == nil)
+	}
+	util.PutUint32(uint32(len(releases)), &w.data)
+	for _, hash := range releases {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Releases[hash], s.Releases[hash] == nil)
+	}
+	util.PutUint32(uint32(len(events)), &w.data)
+	for _, hash := range events {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Events[hash], s.Events[hash] == nil)
+	}
+	util.PutUint32(uint32(len(collectives)), &w.data)
+	for _, hash := range collectives {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Collectives[hash], s.Collectives[hash] == nil)
+	}
+	util.PutUint32(uint32(len(boards)), &w.data)
+	for _, hash := range boards {
+		util.PutHash(hash, &w.data)
+		w.ref(s.Boards[hash], s.Boards[hash] == nil)
+	}
+	epochs := make([]uint64, 0, len(s.Deadline))
+	for epoch := range s.Deadline {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	util.PutUint32(uint32(len(epochs)), &w.data)
+	for _, epoch := range epochs {
+		util.PutUint64(epoch, &w.data)
+		w.hashes(s.Deadline[epoch])
+	}
+	for _, reactions := range s.Reactions {
+		hashes := make([]crypto.Hash, 0, len(reactions))
+		for hash := range reactions {
+			hashes = append(hashes, hash)
+		}
+		util.PutUint32(uint32(len(hashes)), &w.data)
+		for _, hash := range sortHashes(hashes) {
+			util.PutHash(hash, &w.data)
+			util.PutUint64(uint64(reactions[hash]), &w.data)
+		}
+	}
+	authors := make([]crypto.Token, 0, len(s.Attorneys))
+	for token := range s.Attorneys {
+		authors = append(authors, token)
+	}
+	util.PutUint32(uint32(len(authors)), &w.data)
+	for _, token := range sortTokens(authors) {
+		util.PutToken(token, &w.data)
+		w.tokens(sortedTokenSet(s.Attorneys[token]))
+	}
+	epochs = epochs[:0]
+	for epoch := range s.Applied {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	util.PutUint32(uint32(len(epochs)), &w.data)
+	for _, epoch := range epochs {
+		util.PutUint64(epoch, &w.data)
+		w.hashes(sortedHashSet(s.Applied[epoch]))
+	}
+	authors = authors[:0]
+	for token := range s.Rotations {
+		authors = append(authors, token)
+	}
+	util.PutUint32(uint32(len(authors)), &w.data)
+	for _, token := range sortTokens(authors) {
+		util.PutToken(token, &w.data)
+		util.PutByteArray(s.Rotations[token].Serialize(), &w.data)
+	}
+	authors = authors[:0]
+	for token := range s.Rotated {
+		authors = append(authors, token)
+	}
+	util.PutUint32(uint32(len(authors)), &w.data)
+	for _, token := range sortTokens(authors) {
+		util.PutToken(token, &w.data)
+		util.PutToken(s.Rotated[token], &w.data)
+	}
+	comments := make([]crypto.Hash, 0, len(s.Comments))
+	for hash := range s.Comments {
+		comments = append(comments, hash)
+	}
+	util.PutUint32(uint32(len(comments)), &w.data)
+	for _, hash := range sortHashes(comments) {
+		util.PutHash(hash, &w.data)
+		w.comment(s.Comments[hash])
+	}
+	objects := make([]crypto.Hash, 0, len(s.Threads))
+	for hash := range s.Threads {
+		objects = append(objects, hash)
+	}
+	util.PutUint32(uint32(len(objects)), &w.data)
+	for _, hash := range sortHashes(objects) {
+		util.PutHash(hash, &w.data)
+		w.commentHashes(s.Threads[hash])
+	}
+
+	// pending proposals
+	util.PutUint32(uint32(len(pending)), &w.data)
+	for _, hash := range pending {
+		kind := p.all[hash]
+		util.PutHash(hash, &w.data)
+		util.PutByte(kind, &w.data)
+		author, ok := p.authors[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			util.PutToken(author, &w.data)
+		}
+		w.proposal(p, kind, hash)
+	}
+	return w.data
+}
+
+func (w *snapshotWriter) proposal(p *Proposals, kind byte, hash crypto.Hash) {
+	switch kind {
+	case UpdateCollectiveProposal:
+		v, ok := p.UpdateCollective[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.action(v.Update, v.Update == nil)
+			w.ref(v.Collective, v.Collective == nil)
+			util.PutHash(v.Hash, &w.data)
+			util.PutBool(v.ChangePolicy, &w.data)
+			w.votes(v.Votes)
+		}
+	case RequestMembershipProposal:
+		v, ok := p.RequestMembership[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.action(v.Request, v.Request == nil)
+			w.ref(v.Collective, v.Collective == nil)
+			util.PutHash(v.Hash, &w.data)
+			w.votes(v.Votes)
+		}
+	case RemoveMemberProposal:
+		v, ok := p.RemoveMember[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.action(v.Remove, v.Remove == nil)
+			w.ref(v.Collective, v.Collective == nil)
+			util.PutHash(v.Hash, &w.data)
+			w.votes(v.Votes)
+		}
+	case DraftProposal:
+		v, ok := p.Draft[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v, v == nil)
+		}
+	case EditProposal:
+		v, ok := p.Edit[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v, v == nil)
+		}
+	case CreateBoardProposal:
+		v, ok := p.CreateBoard[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.action(v.Origin, v.Origin == nil)
+			w.ref(v.Board, v.Board == nil)
+			util.PutHash(v.Hash, &w.data)
+			w.votes(v.Votes)
+		}
+	case UpdateBoardProposal:
+		v, ok := p.UpdateBoard[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.action(v.Origin, v.Origin == nil)
+			util.PutBool(v.Keywords != nil, &w.data)
+			if v.Keywords != nil {
+				w.strings(*v.Keywords)
+			}
+			w.optionalByte(v.PinMajority)
+			w.optionalString(v.Description)
+			w.ref(v.Board, v.Board == nil)
+			util.PutHash(v.Hash, &w.data)
+			w.votes(v.Votes)
+		}
+	case PinProposal:
+		v, ok := p.Pin[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			util.PutHash(v.Hash, &w.data)
+			util.PutUint64(v.Epoch, &w.data)
+			w.ref(v.Board, v.Board == nil)
+			w.ref(v.Draft, v.Draft == nil)
+			util.PutBool(v.Pin, &w.data)
+			w.votes(v.Votes)
+		}
+	case BoardEditorProposal:
+		v, ok := p.BoardEditor[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			util.PutHash(v.Hash, &w.data)
+			util.PutUint64(v.Epoch, &w.data)
+			w.ref(v.Board, v.Board == nil)
+			util.PutToken(v.Editor, &w.data)
+			util.PutBool(v.Insert, &w.data)
+			w.votes(v.Votes)
+		}
+	case ReleaseDraftProposal:
+		v, ok := p.ReleaseDraft[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v, v == nil)
+		}
+	case ImprintStampProposal:
+		v, ok := p.ImprintStamp[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v, v == nil)
+		}
+	case CreateEventProposal:
+		v, ok := p.CreateEvent[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v, v == nil)
+		}
+	case CancelEventProposal:
+		v, ok := p.CancelEvent[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v.Event, v.Event == nil)
+			util.PutHash(v.Hash, &w.data)
+			w.votes(v.Votes)
+			util.PutString(v.Reasons, &w.data)
+		}
+	case UpdateEventProposal:
+		v, ok := p.UpdateEvent[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v.Event, v.Event == nil)
+			w.optionalTime(v.StartAt)
+			w.optionalTime(v.EstimatedEnd)
+			w.optionalString(v.Description)
+			w.optionalString(v.Venue)
+			w.optionalBool(v.Open)
+			w.optionalBool(v.Public)
+			w.optionalByte(v.ManagerMajority)
+			w.votes(v.Votes)
+			util.PutHash(v.Hash, &w.data)
+			util.PutBool(v.Updated, &w.data)
+			util.PutString(v.Reasons, &w.data)
+		}
+	case CommentProposal:
+		v, ok := p.Comment[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.comment(v)
+		}
+	case WithdrawProposal:
+		v, ok := p.Withdraw[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			util.PutHash(v.Hash, &w.data)
+			util.PutHash(v.Target, &w.data)
+			w.ref(v.Collective, v.Collective == nil)
+			w.votes(v.Votes)
+		}
+	case EventCheckinGreetProposal:
+		v, ok := p.GreetCheckin[hash]
+		util.PutBool(ok, &w.data)
+		if ok {
+			w.ref(v.Event, v.Event == nil)
+			util.PutHash(v.Hash, &w.data)
+			util.PutUint32(uint32(len(v.Greets)), &w.data)
+			for _, greet := range v.Greets {
+				util.PutByteArray(greet.Serialize(), &w.data)
+			}
+		}
+	default:
+		util.PutBool(false, &w.data)
+	}
+}
+
+type snapshotReader struct {
+	data        []byte
+	position    int
+	invalid     bool
+	collectives []*Collective
+	unamed      []*UnamedCollective
+	drafts      []*Draft
+	edits       []*Edit
+	releases    []*Release
+	stamps      []*Stamp
+	boards      []*Board
+	events      []*Event
+}
+
+// check marks the snapshot as invalid if the last read went beyond the data
+func (r *snapshotReader) check(position int) {
+	if position > len(r.data) {
+		r.invalid = true
+		r.position = len(r.data)
+		return
+	}
+	r.position = position
+}
+
+func (r *snapshotReader) fits(size int) bool {
+	if r.invalid || r.position+size > len(r.data) {
+		r.invalid = true
+		return false
+	}
+	return true
+}
+
+func (r *snapshotReader) byte() byte {
+	if !r.fits(1) {
+		return 0
+	}
+	r.position += 1
+	return r.data[r.position-1]
+}
+
+func (r *snapshotReader) bool() bool {
+	b := r.byte()
+	if b > 1 {
+		r.invalid = true
+	}
+	return b == 1
+}
+
+func (r *snapshotReader) uint32() uint32 {
+	if !r.fits(4) {
+		return 0
+	}
+	v, position := util.ParseUint32(r.data, r.position)
+	r.check(position)
+	return v
+}
+
+func (r *snapshotReader) votingWindow() map[byte]uint64 {
+	count := int(r.byte())
+	if count == 0 {
+		return nil
+	}
+	window := make(map[byte]uint64, count)
+	for n := 0; n < count; n++ {
+		kind := r.byte()
+		window[kind] = r.uint64()
+	}
+	return window
+}
+
+func (r *snapshotReader) strategy() actions.Strategy {
+	strategy := actions.Strategy{Kind: r.byte()}
+	count := int(r.uint16())
+	if count > 0 {
+		strategy.Weights = make(map[crypto.Token]int, count)
+	}
+	for n := 0; n < count && !r.invalid; n++ {
+		token := r.token()
+		strategy.Weights[token] = int(r.uint32())
+	}
+	count = int(r.uint16())
+	for n := 0; n < count && !r.invalid; n++ {
+		strategy.Roles = append(strategy.Roles, r.token())
+	}
+	strategy.Required = int(r.uint16())
+	return strategy
+}
+
+func (r *snapshotReader) uint16() uint16 {
+	if !r.fits(2) {
+		return 0
+	}
+	v, position := util.ParseUint16(r.data, r.position)
+	r.check(position)
+	return v
+}
+
+func (r *snapshotReader) uint64() uint64 {
+	if !r.fits(8) {
+		return 0
+	}
+	v, position := util.ParseUint64(r.data, r.position)
+	r.check(position)
+	return v
+}
+
+// count reads the length of a list checking that it is sensible for the
+// remaining data (each entry takes at least one byte)
+func (r *snapshotReader) count() int {
+	n := int(r.uint32())
+	if !r.fits(n) {
+		return 0
+	}
+	return n
+}
+
+func (r *snapshotReader) token() crypto.Token {
+	if !r.fits(crypto.TokenSize) {
+		return crypto.ZeroToken
+	}
+	token, position := util.ParseToken(r.data, r.position)
+	r.check(position)
+	return token
+}
+
+func (r *snapshotReader) hash() crypto.Hash {
+	if !r.fits(crypto.Size) {
+		return crypto.ZeroValueHash
+	}
+	hash, position := util.ParseHash(r.data, r.position)
+	r.check(position)
+	return hash
+}
+
+func (r *snapshotReader) bytes() []byte {
+	if !r.fits(4) {
+		return nil
+	}
+	bytes, position := util.ParseByteArray(r.data, r.position)
+	r.check(position)
+	return bytes
+}
+
+func (r *snapshotReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *snapshotReader) time() time.Time {
+	if !r.fits(8) {
+		return time.Time{}
+	}
+	t, position := util.ParseTime(r.data, r.position)
+	r.check(position)
+	return t
+}
+
+func (r *snapshotReader) strings() []string {
+	words := make([]string, r.count())
+	for n := range words {
+		words[n] = r.string()
+	}
+	return words
+}
+
+func (r *snapshotReader) tokenSet() map[crypto.Token]struct{} {
+	set := make(map[crypto.Token]struct{})
+	for n := r.count(); n > 0; n-- {
+		set[r.token()] = struct{}{}
+	}
+	return set
+}
+
+func (r *snapshotReader) hashes() []crypto.Hash {
+	hashes := make([]crypto.Hash, r.count())
+	for n := range hashes {
+		hashes[n] = r.hash()
+	}
+	return hashes
+}
+
+func (r *snapshotReader) votes() []actions.Vote {
+	votes := make([]actions.Vote, r.count())
+	for n := range votes {
+		data := r.kinded(actions.AVote)
+		if data == nil {
+			return votes
+		}
+		vote := actions.ParseVote(data)
+		if vote == nil {
+			r.invalid = true
+			return votes
+		}
+		votes[n] = *vote
+	}
+	return votes
+}
+
+// kinded reads a serialized action checking its kind, so that it is safe to
+// hand it to the parser
+func (r *snapshotReader) kinded(kind byte) []byte {
+	data := r.bytes()
+	if actions.ActionKind(data) != kind {
+		r.invalid = true
+		return nil
+	}
+	return data
+}
+
+// action reads an optional serialized action of the given kind
+func (r *snapshotReader) action(kind byte) []byte {
+	if !r.bool() {
+		return nil
+	}
+	return r.kinded(kind)
+}
+
+func (r *snapshotReader) optionalString() *string {
+	if !r.bool() {
+		return nil
+	}
+	s := r.string()
+	return &s
+}
+
+func (r *snapshotReader) optionalBool() *bool {
+	if !r.bool() {
+		return nil
+	}
+	b := r.bool()
+	return &b
+}
+
+func (r *snapshotReader) optionalByte() *byte {
+	if !r.bool() {
+		return nil
+	}
+	b := r.byte()
+	return &b
+}
+
+func (r *snapshotReader) optionalTime() *time.Time {
+	if !r.bool() {
+		return nil
+	}
+	t := r.time()
+	return &t
+}
+
+// id reads a reference to a table with size objects
+func (r *snapshotReader) id(size int) int {
+	id := int(r.uint32())
+	if id > size {
+		r.invalid = true
+		return 0
+	}
+	return id
+}
+
+func (r *snapshotReader) collective() *Collective {
+	if id := r.id(len(r.collectives)); id > 0 {
+		return r.collectives[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) unamedCollective() *UnamedCollective {
+	if id := r.id(len(r.unamed)); id > 0 {
+		return r.unamed[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) consensual() Consensual {
+	switch r.byte() {
+	case 0:
+		return nil
+	case 1:
+		if c := r.collective(); c != nil {
+			return c
+		}
+	case 2:
+		if c := r.unamedCollective(); c != nil {
+			return c
+		}
+	}
+	r.invalid = true
+	return nil
+}
+
+func (r *snapshotReader) draft() *Draft {
+	if id := r.id(len(r.drafts)); id > 0 {
+		return r.drafts[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) edit() *Edit {
+	if id := r.id(len(r.edits)); id > 0 {
+		return r.edits[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) release() *Release {
+	if id := r.id(len(r.releases)); id > 0 {
+		return r.releases[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) stamp() *Stamp {
+	if id := r.id(len(r.stamps)); id > 0 {
+		return r.stamps[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) board() *Board {
+	if id := r.id(len(r.boards)); id > 0 {
+		return r.boards[id-1]
+	}
+	return nil
+}
+
+func (r *snapshotReader) event() *Event {
+	if id := r.id(len(r.events)); id > 0 {
+		return r.events[id-1]
+	}
+	return nil
+}
+
+// publishedComment returns the published comment with the given hash,
+// allocating it if it was not read yet
+func publishedComment(s *State, hash crypto.Hash) *Comment {
+	comment, ok := s.Comments[hash]
+	if !ok {
+		comment = &Comment{Hash: hash}
+		s.Comments[hash] = comment
+	}
+	return comment
+}
+
+func (r *snapshotReader) comment(s *State, c *Comment) {
+	c.Hash = r.hash()
+	c.Epoch = r.uint64()
+	c.Author = r.token()
+	c.Collective = r.collective()
+	c.Object = r.hash()
+	if r.bool() {
+		c.Parent = publishedComment(s, r.hash())
+	}
+	c.Content = r.string()
+	c.Replies = r.commentHashes(s)
+	c.Votes = r.votes()
+	c.Published = r.bool()
+}
+
+func (r *snapshotReader) commentHashes(s *State) []*Comment {
+	comments := make([]*Comment, r.count())
+	for n := range comments {
+		comments[n] = publishedComment(s, r.hash())
+	}
+	return comments
+}
+
+func (r *snapshotReader) fillTables() {
+	for _, c := range r.collectives {
+		c.Name = r.string()
+		c.Members = r.tokenSet()
+		c.Description = r.string()
+		c.Policy.Majority = int(r.uint32())
+		c.Policy.SuperMajority = int(r.uint32())
+		c.Policy.Quorum = int(r.uint32())
+		c.Policy.AcceptOnExpiry = r.bool()
+		c.Policy.VotingWindow = r.votingWindow()
+		c.Policy.Strategy = r.strategy()
+	}
+	for _, c := range r.unamed {
+		c.Members = r.tokenSet()
+		c.Majority = int(r.uint32())
+	}
+	for _, d := range r.drafts {
+		d.Title = r.string()
+		d.Date = r.uint64()
+		d.Description = r.string()
+		d.Authors = r.consensual()
+		d.DraftType = r.string()
+		d.DraftHash = r.hash()
+		d.PreviousVersion = r.draft()
+		d.Keywords = r.strings()
+		d.References = r.hashes()
+		d.Votes = r.votes()
+		d.Pinned = make([]*Board, r.count())
+		for n := range d.Pinned {
+			d.Pinned[n] = r.board()
+		}
+		d.Edits = make([]*Edit, r.count())
+		for n := range d.Edits {
+			d.Edits[n] = r.edit()
+		}
+		d.Aproved = r.bool()
+	}
+	for _, e := range r.edits {
+		e.Authors = r.consensual()
+		e.Date = r.uint64()
+		e.Reasons = r.string()
+		e.Draft = r.draft()
+		e.EditType = r.string()
+		e.Edit = r.hash()
+		e.Votes = r.votes()
+	}
+	for _, release := range r.releases {
+		release.Epoch = r.uint64()
+		release.Draft = r.draft()
+		release.Hash = r.hash()
+		release.Votes = r.votes()
+		release.Released = r.bool()
+		release.Stamps = make([]*Stamp, r.count())
+		for n := range release.Stamps {
+			release.Stamps[n] = r.stamp()
+		}
+	}
+	for _, s := range r.stamps {
+		s.Reputation = r.collective()
+		s.Release = r.release()
+		s.Hash = r.hash()
+		s.Votes = r.votes()
+		s.Imprinted = r.bool()
+	}
+	for _, b := range r.boards {
+		b.Name = r.string()
+		b.Keyword = r.strings()
+		b.Description = r.string()
+		b.Collective = r.collective()
+		b.Editors = r.unamedCollective()
+		b.Pinned = make([]*Draft, r.count())
+		for n := range b.Pinned {
+			b.Pinned[n] = r.draft()
+		}
+		b.Hash = r.hash()
+	}
+	for _, e := range r.events {
+		e.Collective = r.collective()
+		e.StartAt = r.time()
+		e.EstimatedEnd = r.time()
+		e.Description = r.string()
+		e.Venue = r.string()
+		e.Open = r.bool()
+		e.Public = r.bool()
+		e.Hash = r.hash()
+		e.Managers = r.unamedCollective()
+		e.Votes = r.votes()
+		e.Checkin = make(map[crypto.Token]*Greeting)
+		for n := r.count(); n > 0; n-- {
+			token := r.token()
+			if !r.bool() {
+				e.Checkin[token] = nil
+				continue
+			}
+			greeting := &Greeting{}
+			if data := r.action(actions.AGreetCheckinEvent); data != nil {
+				if greeting.Action = actions.ParseGreetCheckinEvent(data); greeting.Action == nil {
+					r.invalid = true
+				}
+			}
+			greeting.EphemeralKey = r.token()
+			e.Checkin[token] = greeting
+		}
+		e.CheckinReasons = make(map[crypto.Token]string)
+		for n := r.count(); n > 0; n-- {
+			token := r.token()
+			e.CheckinReasons[token] = r.string()
+		}
+		e.Live = r.bool()
+		e.EventReasons = r.string()
+	}
+}
+
+// RestoreSnapshot rebuilds the state serialized by Snapshot. The indexer is
+// not populated with the restored objects.
+func RestoreSnapshot(data []byte, indexer Indexer) (*State, error) {
+	r := &snapshotReader{data: data}
+	r.collectives = make([]*Collective, r.count())
+	for n := range r.collectives {
+		r.collectives[n] = &Collective{}
+	}
+	r.unamed = make([]*UnamedCollective, r.count())
+	for n := range r.unamed {
+		r.unamed[n] = &UnamedCollective{}
+	}
+	r.drafts = make([]*Draft, r.count())
+	for n := range r.drafts {
+		r.drafts[n] = &Draft{}
+	}
+	r.edits = make([]*Edit, r.count())
+	for n := range r.edits {
+		r.edits[n] = &Edit{}
+	}
+	r.releases = make([]*Release, r.count())
+	for n := range r.releases {
+		r.releases[n] = &Release{}
+	}
+	r.stamps = make([]*Stamp, r.count())
+	for n := range r.stamps {
+		r.stamps[n] = &Stamp{}
+	}
+	r.boards = make([]*Board, r.count())
+	for n := range r.boards {
+		r.boards[n] = &Board{}
+	}
+	r.events = make([]*Event, r.count())
+	for n := range r.events {
+		r.events[n] = &Event{}
+	}
+	r.fillTables()
+
+	s := GenesisState(indexer)
+	s.Epoch = r.uint64()
+	s.GenesisTime = r.time()
+	for n := r.count(); n > 0; n-- {
+		handle := r.string()
+		s.MembersIndex[handle] = r.token()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Members[hash] = r.string()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		pending := &PendingMedia{Hash: r.hash(), NumberOfParts: r.byte()}
+		pending.Parts = make([]*actions.MultipartMedia, r.count())
+		for part := range pending.Parts {
+			if data := r.action(actions.AMultipartMedia); data != nil {
+				if pending.Parts[part] = actions.ParseMultipartMedia(data); pending.Parts[part] == nil {
+					r.invalid = true
+				}
+			}
+		}
+		s.PendingMedia[hash] = pending
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Media[hash] = r.bytes()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Drafts[hash] = r.draft()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Edits[hash] = r.edit()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Releases[hash] = r.release()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Events[hash] = r.event()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Collectives[hash] = r.collective()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Boards[hash] = r.board()
+	}
+	for n := r.count(); n > 0; n-- {
+		epoch := r.uint64()
+		s.Deadline[epoch] = r.hashes()
+	}
+	for _, reactions := range s.Reactions {
+		for n := r.count(); n > 0; n-- {
+			hash := r.hash()
+			reactions[hash] = uint(r.uint64())
+		}
+	}
+	for n := r.count(); n > 0; n-- {
+		token := r.token()
+		s.Attorneys[token] = r.tokenSet()
+	}
+	for n := r.count(); n > 0; n-- {
+		epoch := r.uint64()
+		applied := make(map[crypto.Hash]struct{})
+		for _, hash := range r.hashes() {
+			applied[hash] = struct{}{}
+		}
+		s.Applied[epoch] = applied
+	}
+	for n := r.count(); n > 0; n-- {
+		token := r.token()
+		if data := r.kinded(actions.ARotateKey); data != nil {
+			if rotate := actions.ParseRotateKey(data); rotate != nil {
+				s.Rotations[token] = rotate
+			} else {
+				r.invalid = true
+			}
+		}
+	}
+	for n := r.count(); n > 0; n-- {
+		token := r.token()
+		s.Rotated[token] = r.token()
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		r.comment(s, publishedComment(s, hash))
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		s.Threads[hash] = r.commentHashes(s)
+	}
+	for n := r.count(); n > 0; n-- {
+		hash := r.hash()
+		kind := r.byte()
+		s.Proposals.all[hash] = kind
+		if r.bool() {
+			s.Proposals.authors[hash] = r.token()
+		}
+		if r.bool() {
+			r.proposal(s, kind, hash)
+		}
+	}
+	for _, comment := range s.Comments {
+		// replies to comments that were never published
+		if !comment.Published {
+			r.invalid = true
+		}
+	}
+	if r.invalid || r.position != len(r.data) {
+		return nil, ErrInvalidSnapshot
+	}
+	return s, nil
+}
+
+func (r *snapshotReader) proposal(s *State, kind byte, hash crypto.Hash) {
+	p := s.Proposals
+	switch kind {
+	case UpdateCollectiveProposal:
+		v := &PendingUpdate{}
+		if data := r.action(actions.AUpdateCollective); data != nil {
+			if v.Update = actions.ParseUpdateCollective(data); v.Update == nil {
+				r.invalid = true
+			}
+		}
+		v.Collective = r.collective()
+		v.Hash = r.hash()
+		v.ChangePolicy = r.bool()
+		v.Votes = r.votes()
+		p.UpdateCollective[hash] = v
+	case RequestMembershipProposal:
+		v := &PendingRequestMembership{}
+		if data := r.action(actions.ARequestMembership); data != nil {
+			if v.Request = actions.ParseRequestMembership(data); v.Request == nil {
+				r.invalid = true
+			}
+		}
+		v.Collective = r.collective()
+		v.Hash = r.hash()
+		v.Votes = r.votes()
+		p.RequestMembership[hash] = v
+	case RemoveMemberProposal:
+		v := &PendingRemoveMember{}
+		if data := r.action(actions.ARemoveMember); data != nil {
+			if v.Remove = actions.ParseRemoveMember(data); v.Remove == nil {
+				r.invalid = true
+			}
+		}
+		v.Collective = r.collective()
+		v.Hash = r.hash()
+		v.Votes = r.votes()
+		p.RemoveMember[hash] = v
+	case DraftProposal:
+		p.Draft[hash] = r.draft()
+	case EditProposal:
+		p.Edit[hash] = r.edit()
+	case CreateBoardProposal:
+		v := &PendingBoard{}
+		if data := r.action(actions.ACreateBoard); data != nil {
+			if v.Origin = actions.ParseCreateBoard(data); v.Origin == nil {
+				r.invalid = true
+			}
+		}
+		v.Board = r.board()
+		v.Hash = r.hash()
+		v.Votes = r.votes()
+		p.CreateBoard[hash] = v
+	case UpdateBoardProposal:
+		v := &PendingUpdateBoard{}
+		if data := r.action(actions.AUpdateBoard); data != nil {
+			if v.Origin = actions.ParseUpdateBoard(data); v.Origin == nil {
+				r.invalid = true
+			}
+		}
+		if r.bool() {
+			keywords := r.strings()
+			v.Keywords = &keywords
+		}
+		v.PinMajority = r.optionalByte()
+		v.Description = r.optionalString()
+		v.Board = r.board()
+		v.Hash = r.hash()
+		v.Votes = r.votes()
+		p.UpdateBoard[hash] = v
+	case PinProposal:
+		v := &Pin{}
+		v.Hash = r.hash()
+		v.Epoch = r.uint64()
+		v.Board = r.board()
+		v.Draft = r.draft()
+		v.Pin = r.bool()
+		v.Votes = r.votes()
+		p.Pin[hash] = v
+	case BoardEditorProposal:
+		v := &BoardEditor{}
+		v.Hash = r.hash()
+		v.Epoch = r.uint64()
+		v.Board = r.board()
+		v.Editor = r.token()
+		v.Insert = r.bool()
+		v.Votes = r.votes()
+		p.BoardEditor[hash] = v
+	case ReleaseDraftProposal:
+		p.ReleaseDraft[hash] = r.release()
+	case ImprintStampProposal:
+		p.ImprintStamp[hash] = r.stamp()
+	case CreateEventProposal:
+		p.CreateEvent[hash] = r.event()
+	case CancelEventProposal:
+		v := &CancelEvent{}
+		v.Event = r.event()
+		v.Hash = r.hash()
+		v.Votes = r.votes()
+		v.Reasons = r.string()
+		p.CancelEvent[hash] = v
+	case UpdateEventProposal:
+		v := &EventUpdate{}
+		v.Event = r.event()
+		v.StartAt = r.optionalTime()
+		v.EstimatedEnd = r.optionalTime()
+		v.Description = r.optionalString()
+		v.Venue = r.optionalString()
+		v.Open = r.optionalBool()
+		v.Public = r.optionalBool()
+		v.ManagerMajority = r.optionalByte()
+		v.Votes = r.votes()
+		v.Hash = r.hash()
+		v.Updated = r.bool()
+		v.Reasons = r.string()
+		p.UpdateEvent[hash] = v
+	case EventCheckinGreetProposal:
+		v := &EventCheckinGreet{}
+		v.Event = r.event()
+		v.Hash = r.hash()
+		v.Greets = make([]actions.GreetCheckinEvent, r.count())
+		for n := range v.Greets {
+			data := r.kinded(actions.AGreetCheckinEvent)
+			if data == nil {
+				return
+			}
+			greet := actions.ParseGreetCheckinEvent(data)
+			if greet == nil {
+				r.invalid = true
+				return
+			}
+			v.Greets[n] = *greet
+		}
+		p.GreetCheckin[hash] = v
+	case CommentProposal:
+		v := &Comment{}
+		r.comment(s, v)
+		p.Comment[hash] = v
+	case WithdrawProposal:
+		v := &PendingWithdraw{}
+		v.Hash = r.hash()
+		v.Target = r.hash()
+		v.Collective = r.collective()
+		v.Votes = r.votes()
+		p.Withdraw[hash] = v
+	default:
+		r.invalid = true
+	}
+}
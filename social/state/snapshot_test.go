@@ -0,0 +1,173 @@
+package state
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// snapshotTestState builds a state with the pointer heavy graph: a draft
+// with a previous version pinned on a board, a release with an imprinted
+// stamp and pending proposals holding photos of collectives.
+func snapshotTestState(t *testing.T) *State {
+	s := GenesisState(nil)
+	s.Epoch = 42
+	s.GenesisTime = time.Unix(1700000000, 0)
+	author, _ := crypto.RandomAsymetricKey()
+	other, _ := crypto.RandomAsymetricKey()
+	_, attorney := crypto.RandomAsymetricKey()
+	signin := &actions.Signin{Epoch: 1, Author: author, Handle: "author"}
+	if err := s.Action(actions.Dress(signin.Serialize(), 1, author, attorney, attorney, 0)); err != nil {
+		t.Fatalf("could not sign in: %v", err)
+	}
+	s.SignIn(&actions.Signin{Epoch: 1, Author: other, Handle: "other"})
+
+	collective := &Collective{
+		Name:        "collective",
+		Members:     map[crypto.Token]struct{}{author: {}, other: {}},
+		Description: "snapshot collective",
+		Policy:      actions.Policy{Majority: 50, SuperMajority: 75, VotingWindow: map[byte]uint64{actions.ACreateEvent: 1000}},
+	}
+	s.Collectives[crypto.Hasher([]byte(collective.Name))] = collective
+
+	board := &Board{
+		Name:        "board",
+		Keyword:     []string{"snapshot"},
+		Description: "snapshot board",
+		Collective:  collective,
+		Editors:     Authors(50, author).(*UnamedCollective),
+		Hash:        crypto.Hasher([]byte("board")),
+	}
+	s.Boards[board.Hash] = board
+
+	vote := actions.Vote{Epoch: 2, Author: author, Hash: crypto.Hasher([]byte("first")), Approve: true}
+	first := &Draft{
+		Title:     "first",
+		Date:      2,
+		Authors:   Authors(100, author),
+		DraftType: "md",
+		DraftHash: crypto.Hasher([]byte("first")),
+		Keywords:  []string{"one", "two"},
+		Votes:     []actions.Vote{vote},
+		Aproved:   true,
+	}
+	second := &Draft{
+		Title:           "second",
+		Date:            3,
+		Authors:         collective,
+		DraftType:       "md",
+		DraftHash:       crypto.Hasher([]byte("second")),
+		PreviousVersion: first,
+		References:      []crypto.Hash{first.DraftHash},
+		Pinned:          []*Board{board},
+		Aproved:         true,
+	}
+	board.Pinned = []*Draft{second}
+	edit := &Edit{Authors: first.Authors, Date: 4, Draft: second, EditType: "md", Edit: crypto.Hasher([]byte("edit"))}
+	second.Edits = []*Edit{edit}
+	s.Drafts[first.DraftHash] = first
+	s.Drafts[second.DraftHash] = second
+	s.Edits[edit.Edit] = edit
+	s.Media[first.DraftHash] = []byte("first content")
+
+	release := &Release{Epoch: 5, Draft: second, Hash: crypto.Hasher([]byte("release")), Released: true}
+	stamp := &Stamp{Reputation: collective, Release: release, Hash: crypto.Hasher([]byte("stamp")), Imprinted: true}
+	release.Stamps = []*Stamp{stamp}
+	s.Releases[second.DraftHash] = release
+
+	event := &Event{
+		Collective:     collective,
+		StartAt:        time.Unix(1700001000, 0),
+		EstimatedEnd:   time.Unix(1700002000, 0),
+		Hash:           crypto.Hasher([]byte("event")),
+		Managers:       Authors(50, author).(*UnamedCollective),
+		Checkin:        map[crypto.Token]*Greeting{other: {EphemeralKey: author}},
+		CheckinReasons: map[crypto.Token]string{other: "attend"},
+		Live:           true,
+	}
+	s.Events[event.Hash] = event
+
+	// pending proposals
+	third := &Draft{Title: "third", Authors: collective, DraftHash: crypto.Hasher([]byte("third")), PreviousVersion: second}
+	s.Proposals.AddDraft(third, nil)
+	update := &PendingUpdate{Collective: collective.Photo(), Hash: crypto.Hasher([]byte("update")), ChangePolicy: true}
+	s.Proposals.AddUpdateCollective(update, nil)
+	pin := &Pin{Hash: crypto.Hasher([]byte("pin")), Epoch: 6, Board: board, Draft: first, Pin: true}
+	s.Proposals.AddPin(pin, nil)
+	description := "new description"
+	s.Proposals.AddEventUpdate(&EventUpdate{Event: event, Description: &description, Hash: crypto.Hasher([]byte("event update"))}, nil)
+	s.setDeadline(100, third.DraftHash)
+	s.Reactions[1][second.DraftHash] = 3
+	return s
+}
+
+func TestSnapshot(t *testing.T) {
+	original := snapshotTestState(t)
+	snapshot := original.Snapshot()
+	if !bytes.Equal(snapshot, original.Snapshot()) {
+		t.Fatal("Snapshot is not deterministic")
+	}
+	restored, err := RestoreSnapshot(snapshot, nil)
+	if err != nil {
+		t.Fatalf("Could not restore snapshot: %v", err)
+	}
+	if !bytes.Equal(snapshot, restored.Snapshot()) {
+		t.Fatal("Snapshot and RestoreSnapshot not working")
+	}
+	if !restored.StateRoot().Equal(original.StateRoot()) {
+		t.Error("restored state has a different state root")
+	}
+	restored.Reactions[0][crypto.Hasher([]byte("first"))] = 1
+	if restored.StateRoot().Equal(original.StateRoot()) {
+		t.Error("state root does not commit to reactions")
+	}
+	delete(restored.Reactions[0], crypto.Hasher([]byte("first")))
+	first := restored.Drafts[crypto.Hasher([]byte("first"))]
+	second := restored.Drafts[crypto.Hasher([]byte("second"))]
+	board := restored.Boards[crypto.Hasher([]byte("board"))]
+	collective := restored.Collectives[crypto.Hasher([]byte("collective"))]
+	if second.PreviousVersion != first {
+		t.Error("Draft.PreviousVersion not restored")
+	}
+	if len(board.Pinned) != 1 || board.Pinned[0] != second || second.Pinned[0] != board {
+		t.Error("Board.Pinned not restored")
+	}
+	if second.Edits[0] != restored.Edits[crypto.Hasher([]byte("edit"))] || second.Edits[0].Draft != second {
+		t.Error("Draft.Edits not restored")
+	}
+	if second.Authors != Consensual(collective) || board.Collective != collective {
+		t.Error("shared collective not restored")
+	}
+	release := restored.Releases[second.DraftHash]
+	if release.Draft != second || release.Stamps[0].Release != release || release.Stamps[0].Reputation != collective {
+		t.Error("Stamp.Release not restored")
+	}
+	third := restored.Proposals.Draft[crypto.Hasher([]byte("third"))]
+	if third == nil || third.PreviousVersion != second {
+		t.Error("pending draft not restored")
+	}
+	update := restored.Proposals.UpdateCollective[crypto.Hasher([]byte("update"))]
+	if update == nil || update.Collective == collective || update.Collective.Name != collective.Name {
+		t.Error("photo of collective not restored")
+	}
+	pin := restored.Proposals.Pin[crypto.Hasher([]byte("pin"))]
+	if pin == nil || pin.Board != board || pin.Draft != first {
+		t.Error("pending pin not restored")
+	}
+	if restored.Proposals.Kind(crypto.Hasher([]byte("event update"))) != UpdateEventProposal {
+		t.Error("proposal kind not restored")
+	}
+	author := restored.MembersIndex["author"]
+	if len(restored.Attorneys[author]) != 1 || len(restored.Applied[1]) != 1 || restored.Epoch != 42 {
+		t.Error("state fields not restored")
+	}
+	// truncated snapshots must be rejected, not panic
+	for cut := 0; cut < len(snapshot); cut++ {
+		if _, err := RestoreSnapshot(snapshot[:cut], nil); err == nil {
+			t.Fatalf("truncated snapshot at %v accepted", cut)
+		}
+	}
+}
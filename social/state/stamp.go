@@ -23,7 +23,7 @@ func (p *Stamp) IncorporateVote(vote actions.Vote, state *State) error {
 	if !p.Reputation.IsMember(vote.Author) {
 		return errors.New("author is not a recognized member of the collective")
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if p.Imprinted {
 		return nil
 	}
@@ -31,10 +31,15 @@ func (p *Stamp) IncorporateVote(vote actions.Vote, state *State) error {
 	if consensus == Undecided {
 		return nil
 	}
+	return p.decide(consensus, state)
+}
+
+func (p *Stamp) decide(consensus ConsensusState, state *State) error {
 	// new consensus
-	state.IndexConsensus(vote.Hash, consensus == Favorable)
+	state.IndexConsensus(p.Hash, consensus == Favorable)
 	if consensus == Favorable {
 		p.Imprinted = true
+		state.touch(releaseLeaf, p.Release.Draft.DraftHash)
 		if state.index != nil {
 			state.index.AddStampToCollective(p, p.Reputation)
 		}
@@ -57,12 +62,23 @@ type Release struct {
 	Stamps   []*Stamp
 }
 
+// Release returns the release with the given hash. Releases are kept by the
+// hash of their draft, so this goes through all of them.
+func (s *State) Release(hash crypto.Hash) (*Release, bool) {
+	for _, release := range s.Releases {
+		if release.Hash.Equal(hash) {
+			return release, true
+		}
+	}
+	return nil, false
+}
+
 func (p *Release) IncorporateVote(vote actions.Vote, state *State) error {
 	fmt.Println(vote)
 	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
 		return err
 	}
-	p.Votes = append(p.Votes, vote)
+	p.Votes = castVote(p.Votes, vote)
 	if p.Released {
 		return nil
 	}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/lienkolabs/breeze/crypto"
@@ -15,7 +16,11 @@ const (
 	ProposalDeadline = 30 * 24 * 60 * 60
 )
 
+// State is changed only by Action, NextBlock and Reset, which hold the write
+// lock. Readers running concurrently with those (http handlers of a proxy)
+// must hold RLock while reading the state and its indexer.
 type State struct {
+	mu           *sync.RWMutex
 	Epoch        uint64
 	MembersIndex map[string]crypto.Token       // mapa do handle to token
 	Members      map[crypto.Hash]string        // mapa do hash do token para o handle
@@ -30,17 +35,37 @@ type State struct {
 	Proposals    *Proposals                    // map[crypto.Hash]Proposal // proposals pending vote actions
 	Deadline     map[uint64][]crypto.Hash      // map do epoch que morre para o array de hash dos elementos que vao morrer naquele epoch
 	Reactions    [ReactionsCount]map[crypto.Hash]uint
+	Attorneys    map[crypto.Token]map[crypto.Token]struct{} // token do membro para os procuradores autorizados
+	Applied      map[uint64]map[crypto.Hash]struct{}        // epoch da acao para os hashes das acoes aplicadas dentro da janela
+	Rotations    map[crypto.Token]*actions.RotateKey        // token do membro para a rotacao de chave pendente
+	Rotated      map[crypto.Token]crypto.Token              // token aposentado para o token que o substituiu
+	Comments     map[crypto.Hash]*Comment                   // hash da acao do comentario para o comentario publicado
+	Threads      map[crypto.Hash][]*Comment                 // hash do draft ou edit para os comentarios que abrem threads
 	GenesisTime  time.Time
 	index        Indexer
-	action       Notifier // pra ser usado pra notificacao real time
+	bus          *Bus       // pra ser usado pra notificacao real time
+	root         *rootCache // leaf hashes of the last state root
 
 }
 
+func (s *State) RLock() {
+	s.mu.RLock()
+}
+
+func (s *State) RUnlock() {
+	s.mu.RUnlock()
+}
+
 func (s *State) TimeOfEpoch(epoch uint64) time.Time {
 	return s.GenesisTime.Add(time.Duration(epoch) * time.Second)
 }
 
 func (s *State) IndexConsensus(hash crypto.Hash, approve bool) {
+	if approve {
+		s.Notify(AcceptProposal, hash)
+	} else {
+		s.Notify(RejectProposal, hash)
+	}
 	if s.index == nil {
 		return
 	}
@@ -94,218 +119,319 @@ func logAction(a actions.Action) {
 		des = "Checkin Event"
 	case *actions.GreetCheckinEvent:
 		des = "Greet Checkin Event"
+	case *actions.GrantPowerOfAttorney:
+		des = "Grant Power Of Attorney"
+	case *actions.RevokePowerOfAttorney:
+		des = "Revoke Power Of Attorney"
+	case *actions.RotateKey:
+		des = "Rotate Key"
+	case *actions.ChangeHandle:
+		des = "Change Handle"
+	case *actions.Comment:
+		des = "Comment"
+	case *actions.Withdraw:
+		des = "Withdraw"
 	}
 	text, _ := json.Marshal(a)
 	fmt.Printf("%v: %v\n\n", des, string(text))
 }
 
 // funcao que esta sendo chamada no SelfGateway do genesis
-// valida e incorpora a acao
+// valida o envelope e a procuracao e incorpora a acao. Actions rejected after
+// the envelope is parsed publish a RejectAction with the reason.
 func (s *State) Action(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	envelope, err := actions.ParseEnvelope(data)
+	if err != nil {
+		return err
+	}
+	// replays of the same signed action are rejected whatever the wallet
+	hash := crypto.Hasher(envelope.Action)
+	if err := s.apply(envelope, hash); err != nil {
+		s.publishRejection(hash, envelope.Author, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *State) apply(envelope *actions.Envelope, hash crypto.Hash) error {
+	if err := s.checkWindow(envelope.Epoch, hash); err != nil {
+		return err
+	}
+	kind := actions.ActionKind(envelope.Action)
+	if kind != actions.ASignIn && !s.IsAttorney(envelope.Author, envelope.Attorney) {
+		return ErrUnauthorizedAttorney
+	}
+	if kind == actions.ARotateKey && !envelope.Attorney.Equal(envelope.Author) {
+		return ErrRotationByAttorney
+	}
+	// an attorney cannot grant new attorneys nor revoke the others
+	if (kind == actions.AGrantPowerOfAttorney || kind == actions.ARevokePowerOfAttorney) && !envelope.Attorney.Equal(envelope.Author) {
+		return ErrAttorneyByAttorney
+	}
+	action, err := s.incorporate(envelope.Action)
+	if err != nil {
+		return err
+	}
+	s.markApplied(envelope.Epoch, hash)
+	if kind == actions.ASignIn {
+		// the attorney that signs in a new member is its first attorney
+		s.grantAttorney(envelope.Author, envelope.Attorney)
+	}
+	update := s.updateOf(action)
+	update.Origin = hash
+	s.bus.Publish(update)
+	return nil
+}
+
+// Rejected publishes the rejection of an action by a remote state, as
+// reported by the gateway to its proxies.
+func (s *State) Rejected(hash crypto.Hash, reason string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.publishRejection(hash, crypto.ZeroToken, reason)
+}
+
+func (s *State) publishRejection(hash crypto.Hash, author crypto.Token, reason string) {
+	s.bus.Publish(Updated{Action: RejectAction, Origin: hash, Epoch: s.Epoch, Author: author, Reason: reason})
+}
+
+// incorpora a acao do synergy ja sem o envelope
+func (s *State) incorporate(data []byte) (actions.Action, error) {
 	kind := actions.ActionKind(data)
 	// verifica qual o tipo de acao ta sendo processado segundo o byte
 	switch kind {
 	case actions.AVote:
 		action := actions.ParseVote(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.Vote(action)
-		return err
+		return action, s.Vote(action)
 	case actions.ACreateCollective:
 		action := actions.ParseCreateCollective(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.CreateCollective(action)
-		return err
+		return action, s.CreateCollective(action)
 	case actions.AUpdateCollective:
 		action := actions.ParseUpdateCollective(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.UpdateCollective(action)
-		return err
+		return action, s.UpdateCollective(action)
 	case actions.ARequestMembership:
 		action := actions.ParseRequestMembership(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.RequestMembership(action)
-		return err
+		return action, s.RequestMembership(action)
 	case actions.ARemoveMember:
 		action := actions.ParseRemoveMember(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.RemoveMember(action)
-		return err
+		return action, s.RemoveMember(action)
 	case actions.ADraft:
 		action := actions.ParseDraft(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.Draft(action)
-		return err
+		return action, s.Draft(action)
 
 	case actions.AEdit:
 		action := actions.ParseEdit(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.Edit(action)
-		return err
+		return action, s.Edit(action)
 	case actions.AMultipartMedia:
 		action := actions.ParseMultipartMedia(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		// no need to index
-		return s.MultipartMedia(action)
+		return action, s.MultipartMedia(action)
 
 	case actions.ACreateBoard:
 		action := actions.ParseCreateBoard(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.CreateBoard(action)
-		return err
+		return action, s.CreateBoard(action)
 
 	case actions.AUpdateBoard:
 		action := actions.ParseUpdateBoard(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.UpdateBoard(action)
-		return err
+		return action, s.UpdateBoard(action)
 
 	case actions.APin:
 		action := actions.ParsePin(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.Pin(action)
-		return err
+		return action, s.Pin(action)
 	case actions.ABoardEditor:
 		action := actions.ParseBoardEditor(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		return s.BoardEditor(action)
+		return action, s.BoardEditor(action)
 
 	case actions.AReleaseDraft:
 		action := actions.ParseReleaseDraft(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.ReleaseDraft(action)
-		return err
+		return action, s.ReleaseDraft(action)
 	case actions.AImprintStamp:
 		action := actions.ParseImprintStamp(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.ImprintStamp(action)
-		return err
+		return action, s.ImprintStamp(action)
 	case actions.AReact:
 		action := actions.ParseReact(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.React(action)
-		return err
+		return action, s.React(action)
 	case actions.ASignIn:
 		action := actions.ParseSignIn(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		// should index signin???
-		return s.SignIn(action)
+		return action, s.SignIn(action)
 
 	case actions.ACreateEvent:
 		action := actions.ParseCreateEvent(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.CreateEvent(action)
-		return err
+		return action, s.CreateEvent(action)
 
 	case actions.ACancelEvent:
 		action := actions.ParseCancelEvent(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.CancelEvent(action)
-		return err
+		return action, s.CancelEvent(action)
 	case actions.AUpdateEvent:
 		action := actions.ParseUpdateEvent(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.UpdateEvent(action)
-		return err
+		return action, s.UpdateEvent(action)
 	case actions.ACheckinEvent:
 		action := actions.ParseCheckinEvent(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.CheckinEvent(action)
-		return err
+		return action, s.CheckinEvent(action)
 	case actions.AGreetCheckinEvent:
 		action := actions.ParseGreetCheckinEvent(data)
 		if action == nil {
-			return errors.New("cound not parse action")
+			return nil, errors.New("cound not parse action")
 		}
 		logAction(action)
 		s.IndexAction(action)
-		err := s.GreetCheckinEvent(action)
-		return err
+		return action, s.GreetCheckinEvent(action)
+	case actions.AGrantPowerOfAttorney:
+		action := actions.ParseGrantPowerOfAttorney(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		return action, s.GrantPowerOfAttorney(action)
+	case actions.ARevokePowerOfAttorney:
+		action := actions.ParseRevokePowerOfAttorney(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		return action, s.RevokePowerOfAttorney(action)
+	case actions.ARotateKey:
+		action := actions.ParseRotateKey(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		s.IndexAction(action)
+		return action, s.RotateKey(action)
+	case actions.AChangeHandle:
+		action := actions.ParseChangeHandle(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		s.IndexAction(action)
+		return action, s.ChangeHandle(action)
+	case actions.AComment:
+		action := actions.ParseComment(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		s.IndexAction(action)
+		return action, s.Comment(action)
+	case actions.AWithdraw:
+		action := actions.ParseWithdraw(data)
+		if action == nil {
+			return nil, errors.New("cound not parse action")
+		}
+		logAction(action)
+		s.IndexAction(action)
+		return action, s.Withdraw(action)
 	}
 
-	return errors.New("unrecognized action")
+	return nil, errors.New("unrecognized action")
 }
 
 // cria o estado inicial
 func GenesisState(indexer Indexer) *State {
 	state := &State{
+		mu:           &sync.RWMutex{},
 		Epoch:        0,
 		MembersIndex: make(map[string]crypto.Token),
 		Members:      make(map[crypto.Hash]string),
@@ -317,9 +443,17 @@ func GenesisState(indexer Indexer) *State {
 		Events:       make(map[crypto.Hash]*Event),
 		Collectives:  make(map[crypto.Hash]*Collective),
 		Boards:       make(map[crypto.Hash]*Board),
+		Attorneys:    make(map[crypto.Token]map[crypto.Token]struct{}),
 		Proposals:    NewProposals(indexer),
 		Deadline:     make(map[uint64][]crypto.Hash),
+		Applied:      make(map[uint64]map[crypto.Hash]struct{}),
+		Rotations:    make(map[crypto.Token]*actions.RotateKey),
+		Rotated:      make(map[crypto.Token]crypto.Token),
+		Comments:     make(map[crypto.Hash]*Comment),
+		Threads:      make(map[crypto.Hash][]*Comment),
 		index:        indexer,
+		bus:          NewBus(),
+		root:         newRootCache(),
 	}
 	for n := 0; n < ReactionsCount; n++ {
 		state.Reactions[n] = make(map[crypto.Hash]uint)
@@ -352,39 +486,85 @@ func (s *State) IsMember(token crypto.Token) bool {
 	return ok
 }
 
-func (s *State) Notify(origin Action, objHash crypto.Hash) {
-	s.action.Notify(origin, s.hashToObjectType(objHash), objHash)
-}
-
-func (s *State) NextBlock() {
+// NextBlock closes the current epoch: proposals with deadline on the epoch
+// expire, actions leaving the validity window are forgotten and the state
+// root of the closed epoch is returned. Key rotations time-locked to the new
+// epoch take place.
+func (s *State) NextBlock() crypto.Hash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if deadline, ok := s.Deadline[s.Epoch]; ok {
 		for _, hash := range deadline {
-			s.Proposals.Delete(hash)
-			s.Notify(ExpireProposal, hash)
+			// withdrawn or decided proposals are not pending anymore
+			if !s.Proposals.Has(hash) {
+				continue
+			}
+			s.expireProposal(hash)
 		}
+		delete(s.Deadline, s.Epoch)
 	}
+	s.expireApplied()
+	root := s.StateRoot()
+	s.Epoch += 1
+	s.applyRotations()
+	return root
 }
 
-func (s *State) hashToObjectType(hash crypto.Hash) Object {
-	if _, ok := s.Members[hash]; ok {
-		return MemberObject
-	}
-	if _, ok := s.Drafts[hash]; ok {
-		return DraftObject
+// Reset brings the state back to genesis keeping indexer and subscribers, so
+// that a node that diverged can incorporate the chain again.
+func (s *State) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// mu, bus, index and GenesisTime are kept: readers may be waiting on mu
+	genesis := GenesisState(s.index)
+	s.Epoch = genesis.Epoch
+	s.MembersIndex = genesis.MembersIndex
+	s.Members = genesis.Members
+	s.PendingMedia = genesis.PendingMedia
+	s.Media = genesis.Media
+	s.Drafts = genesis.Drafts
+	s.Edits = genesis.Edits
+	s.Releases = genesis.Releases
+	s.Events = genesis.Events
+	s.Collectives = genesis.Collectives
+	s.Boards = genesis.Boards
+	s.Proposals = genesis.Proposals
+	s.Deadline = genesis.Deadline
+	s.Reactions = genesis.Reactions
+	s.Attorneys = genesis.Attorneys
+	s.Applied = genesis.Applied
+	s.Rotations = genesis.Rotations
+	s.Rotated = genesis.Rotated
+	s.Comments = genesis.Comments
+	s.Threads = genesis.Threads
+	s.root = genesis.root
+	if s.index != nil {
+		s.index.Reset(s)
 	}
-	if _, ok := s.Edits[hash]; ok {
-		return EditObject
+}
+
+// expireProposal closes the voting window of a pending proposal. Proposals of
+// a collective are decided by its policy, every other is rejected.
+func (s *State) expireProposal(hash crypto.Hash) {
+	proposal, collective := s.Proposals.closing(hash)
+	if proposal != nil && collective.Expiry(hash, s.Proposals.Votes(hash)) == Favorable {
+		proposal.decide(Favorable, s)
+		return
 	}
-	if _, ok := s.Media[hash]; ok {
-		return MediaObject
+	s.Notify(ExpireProposal, hash)
+	if proposal != nil {
+		proposal.decide(Against, s)
+		return
 	}
-	return NoObject
+	s.IndexConsensus(hash, false)
+	s.Proposals.Delete(hash)
 }
 
 func (s *State) setDeadline(epoch uint64, hash crypto.Hash) {
 	if epoch <= s.Epoch {
 		return
 	}
+	s.touchEpoch(deadlineLeaf, epoch)
 	if deadlines, ok := s.Deadline[epoch]; ok {
 		s.Deadline[epoch] = append(deadlines, hash)
 	} else {
@@ -402,6 +582,7 @@ func (s *State) GreetCheckinEvent(greet *actions.GreetCheckinEvent) error {
 		return errors.New("checkin not found")
 	}
 	greeting.Action = greet
+	s.touch(eventLeaf, greet.EventHash)
 	return nil
 }
 
@@ -432,6 +613,7 @@ func (s *State) ImprintStamp(stamp *actions.ImprintStamp) error {
 		Votes:      []actions.Vote{},
 	}
 	s.Proposals.AddStamp(&newStamp, stamp)
+	s.setDeadline(stamp.Epoch+collective.VotingWindow(actions.AImprintStamp), hash)
 	return newStamp.IncorporateVote(vote, s)
 }
 
@@ -448,6 +630,7 @@ func (s *State) CheckinEvent(checkin *actions.CheckinEvent) error {
 	}
 	event.Checkin[checkin.Author] = &Greeting{Action: nil, EphemeralKey: checkin.EphemeralToken}
 	event.CheckinReasons[checkin.Author] = checkin.Reasons
+	s.touch(eventLeaf, checkin.EventHash)
 	if s.index != nil {
 		s.index.AddCheckin(checkin.Author, event)
 	}
@@ -581,6 +764,7 @@ func (s *State) CreateEvent(create *actions.CreateEvent) error {
 		return errors.New("event already booked")
 	}
 	s.Proposals.AddEvent(&event, create)
+	s.setDeadline(create.Epoch+event.Collective.VotingWindow(actions.ACreateEvent), hash)
 	return event.IncorporateVote(vote, s)
 }
 
@@ -593,6 +777,7 @@ func (s *State) MultipartMedia(media *actions.MultipartMedia) error {
 	if err != nil {
 		return err
 	}
+	s.touch(pendingMediaLeaf, media.Hash)
 	if total != nil {
 		delete(s.PendingMedia, media.Hash)
 		s.Media[media.Hash] = total
@@ -682,6 +867,7 @@ func (s *State) UpdateBoard(update *actions.UpdateBoard) error {
 		Votes:       []actions.Vote{},
 	}
 	s.Proposals.AddPendingUpdateBoard(&pending, update)
+	s.setDeadline(update.Epoch+pending.Board.Collective.VotingWindow(actions.AUpdateBoard), hash)
 	return pending.IncorporateVote(vote, s)
 	// TODO notify
 }
@@ -728,6 +914,7 @@ func (s *State) CreateBoard(board *actions.CreateBoard) error {
 		Votes:  []actions.Vote{},
 	}
 	s.Proposals.AddPendingBoard(pendingboard, board)
+	s.setDeadline(board.Epoch+pendingboard.Board.Collective.VotingWindow(actions.ACreateBoard), hash)
 	// TODO: notify
 	return pendingboard.IncorporateVote(vote, s)
 }
@@ -737,8 +924,15 @@ func (s *State) SignIn(signin *actions.Signin) error {
 	if _, ok := s.Members[hash]; ok {
 		return errors.New("already a member of synergy")
 	}
+	if !s.isFreeKey(signin.Author) {
+		return errors.New("key already in use")
+	}
+	if _, ok := s.MembersIndex[signin.Handle]; ok {
+		return errors.New("handle already taken")
+	}
 	s.Members[hash] = signin.Handle
-	s.MembersIndex[signin.Handle] = signin.Author // TODO: who guarantees single names?
+	s.MembersIndex[signin.Handle] = signin.Author
+	s.touch(memberLeaf, hash)
 	//s.Notify(SigninAction, hash)
 	return nil
 }
@@ -750,7 +944,7 @@ func (s *State) CreateCollective(create *actions.CreateCollective) error {
 	if _, ok := s.Collective(create.Name); ok {
 		return errors.New("collective already exists")
 	}
-	if create.Policy.Majority < 0 || create.Policy.Majority > 100 || create.Policy.SuperMajority < 0 || create.Policy.SuperMajority > 100 {
+	if !isValidPolicy(create.Policy) {
 		return errors.New("invalid policy")
 	}
 	// hash := crypto.Hasher([]byte(create.Name))
@@ -759,10 +953,7 @@ func (s *State) CreateCollective(create *actions.CreateCollective) error {
 		Name:        create.Name,
 		Members:     map[crypto.Token]struct{}{create.Author: {}},
 		Description: create.Description,
-		Policy: actions.Policy{
-			Majority:      create.Policy.Majority,
-			SuperMajority: create.Policy.SuperMajority,
-		},
+		Policy:      clonePolicy(create.Policy),
 	}
 	return nil
 }
@@ -826,11 +1017,14 @@ func (s *State) UpdateCollective(update *actions.UpdateCollective) error {
 		Hash:       hash,
 		Votes:      []actions.Vote{},
 	}
-	if update.Majority != nil || update.SuperMajority != nil {
+	if update.Majority != nil || update.SuperMajority != nil || update.Quorum != nil || update.AcceptOnExpiry != nil || len(update.VotingWindow) > 0 || update.Strategy != nil {
 		pending.ChangePolicy = true
+		if !isValidPolicyUpdate(update) {
+			return errors.New("invalid policy")
+		}
 	}
 	s.Proposals.AddUpdateCollective(&pending, update)
-	s.setDeadline(update.Epoch+ProposalDeadline, hash)
+	s.setDeadline(update.Epoch+collective.VotingWindow(actions.AUpdateCollective), hash)
 	return pending.IncorporateVote(vote, s)
 
 }
@@ -851,6 +1045,7 @@ func (s *State) RequestMembership(request *actions.RequestMembership) error {
 	}
 	if !request.Include {
 		delete(collective.Members, request.Author)
+		s.touchCollective(collective.Name)
 		return nil
 	}
 	// hash := crypto.Hasher(request.Serialize())
@@ -869,7 +1064,7 @@ func (s *State) RequestMembership(request *actions.RequestMembership) error {
 		Approve: true,
 	}
 	s.Proposals.AddRequestMembership(&pending, request)
-	s.setDeadline(request.Epoch+ProposalDeadline, hash)
+	s.setDeadline(request.Epoch+collective.VotingWindow(actions.ARequestMembership), hash)
 	return pending.IncorporateVote(vote, s)
 }
 
@@ -886,6 +1081,7 @@ func (s *State) RemoveMember(remove *actions.RemoveMember) error {
 	}
 	if remove.Author.Equal(remove.Member) {
 		delete(collective.Members, remove.Author)
+		s.touchCollective(collective.Name)
 		if s.index != nil {
 			s.index.IndexConsensus(remove.Hashed(), true)
 		}
@@ -911,7 +1107,7 @@ func (s *State) RemoveMember(remove *actions.RemoveMember) error {
 		Votes:      []actions.Vote{},
 	}
 	s.Proposals.AddPendingRemoveMember(&pending, remove)
-	s.setDeadline(remove.Epoch+ProposalDeadline, hash)
+	s.setDeadline(remove.Epoch+collective.VotingWindow(actions.ARemoveMember), hash)
 	return pending.IncorporateVote(vote, s)
 }
 
@@ -925,6 +1121,7 @@ func (s *State) React(reaction *actions.React) error {
 	} else {
 		s.Reactions[reaction.Reaction][reaction.Hash] = 1
 	}
+	s.touchReaction(reaction.Reaction, reaction.Hash)
 	return nil
 }
 
@@ -1123,6 +1320,9 @@ func (s *State) Draft(draft *actions.Draft) error {
 }
 
 func (s *State) Vote(vote *actions.Vote) error {
+	// votes go to the pending proposal or to the approved draft
+	s.touch(proposalLeaf, vote.Hash)
+	s.touch(draftLeaf, vote.Hash)
 	if draft, ok := s.Drafts[vote.Hash]; ok {
 		return draft.IncorporateVote(*vote, s)
 	}
@@ -1188,5 +1388,6 @@ func (s *State) BoardEditor(action *actions.BoardEditor) error {
 		Approve: true,
 	}
 	s.Proposals.AddBoardEditor(&proposal, action)
+	s.setDeadline(action.Epoch+proposal.Board.Collective.VotingWindow(actions.ABoardEditor), hash)
 	return proposal.IncorporateVote(selfVote, s)
 }
@@ -0,0 +1,56 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// TestConcurrentReads runs readers holding RLock while actions and blocks are
+// incorporated. Meant to be run with -race.
+func TestConcurrentReads(t *testing.T) {
+	s := GenesisState(nil)
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for n := 0; n < 4; n++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				s.RLock()
+				for handle, token := range s.MembersIndex {
+					if s.Members[crypto.HashToken(token)] != handle {
+						t.Error("inconsistent members index")
+					}
+				}
+				s.RUnlock()
+			}
+		}()
+	}
+	for n := 0; n < 100; n++ {
+		author, key := crypto.RandomAsymetricKey()
+		signin := &actions.Signin{Epoch: s.Epoch, Author: author, Handle: fmt.Sprintf("user_%v", n)}
+		if err := s.Action(actions.Dress(signin.Serialize(), s.Epoch, author, key, key, 0)); err != nil {
+			t.Fatalf("could not sign in: %v", err)
+		}
+		if n%10 == 0 {
+			s.NextBlock()
+		}
+	}
+	s.Snapshot()
+	// readers keep using the same lock across a reset
+	s.Reset()
+	close(done)
+	readers.Wait()
+	if len(s.Members) != 0 {
+		t.Error("state not reset")
+	}
+}
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// strategy is a rule of consensus of a collective other than the majority of
+// its members. Every variant embeds the collective it decides for.
+type strategy interface {
+	Consensual
+	SuperConsensus(hash crypto.Hash, votes []actions.Vote) ConsensusState
+	Expiry(hash crypto.Hash, votes []actions.Vote) ConsensusState
+}
+
+// strategy returns the variant chosen by the policy of the collective, nil for
+// the majority of members.
+func (c *Collective) strategy() strategy {
+	switch c.Policy.Strategy.Kind {
+	case actions.WeightedStrategy:
+		return &WeightedCollective{Collective: c}
+	case actions.VetoStrategy:
+		return &VetoCollective{Collective: c}
+	case actions.ReviewersStrategy:
+		return &ReviewersCollective{Collective: c}
+	}
+	return nil
+}
+
+// WeightedCollective decides by the majority of the weights of the members
+// that did not abstain. Members without a weight in the policy weigh 1.
+type WeightedCollective struct {
+	*Collective
+}
+
+func (c *WeightedCollective) weight(token crypto.Token) int {
+	if weight, ok := c.Policy.Strategy.Weights[token]; ok {
+		return weight
+	}
+	return 1
+}
+
+func (c *WeightedCollective) tally(hash crypto.Hash, votes []actions.Vote) (favor, against, abstain, total int) {
+	for token := range c.Members {
+		total += c.weight(token)
+	}
+	for token, vote := range lastVotes(c.Members, hash, votes) {
+		if vote.Abstain {
+			abstain += c.weight(token)
+		} else if vote.Approve {
+			favor += c.weight(token)
+		} else {
+			against += c.weight(token)
+		}
+	}
+	return
+}
+
+func (c *WeightedCollective) Consensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	favor, against, abstain, total := c.tally(hash, votes)
+	return majorityOf(favor, against, abstain, total, c.Policy.Majority, c.Policy.Quorum)
+}
+
+func (c *WeightedCollective) SuperConsensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	favor, against, abstain, total := c.tally(hash, votes)
+	return majorityOf(favor, against, abstain, total, c.Policy.SuperMajority, c.Policy.Quorum)
+}
+
+func (c *WeightedCollective) ConsensusEpoch(votes []actions.Vote) uint64 {
+	return epochOf(c, votes)
+}
+
+func (c *WeightedCollective) Expiry(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	favor, against, abstain, total := c.tally(hash, votes)
+	if favor > against && quorumMet(total, c.Policy.Quorum, favor+against+abstain) {
+		return Favorable
+	}
+	return Against
+}
+
+// VetoCollective decides by the majority of members, but any member in the
+// roles of the policy vetoes the proposal by voting against it. A favorable
+// consensus waits for the vote of every member with veto.
+type VetoCollective struct {
+	*Collective
+}
+
+func (c *VetoCollective) veto(hash crypto.Hash, votes []actions.Vote) (vetoed, waiting bool) {
+	cast := lastVotes(c.Members, hash, votes)
+	for _, token := range c.Policy.Strategy.Roles {
+		if !c.IsMember(token) {
+			continue
+		}
+		vote, ok := cast[token]
+		if !ok {
+			waiting = true
+		} else if !vote.Approve && !vote.Abstain {
+			vetoed = true
+		}
+	}
+	return
+}
+
+func (c *VetoCollective) withVeto(consensus ConsensusState, hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	vetoed, waiting := c.veto(hash, votes)
+	if vetoed {
+		return Against
+	}
+	if consensus == Favorable && waiting {
+		return Undecided
+	}
+	return consensus
+}
+
+func (c *VetoCollective) Consensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	return c.withVeto(consensus(c.Members, c.Policy.Majority, c.Policy.Quorum, hash, votes), hash, votes)
+}
+
+func (c *VetoCollective) SuperConsensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	return c.withVeto(consensus(c.Members, c.Policy.SuperMajority, c.Policy.Quorum, hash, votes), hash, votes)
+}
+
+func (c *VetoCollective) ConsensusEpoch(votes []actions.Vote) uint64 {
+	return epochOf(c, votes)
+}
+
+// Expiry does not wait for the members with veto that did not vote.
+func (c *VetoCollective) Expiry(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	if vetoed, _ := c.veto(hash, votes); vetoed {
+		return Against
+	}
+	return expiry(c.Members, c.Policy.Quorum, hash, votes)
+}
+
+// ReviewersCollective decides by the approval of Required of the members in
+// the roles of the policy, as the reviewers of a collective imprinting stamps.
+// Changes of policy still need the supermajority of all members.
+type ReviewersCollective struct {
+	*Collective
+}
+
+func (c *ReviewersCollective) reviewers() map[crypto.Token]struct{} {
+	reviewers := make(map[crypto.Token]struct{})
+	for _, token := range c.Policy.Strategy.Roles {
+		if c.IsMember(token) {
+			reviewers[token] = struct{}{}
+		}
+	}
+	return reviewers
+}
+
+func (c *ReviewersCollective) Consensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	reviewers := c.reviewers()
+	favor, against, abstain := tally(reviewers, hash, votes)
+	if favor >= c.Policy.Strategy.Required {
+		return Favorable
+	}
+	// not enough reviewers left to approve
+	if len(reviewers)-against-abstain < c.Policy.Strategy.Required {
+		return Against
+	}
+	return Undecided
+}
+
+func (c *ReviewersCollective) SuperConsensus(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	return consensus(c.Members, c.Policy.SuperMajority, c.Policy.Quorum, hash, votes)
+}
+
+func (c *ReviewersCollective) ConsensusEpoch(votes []actions.Vote) uint64 {
+	return epochOf(c, votes)
+}
+
+// Expiry rejects: the reviewers did not approve within the voting window.
+func (c *ReviewersCollective) Expiry(hash crypto.Hash, votes []actions.Vote) ConsensusState {
+	return Against
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+type strategyVote struct {
+	member  int
+	approve bool
+	abstain bool
+}
+
+func strategyCollective(strategy actions.Strategy) (*Collective, []crypto.Token) {
+	collective := &Collective{Name: "strategy", Members: make(map[crypto.Token]struct{}), Policy: actions.Policy{Majority: 50, SuperMajority: 75, AcceptOnExpiry: true, Strategy: strategy}}
+	tokens := make([]crypto.Token, 4)
+	for n := range tokens {
+		tokens[n], _ = crypto.RandomAsymetricKey()
+		collective.Members[tokens[n]] = struct{}{}
+	}
+	return collective, tokens
+}
+
+func strategyVotes(hash crypto.Hash, tokens []crypto.Token, cast []strategyVote) []actions.Vote {
+	votes := make([]actions.Vote, 0)
+	for n, v := range cast {
+		votes = castVote(votes, actions.Vote{Epoch: uint64(n + 1), Author: tokens[v.member], Hash: hash, Approve: v.approve, Abstain: v.abstain})
+	}
+	return votes
+}
+
+func TestWeightedStrategy(t *testing.T) {
+	hash := crypto.Hasher([]byte("proposal"))
+	collective, tokens := strategyCollective(actions.Strategy{Kind: actions.WeightedStrategy})
+	// member 0 weighs as much as the other three together, member 3 nothing
+	collective.Policy.Strategy.Weights = map[crypto.Token]int{tokens[0]: 3, tokens[3]: 0}
+	tests := []struct {
+		name      string
+		votes     []strategyVote
+		consensus ConsensusState
+		super     ConsensusState
+		expiry    ConsensusState
+	}{
+		{"no votes", nil, Undecided, Undecided, Against},
+		{"heavy member", []strategyVote{{0, true, false}}, Favorable, Undecided, Favorable},
+		{"light members", []strategyVote{{1, true, false}, {2, true, false}, {3, true, false}}, Undecided, Undecided, Favorable},
+		{"heavy against", []strategyVote{{0, false, false}}, Against, Against, Against},
+		{"heavy abstains", []strategyVote{{0, true, true}, {1, true, false}, {2, false, false}}, Against, Against, Against},
+		{"heavy and light", []strategyVote{{0, true, false}, {1, true, false}}, Favorable, Favorable, Favorable},
+		{"weightless member", []strategyVote{{1, true, false}, {3, false, false}}, Undecided, Undecided, Favorable},
+	}
+	for _, test := range tests {
+		votes := strategyVotes(hash, tokens, test.votes)
+		if got := collective.Consensus(hash, votes); got != test.consensus {
+			t.Errorf("%v: consensus %v, expected %v", test.name, got, test.consensus)
+		}
+		if got := collective.SuperConsensus(hash, votes); got != test.super {
+			t.Errorf("%v: super consensus %v, expected %v", test.name, got, test.super)
+		}
+		if got := collective.Expiry(hash, votes); got != test.expiry {
+			t.Errorf("%v: expiry %v, expected %v", test.name, got, test.expiry)
+		}
+	}
+	votes := strategyVotes(hash, tokens, []strategyVote{{1, true, false}, {0, true, false}})
+	if epoch := collective.ConsensusEpoch(votes); epoch != 2 {
+		t.Errorf("consensus epoch %v, expected 2", epoch)
+	}
+}
+
+func TestVetoStrategy(t *testing.T) {
+	hash := crypto.Hasher([]byte("proposal"))
+	collective, tokens := strategyCollective(actions.Strategy{Kind: actions.VetoStrategy})
+	collective.Policy.Strategy.Roles = []crypto.Token{tokens[0]}
+	tests := []struct {
+		name      string
+		votes     []strategyVote
+		consensus ConsensusState
+		super     ConsensusState
+		expiry    ConsensusState
+	}{
+		{"no votes", nil, Undecided, Undecided, Against},
+		{"waits for veto", []strategyVote{{1, true, false}, {2, true, false}, {3, true, false}}, Undecided, Undecided, Favorable},
+		{"veto holder approves", []strategyVote{{0, true, false}, {1, true, false}, {2, true, false}}, Favorable, Undecided, Favorable},
+		{"vetoed", []strategyVote{{0, false, false}, {1, true, false}, {2, true, false}, {3, true, false}}, Against, Against, Against},
+		{"veto holder abstains", []strategyVote{{0, true, true}, {1, true, false}, {2, true, false}}, Favorable, Undecided, Favorable},
+		{"majority against", []strategyVote{{0, true, false}, {1, false, false}, {2, false, false}, {3, false, false}}, Against, Against, Against},
+	}
+	for _, test := range tests {
+		votes := strategyVotes(hash, tokens, test.votes)
+		if got := collective.Consensus(hash, votes); got != test.consensus {
+			t.Errorf("%v: consensus %v, expected %v", test.name, got, test.consensus)
+		}
+		if got := collective.SuperConsensus(hash, votes); got != test.super {
+			t.Errorf("%v: super consensus %v, expected %v", test.name, got, test.super)
+		}
+		if got := collective.Expiry(hash, votes); got != test.expiry {
+			t.Errorf("%v: expiry %v, expected %v", test.name, got, test.expiry)
+		}
+	}
+	// a veto role held by someone outside the collective is ignored
+	collective.Policy.Strategy.Roles = []crypto.Token{crypto.ZeroToken}
+	votes := strategyVotes(hash, tokens, []strategyVote{{1, true, false}, {2, true, false}, {3, true, false}})
+	if collective.Consensus(hash, votes) != Favorable {
+		t.Error("Consensus waiting for a veto outside the collective")
+	}
+}
+
+func TestReviewersStrategy(t *testing.T) {
+	hash := crypto.Hasher([]byte("proposal"))
+	collective, tokens := strategyCollective(actions.Strategy{Kind: actions.ReviewersStrategy, Required: 2})
+	collective.Policy.Strategy.Roles = []crypto.Token{tokens[0], tokens[1], tokens[2]}
+	tests := []struct {
+		name      string
+		votes     []strategyVote
+		consensus ConsensusState
+		super     ConsensusState
+		expiry    ConsensusState
+	}{
+		{"no votes", nil, Undecided, Undecided, Against},
+		{"one reviewer", []strategyVote{{0, true, false}}, Undecided, Undecided, Against},
+		{"two reviewers", []strategyVote{{0, true, false}, {1, true, false}}, Favorable, Undecided, Against},
+		{"not a reviewer", []strategyVote{{0, true, false}, {3, true, false}}, Undecided, Undecided, Against},
+		{"reviewers against", []strategyVote{{0, false, false}, {1, false, false}}, Against, Against, Against},
+		{"reviewer abstains", []strategyVote{{0, false, false}, {1, true, true}}, Against, Against, Against},
+		{"all members", []strategyVote{{0, true, false}, {1, true, false}, {2, true, false}, {3, true, false}}, Favorable, Favorable, Against},
+	}
+	for _, test := range tests {
+		votes := strategyVotes(hash, tokens, test.votes)
+		if got := collective.Consensus(hash, votes); got != test.consensus {
+			t.Errorf("%v: consensus %v, expected %v", test.name, got, test.consensus)
+		}
+		if got := collective.SuperConsensus(hash, votes); got != test.super {
+			t.Errorf("%v: super consensus %v, expected %v", test.name, got, test.super)
+		}
+		if got := collective.Expiry(hash, votes); got != test.expiry {
+			t.Errorf("%v: expiry %v, expected %v", test.name, got, test.expiry)
+		}
+	}
+}
+
+func TestStrategySnapshot(t *testing.T) {
+	s := snapshotTestState(t)
+	collective := s.Collectives[crypto.Hasher([]byte("collective"))]
+	root := s.StateRoot()
+	collective.Policy.Strategy = actions.Strategy{Kind: actions.ReviewersStrategy, Roles: []crypto.Token{s.MembersIndex["other"]}, Required: 1}
+	s.touchCollective(collective.Name)
+	if s.StateRoot().Equal(root) {
+		t.Error("state root does not commit to the consensus strategy")
+	}
+	restored, err := RestoreSnapshot(s.Snapshot(), nil)
+	if err != nil {
+		t.Fatalf("Could not restore snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Collectives[crypto.Hasher([]byte("collective"))].Policy, collective.Policy) {
+		t.Error("Consensus strategy not restored")
+	}
+}
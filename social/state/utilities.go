@@ -7,15 +7,44 @@ import (
 	"github.com/lienkolabs/synergy/social/actions"
 )
 
-// verifica se eh um voto novo e se o hash bate
+// verifica se eh um voto novo (ou uma mudanca de voto) e se o hash bate
 func IsNewValidVote(vote actions.Vote, voted []actions.Vote, hash crypto.Hash) error {
 	if vote.Hash != hash {
 		return errors.New("invalid hash")
 	}
 	for _, cast := range voted {
-		if cast.Author == vote.Author {
+		if cast.Author == vote.Author && sameChoice(cast, vote) {
 			return errors.New("vote already cast")
 		}
 	}
 	return nil
 }
+
+// sameChoice checks if two votes express the same choice. The approval of an
+// abstention is irrelevant.
+func sameChoice(a, b actions.Vote) bool {
+	if a.Abstain || b.Abstain {
+		return a.Abstain == b.Abstain
+	}
+	return a.Approve == b.Approve
+}
+
+// castVote appends the vote replacing any previous vote of the same author.
+func castVote(votes []actions.Vote, vote actions.Vote) []actions.Vote {
+	cast := make([]actions.Vote, 0, len(votes)+1)
+	for _, previous := range votes {
+		if previous.Author != vote.Author {
+			cast = append(cast, previous)
+		}
+	}
+	return append(cast, vote)
+}
+
+func hasVoted(votes []actions.Vote, token crypto.Token) bool {
+	for _, vote := range votes {
+		if vote.Author == token {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"errors"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+// PendingWithdraw is a withdraw on behalf of a collective waiting for the
+// consensus of the collective.
+type PendingWithdraw struct {
+	Hash       crypto.Hash
+	Target     crypto.Hash // hash da proposta a ser retirada
+	Collective *Collective
+	Votes      []actions.Vote
+}
+
+func (p *PendingWithdraw) IncorporateVote(vote actions.Vote, state *State) error {
+	if err := IsNewValidVote(vote, p.Votes, p.Hash); err != nil {
+		return err
+	}
+	if !p.Collective.IsMember(vote.Author) {
+		return errors.New("author is not a recognized member of the collective")
+	}
+	p.Votes = castVote(p.Votes, vote)
+	consensus := p.Collective.Consensus(vote.Hash, p.Votes)
+	if consensus == Undecided {
+		return nil
+	}
+	return p.decide(consensus, state)
+}
+
+func (p *PendingWithdraw) decide(consensus ConsensusState, state *State) error {
+	// new consensus
+	state.IndexConsensus(p.Hash, consensus == Favorable)
+	state.Proposals.Delete(p.Hash)
+	// the target might have reached consensus or expired in the meantime
+	if consensus == Favorable && state.Proposals.Has(p.Target) {
+		state.withdrawProposal(p.Target)
+	}
+	return nil
+}
+
+func (s *State) Withdraw(withdraw *actions.Withdraw) error {
+	if !s.IsMember(withdraw.Author) {
+		return errors.New("not a member")
+	}
+	if !s.Proposals.Has(withdraw.Hash) {
+		return ErrProposalNotFound
+	}
+	if withdraw.OnBehalfOf == "" {
+		author, ok := s.Proposals.Author(withdraw.Hash)
+		if !ok || !author.Equal(withdraw.Author) {
+			return errors.New("not the author of the proposal")
+		}
+		s.withdrawProposal(withdraw.Hash)
+		return nil
+	}
+	collective, ok := s.Collective(withdraw.OnBehalfOf)
+	if !ok {
+		return errors.New("collective not found")
+	}
+	if !collective.IsMember(withdraw.Author) {
+		return errors.New("not a member of the collective")
+	}
+	if s.Proposals.OnBehalfOf(withdraw.Hash) != collective.Name {
+		return errors.New("proposal not on behalf of the collective")
+	}
+	hash := withdraw.Hashed()
+	vote := actions.Vote{
+		Epoch:   withdraw.Epoch,
+		Author:  withdraw.Author,
+		Reasons: "commit",
+		Hash:    hash,
+		Approve: true,
+	}
+	pending := PendingWithdraw{
+		Hash:       hash,
+		Target:     withdraw.Hash,
+		Collective: collective,
+		Votes:      []actions.Vote{},
+	}
+	s.Proposals.AddWithdraw(&pending, withdraw)
+	s.setDeadline(withdraw.Epoch+collective.VotingWindow(actions.AWithdraw), hash)
+	return pending.IncorporateVote(vote, s)
+}
+
+// withdrawProposal removes the pending proposal from the state and from the
+// votes expected by the indexer.
+func (s *State) withdrawProposal(hash crypto.Hash) {
+	// notified before the removal so that the scope of the proposal is known
+	s.Notify(WithdrawnProposal, hash)
+	if s.index != nil {
+		s.index.IndexConsensus(hash, false)
+	}
+	s.Proposals.Delete(hash)
+}
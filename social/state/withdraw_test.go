@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/lienkolabs/breeze/crypto"
+	"github.com/lienkolabs/synergy/social/actions"
+)
+
+func TestWithdraw(t *testing.T) {
+	s := snapshotTestState(t)
+	author, other := s.MembersIndex["author"], s.MembersIndex["other"]
+
+	comment := &actions.Comment{Epoch: 42, Author: author, OnBehalfOf: "collective", Object: crypto.Hasher([]byte("first")), Content: "pending"}
+	if err := s.Comment(comment); err != nil {
+		t.Fatalf("Could not comment: %v", err)
+	}
+	if err := s.Withdraw(&actions.Withdraw{Epoch: 43, Author: author, Hash: crypto.Hasher([]byte("nothing"))}); err == nil {
+		t.Error("Withdraw of unknown proposal accepted")
+	}
+	if err := s.Withdraw(&actions.Withdraw{Epoch: 43, Author: other, Hash: comment.Hashed()}); err == nil {
+		t.Error("Withdraw by another member accepted")
+	}
+	if err := s.Withdraw(&actions.Withdraw{Epoch: 43, Author: author, Hash: comment.Hashed()}); err != nil {
+		t.Fatalf("Could not withdraw: %v", err)
+	}
+	if s.Proposals.Has(comment.Hashed()) {
+		t.Error("Withdrawn proposal still pending")
+	}
+
+	third := crypto.Hasher([]byte("third"))
+	withdraw := &actions.Withdraw{Epoch: 44, Author: author, OnBehalfOf: "collective", Hash: third}
+	if err := s.Withdraw(withdraw); err != nil {
+		t.Fatalf("Could not withdraw on behalf of collective: %v", err)
+	}
+	if !s.Proposals.Has(third) || s.Proposals.Kind(withdraw.Hashed()) != WithdrawProposal {
+		t.Fatal("Proposal withdrawn without consensus")
+	}
+	restored, err := RestoreSnapshot(s.Snapshot(), nil)
+	if err != nil || !restored.StateRoot().Equal(s.StateRoot()) {
+		t.Fatalf("Pending withdraw not restored: %v", err)
+	}
+	vote := &actions.Vote{Epoch: 45, Author: other, Hash: withdraw.Hashed(), Approve: true}
+	if err := restored.Vote(vote); err != nil {
+		t.Fatalf("Could not vote: %v", err)
+	}
+	if restored.Proposals.Has(third) || restored.Proposals.Has(withdraw.Hashed()) {
+		t.Error("Proposal not withdrawn on consensus")
+	}
+}